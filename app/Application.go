@@ -31,8 +31,33 @@ var (
 	ctx            context.Context
 	cancel         context.CancelFunc
 	statsUiHandler handlers.StatsUiHandler
+	routingHandler handlers.RoutingHandler
+	presetHandler  handlers.PresetHandler
+	eventHandler   handlers.EventHandler
+	webhookHandler handlers.WebhookHandler
+	redundancyHdl  handlers.RedundancyHandler
+	settingsHdl    handlers.SettingsHandler
+	renameHdl      handlers.RenameHandler
+	namingHdl      handlers.NamingHandler
+	apiHandler     handlers.ApiHandler
+	liveHandler    handlers.LiveHandler
 	deviceRepo     repositories.DefaultDeviceRepository
+	presetRepo     repositories.DefaultPresetRepository
+	eventRepo      repositories.DefaultEventRepository
+	eventService   service.DefaultEventService
+	webhookService service.DefaultWebhookService
+	mqttService    service.DefaultMqttService
+	redundancySvc  service.DefaultRedundancyService
+	conflictSvc    service.DefaultConflictService
+	formatSvc      service.DefaultFormatService
+	broadcastSvc   service.DefaultBroadcastService
 	scanService    service.DefaultDeviceScanService
+	routingService service.DefaultRoutingService
+	settingsSvc    service.DefaultSettingsService
+	renameSvc      service.DefaultRenameService
+	namingSvc      service.DefaultNamingService
+	presetService  service.DefaultPresetService
+	jobService     service.DefaultJobService
 )
 
 // StartApp orchestrates the startup of the application
@@ -56,6 +81,7 @@ func StartApp() {
 	mapUrls()
 	RegisterForOsSignals()
 	scheduleBgJobs()
+	startMqtt()
 	go startServer()
 	go updateMetrics()
 	go scanService.Scan()
@@ -131,17 +157,83 @@ func initServer() {
 // wireApp initializes the services in the right order and injects the dependencies
 func wireApp() {
 	deviceRepo = repositories.NewDeviceRepository(&cfg)
-	statsUiHandler = handlers.NewStatsUiHandler(&cfg, &deviceRepo)
-	scanService = service.NewDeviceScanService(&cfg, &deviceRepo)
+	presetRepo = repositories.NewPresetRepository(&cfg)
+	eventRepo = repositories.NewEventRepository(&cfg)
+	conflictSvc = service.NewConflictService(&cfg, &deviceRepo)
+	formatSvc = service.NewFormatService(&cfg, &deviceRepo)
+	statsUiHandler = handlers.NewStatsUiHandler(&cfg, &deviceRepo, conflictSvc, formatSvc)
+	broadcastSvc = service.NewBroadcastService(&cfg)
+	deviceRepo.Watch(broadcastSvc.DeviceChanged)
+	webhookService = service.NewWebhookService(&cfg)
+	webhookHandler = handlers.NewWebhookHandler(&cfg, webhookService)
+	mqttService = service.NewMqttService(&cfg, &deviceRepo, broadcastSvc)
+	eventService = service.NewEventService(&cfg, &eventRepo, webhookService, mqttService)
+	eventHandler = handlers.NewEventHandler(&cfg, &eventRepo)
+	liveHandler = handlers.NewLiveHandler(&cfg, &deviceRepo, broadcastSvc)
+	scanService = service.NewDeviceScanService(&cfg, &deviceRepo, eventService, broadcastSvc, conflictSvc)
+	apiHandler = handlers.NewApiHandler(&cfg, &deviceRepo, scanService, conflictSvc, formatSvc)
+	redundancySvc = service.NewRedundancyService(&cfg, &deviceRepo)
+	redundancyHdl = handlers.NewRedundancyHandler(&cfg, redundancySvc)
+	routingService = service.NewRoutingService(&cfg, &deviceRepo)
+	routingHandler = handlers.NewRoutingHandler(&cfg, routingService)
+	settingsSvc = service.NewSettingsService(&cfg, &deviceRepo)
+	settingsHdl = handlers.NewSettingsHandler(&cfg, &deviceRepo, settingsSvc)
+	renameSvc = service.NewRenameService(&cfg, &deviceRepo)
+	renameHdl = handlers.NewRenameHandler(&cfg, renameSvc)
+	namingSvc = service.NewNamingService(&cfg, &deviceRepo, renameSvc)
+	namingHdl = handlers.NewNamingHandler(&cfg, namingSvc)
+	presetService = service.NewPresetService(&cfg, &deviceRepo, &presetRepo, routingService)
+	presetHandler = handlers.NewPresetHandler(&cfg, &presetRepo, presetService)
+	jobService = service.NewJobService(&cfg, presetService, routingService)
 }
 
 // mapUrls defines the handlers for the available URLs
 func mapUrls() {
 	cfg.RunTime.Router.GET("/", statsUiHandler.StatusPage)
 	cfg.RunTime.Router.GET("/devicelist", statsUiHandler.DeviceListPage)
+	cfg.RunTime.Router.GET("/subscriptions", statsUiHandler.SubscriptionsPage)
+	cfg.RunTime.Router.GET("/routing", statsUiHandler.RoutingPage)
+	cfg.RunTime.Router.GET("/presets", presetHandler.PresetsPage)
+	cfg.RunTime.Router.GET("/events", eventHandler.EventsPage)
+	cfg.RunTime.Router.GET("/webhooks", webhookHandler.WebhooksPage)
+	cfg.RunTime.Router.GET("/redundancy", redundancyHdl.RedundancyPage)
+	cfg.RunTime.Router.GET("/naming", namingHdl.NamingPage)
+	cfg.RunTime.Router.GET("/devices/:name/settings", settingsHdl.SettingsPage)
+	cfg.RunTime.Router.GET("/live", liveHandler.Stream)
 	cfg.RunTime.Router.GET("/logs", statsUiHandler.LogsPage)
 	cfg.RunTime.Router.GET("/about", statsUiHandler.AboutPage)
 	cfg.RunTime.Router.GET("/metrics", gin.WrapH(promhttp.Handler()))
+	cfg.RunTime.Router.GET("/api/v1/openapi.yaml", apiHandler.OpenApi)
+	cfg.RunTime.Router.GET("/api/v1/devices", apiHandler.GetDevices)
+	cfg.RunTime.Router.GET("/api/v1/devices/:name", apiHandler.GetDevice)
+	cfg.RunTime.Router.GET("/api/v1/devices/:name/settings", settingsHdl.GetSettings)
+	cfg.RunTime.Router.GET("/api/v1/status", apiHandler.GetStatus)
+	cfg.RunTime.Router.GET("/api/v1/redundancy", redundancyHdl.GetReport)
+	cfg.RunTime.Router.GET("/api/v1/naming", namingHdl.GetReport)
+	cfg.RunTime.Router.GET("/api/v1/conflicts", apiHandler.GetConflicts)
+	cfg.RunTime.Router.GET("/api/v1/formats", apiHandler.GetFormats)
+	cfg.RunTime.Router.GET("/api/v1/presets", presetHandler.GetPresets)
+	cfg.RunTime.Router.GET("/api/v1/presets/:name", presetHandler.GetPreset)
+	cfg.RunTime.Router.GET("/api/v1/presets/:name/diff", presetHandler.DiffPreset)
+
+	if cfg.Server.AdminPassword == "" {
+		logger.Warn("No admin password configured. Changes to devices are disabled.")
+		return
+	}
+	authorized := cfg.RunTime.Router.Group("/", gin.BasicAuth(gin.Accounts{
+		cfg.Server.AdminUserName: cfg.Server.AdminPassword,
+	}), handlers.RequireJson)
+	authorized.POST("/api/v1/scan", apiHandler.TriggerScan)
+	authorized.POST("/subscriptions", routingHandler.Subscribe)
+	authorized.DELETE("/subscriptions/:device/:channel", routingHandler.Unsubscribe)
+	authorized.POST("/api/v1/presets", presetHandler.SavePreset)
+	authorized.POST("/api/v1/presets/:name/recall", presetHandler.RecallPreset)
+	authorized.DELETE("/api/v1/presets/:name", presetHandler.DeletePreset)
+	authorized.PUT("/api/v1/devices/:name/settings", settingsHdl.UpdateSettings)
+	authorized.POST("/api/v1/devices/:name/identify", settingsHdl.Identify)
+	authorized.PUT("/api/v1/devices/:name/name", renameHdl.RenameDevice)
+	authorized.PUT("/api/v1/devices/:name/channels/:direction/:channel/name", renameHdl.RenameChannel)
+	authorized.POST("/api/v1/naming/apply", namingHdl.ApplyRenames)
 }
 
 // RegisterForOsSignals listens for OS signals terminating the program and sends an internal signal to start cleanup
@@ -155,10 +247,18 @@ func scheduleBgJobs() {
 	// cron format: Minutes, Hours, day of Month, Month, Day of Week
 	logger.Info("Scheduling jobs...")
 	cfg.RunTime.BgJobs = cron.New()
+	jobService.Schedule()
 	cfg.RunTime.BgJobs.Start()
 	logger.Info("Jobs scheduled")
 }
 
+// startMqtt connects to the MQTT broker, if one is configured
+func startMqtt() {
+	if err := mqttService.Start(); err != nil {
+		logger.Error("Could not connect to MQTT broker", err)
+	}
+}
+
 // startServer starts the preconfigured web server
 func startServer() {
 	logger.Infof("Listening on %v", cfg.RunTime.ListenAddr)
@@ -181,6 +281,13 @@ func cleanUp() {
 	logger.Info("Cleaning up...")
 	cfg.DeviceScan.DeviceScanRun = false
 	cfg.RunTime.BgJobs.Stop()
+	mqttService.Stop()
+	if err := deviceRepo.Close(); err != nil {
+		logger.Error("Could not close device store", err)
+	}
+	if err := eventRepo.Close(); err != nil {
+		logger.Error("Could not write event file", err)
+	}
 	shutdownTime := time.Duration(cfg.Server.GracefulShutdownTime) * time.Second
 	ctx, cancel = context.WithTimeout(context.Background(), shutdownTime)
 	defer func() {
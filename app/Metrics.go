@@ -3,20 +3,208 @@ package app
 
 import (
 	"time"
+
+	"github.com/johannes-kuhfuss/alighieri/config"
+	"github.com/johannes-kuhfuss/alighieri/domain"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	metricsNamespace = "alighieri"
+)
+
+var (
+	scanRunsTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "scan_runs_total",
+		Help:      "Number of device scan runs started",
+	})
+	scanRunning = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "scan_running",
+		Help:      "1 while a device scan is running, 0 otherwise",
+	})
+	scanDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "scan_duration_seconds",
+		Help:      "Duration of the device scan runs",
+		Buckets:   []float64{1, 2, 5, 10, 15, 20, 30, 45, 60, 90, 120},
+	})
+	devicesInList = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "devices_in_list",
+		Help:      "Number of devices in the device list",
+	})
+	devicesByState = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "devices_by_state",
+		Help:      "Number of devices in the device list per state",
+	}, []string{"state"})
+	devicesByModel = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "devices_by_model",
+		Help:      "Number of devices in the device list per manufacturer and model",
+	}, []string{"manufacturer", "model"})
+	deviceUp = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "device_up",
+		Help:      "1 if the device is online or stale, 0 if it is offline",
+	}, []string{"key", "name", "ip"})
+	deviceLastSeenAge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "device_last_seen_age_seconds",
+		Help:      "Seconds since the device was last seen",
+	}, []string{"key", "name", "ip"})
+	mdnsEntriesTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "mdns_entries_received_total",
+		Help:      "Number of mDNS entries received per service type",
+	}, []string{"service"})
+	conversionErrorsTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "conversion_errors_total",
+		Help:      "Number of mDNS entries that could not be converted to a device or channel",
+	})
+	redundancyIssues = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "redundancy_issues",
+		Help:      "Number of devices with an issue on the primary and secondary network per issue",
+	}, []string{"issue"})
+	conflicts = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "conflicts",
+		Help:      "Number of conflicts found after the last scan per conflict type",
+	}, []string{"type"})
+
+	// runtime counter values already added to the Prometheus counters
+	countedScanRuns         int
+	countedConversionErrors int
+	countedMdnsEntries      = make(map[string]int)
+
+	// label sets set during the last update, so vanished devices and models can be removed
+	deviceLabels map[[3]string]bool
+	modelLabels  map[[2]string]int
 )
 
 // initMetrics sets up the Prometheus metrics
 func initMetrics() {
+	prometheus.MustRegister(scanRunsTotal, scanRunning, scanDuration, devicesInList, devicesByState,
+		devicesByModel, deviceUp, deviceLastSeenAge, mdnsEntriesTotal, conversionErrorsTotal, redundancyIssues, conflicts)
 }
 
+// updateMetrics periodically copies the runtime statistics and the device list into the Prometheus metrics
 func updateMetrics() {
+	interval := time.Duration(cfg.Metrics.UpdateSec) * time.Second
+	if interval <= 0 {
+		interval = 3 * time.Second
+	}
 	for {
 		doUpdate()
-		time.Sleep(3 * time.Second)
+		time.Sleep(interval)
 	}
 }
 
+// doUpdate updates all metrics once
 func doUpdate() {
-	cfg.RunTime.Mu.Lock()
-	defer cfg.RunTime.Mu.Unlock()
+	updateScanMetrics(&cfg)
+	updateDeviceMetrics(&cfg, deviceRepo.GetAll(), time.Now().UTC())
+	updateRedundancyMetrics(redundancySvc.Report())
+	updateConflictMetrics(conflictSvc.Report())
+}
+
+// updateScanMetrics adds the scan statistics collected since the last update to the metrics
+func updateScanMetrics(appCfg *config.AppConfig) {
+	appCfg.RunTime.Mu.Lock()
+	defer appCfg.RunTime.Mu.Unlock()
+	if runs := appCfg.RunTime.DeviceScanNumber - countedScanRuns; runs > 0 {
+		scanRunsTotal.Add(float64(runs))
+	}
+	countedScanRuns = appCfg.RunTime.DeviceScanNumber
+	if appCfg.RunTime.DeviceScanRunning {
+		scanRunning.Set(1)
+	} else {
+		scanRunning.Set(0)
+	}
+	for _, dur := range appCfg.RunTime.ScanDurations {
+		scanDuration.Observe(dur.Seconds())
+	}
+	appCfg.RunTime.ScanDurations = nil
+	for service, entries := range appCfg.RunTime.MdnsEntries {
+		if added := entries - countedMdnsEntries[service]; added > 0 {
+			mdnsEntriesTotal.WithLabelValues(service).Add(float64(added))
+		}
+		countedMdnsEntries[service] = entries
+	}
+	if errs := appCfg.RunTime.ConversionErrors - countedConversionErrors; errs > 0 {
+		conversionErrorsTotal.Add(float64(errs))
+	}
+	countedConversionErrors = appCfg.RunTime.ConversionErrors
+}
+
+// updateDeviceMetrics sets the device metrics from the device list. The per-device gauges are labelled with the device's identity, as devices may share name and IP.
+// Label sets of devices no longer in the list are removed
+func updateDeviceMetrics(appCfg *config.AppConfig, devices *domain.DeviceList, now time.Time) {
+	staleAfter, offlineAfter := config.DeviceStateThresholds(appCfg)
+	states := map[string]int{
+		string(domain.DeviceOnline):  0,
+		string(domain.DeviceStale):   0,
+		string(domain.DeviceOffline): 0,
+	}
+	models := make(map[[2]string]int)
+	seen := make(map[[3]string]bool)
+	if devices != nil {
+		for _, device := range *devices {
+			state := device.State(now, staleAfter, offlineAfter)
+			ip := ""
+			if device.IPv4 != nil {
+				ip = device.IPv4.String()
+			}
+			states[string(state)]++
+			models[[2]string{device.Manufacturer, device.Model}]++
+			labels := [3]string{device.Key(), device.Name, ip}
+			seen[labels] = true
+			if state == domain.DeviceOffline {
+				deviceUp.WithLabelValues(labels[:]...).Set(0)
+			} else {
+				deviceUp.WithLabelValues(labels[:]...).Set(1)
+			}
+			deviceLastSeenAge.WithLabelValues(labels[:]...).Set(now.Sub(device.LastSeen).Seconds())
+		}
+		devicesInList.Set(float64(len(*devices)))
+	} else {
+		devicesInList.Set(0)
+	}
+	for state, count := range states {
+		devicesByState.WithLabelValues(state).Set(float64(count))
+	}
+	for labels, count := range models {
+		devicesByModel.WithLabelValues(labels[:]...).Set(float64(count))
+	}
+	for labels := range deviceLabels {
+		if !seen[labels] {
+			deviceUp.DeleteLabelValues(labels[:]...)
+			deviceLastSeenAge.DeleteLabelValues(labels[:]...)
+		}
+	}
+	for labels := range modelLabels {
+		if _, ok := models[labels]; !ok {
+			devicesByModel.DeleteLabelValues(labels[:]...)
+		}
+	}
+	deviceLabels = seen
+	modelLabels = models
+}
+
+// updateRedundancyMetrics sets the number of findings per issue from the redundancy report
+func updateRedundancyMetrics(report domain.RedundancyReport) {
+	for _, issue := range domain.RedundancyIssues {
+		redundancyIssues.WithLabelValues(string(issue)).Set(float64(report.Count(issue)))
+	}
+}
+
+// updateConflictMetrics sets the number of conflicts per type from the last conflict analysis
+func updateConflictMetrics(report domain.ConflictReport) {
+	for _, conflictType := range domain.ConflictTypes {
+		conflicts.WithLabelValues(string(conflictType)).Set(float64(report.Count(conflictType)))
+	}
 }
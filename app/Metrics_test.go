@@ -0,0 +1,123 @@
+package app
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/johannes-kuhfuss/alighieri/config"
+	"github.com/johannes-kuhfuss/alighieri/domain"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUpdateDeviceMetricsSetsStateAndDeviceGauges(t *testing.T) {
+	var testCfg config.AppConfig
+	config.InitConfig("", &testCfg)
+	now := time.Now().UTC()
+	devices := domain.DeviceList{
+		{Name: "stagebox", IPv4: net.ParseIP("192.168.1.10"), Manufacturer: "Audinate", Model: "DAI2", LastSeen: now},
+		{Name: "amp", IPv4: net.ParseIP("192.168.1.11"), Manufacturer: "Audinate", Model: "DAI2", LastSeen: now.Add(-24 * time.Hour)},
+	}
+
+	updateDeviceMetrics(&testCfg, &devices, now)
+
+	assert.EqualValues(t, 2, testutil.ToFloat64(devicesInList))
+	assert.EqualValues(t, 1, testutil.ToFloat64(devicesByState.WithLabelValues("online")))
+	assert.EqualValues(t, 1, testutil.ToFloat64(devicesByState.WithLabelValues("offline")))
+	assert.EqualValues(t, 2, testutil.ToFloat64(devicesByModel.WithLabelValues("Audinate", "DAI2")))
+	assert.EqualValues(t, 1, testutil.ToFloat64(deviceUp.WithLabelValues("stagebox", "stagebox", "192.168.1.10")))
+	assert.EqualValues(t, 0, testutil.ToFloat64(deviceUp.WithLabelValues("amp", "amp", "192.168.1.11")))
+	assert.EqualValues(t, 86400, testutil.ToFloat64(deviceLastSeenAge.WithLabelValues("amp", "amp", "192.168.1.11")))
+}
+
+func TestUpdateDeviceMetricsRemovesVanishedDevices(t *testing.T) {
+	var testCfg config.AppConfig
+	config.InitConfig("", &testCfg)
+	now := time.Now().UTC()
+	devices := domain.DeviceList{
+		{Name: "stagebox", IPv4: net.ParseIP("192.168.1.10"), LastSeen: now},
+	}
+	updateDeviceMetrics(&testCfg, &devices, now)
+
+	updateDeviceMetrics(&testCfg, nil, now)
+
+	assert.EqualValues(t, 0, testutil.ToFloat64(devicesInList))
+	assert.EqualValues(t, 0, testutil.CollectAndCount(deviceUp))
+	assert.EqualValues(t, 0, testutil.CollectAndCount(deviceLastSeenAge))
+	assert.EqualValues(t, 0, testutil.CollectAndCount(devicesByModel))
+}
+
+func TestUpdateDeviceMetricsKeepsDevicesWithSameNameAndIp(t *testing.T) {
+	var testCfg config.AppConfig
+	config.InitConfig("", &testCfg)
+	now := time.Now().UTC()
+	devices := domain.DeviceList{
+		{Name: "stagebox", Id: "001dc1fffe000001", IPv4: net.ParseIP("192.168.1.10"), LastSeen: now},
+		{Name: "stagebox", Id: "001dc1fffe000002", IPv4: net.ParseIP("192.168.1.10"), LastSeen: now.Add(-24 * time.Hour)},
+	}
+	updateDeviceMetrics(&testCfg, &devices, now)
+
+	assert.EqualValues(t, 2, testutil.CollectAndCount(deviceUp))
+	assert.EqualValues(t, 1, testutil.ToFloat64(deviceUp.WithLabelValues("001dc1fffe000001", "stagebox", "192.168.1.10")))
+	assert.EqualValues(t, 0, testutil.ToFloat64(deviceUp.WithLabelValues("001dc1fffe000002", "stagebox", "192.168.1.10")))
+
+	devices = devices[:1]
+	updateDeviceMetrics(&testCfg, &devices, now)
+
+	assert.EqualValues(t, 1, testutil.CollectAndCount(deviceUp))
+	assert.EqualValues(t, 1, testutil.CollectAndCount(deviceLastSeenAge))
+}
+
+func TestUpdateScanMetricsAddsNewCountsOnly(t *testing.T) {
+	var testCfg config.AppConfig
+	config.InitConfig("", &testCfg)
+	runsBefore := testutil.ToFloat64(scanRunsTotal)
+	entriesBefore := testutil.ToFloat64(mdnsEntriesTotal.WithLabelValues(domain.ServiceArc))
+	countedScanRuns = 0
+	countedConversionErrors = 0
+	countedMdnsEntries = make(map[string]int)
+	testCfg.RunTime.DeviceScanNumber = 2
+	testCfg.RunTime.MdnsEntries = map[string]int{domain.ServiceArc: 5}
+	testCfg.RunTime.ScanDurations = []time.Duration{3 * time.Second}
+
+	updateScanMetrics(&testCfg)
+	updateScanMetrics(&testCfg)
+
+	assert.EqualValues(t, runsBefore+2, testutil.ToFloat64(scanRunsTotal))
+	assert.EqualValues(t, entriesBefore+5, testutil.ToFloat64(mdnsEntriesTotal.WithLabelValues(domain.ServiceArc)))
+	assert.Nil(t, testCfg.RunTime.ScanDurations)
+}
+
+func TestUpdateRedundancyMetricsSetsIssueCounts(t *testing.T) {
+	report := domain.RedundancyReport{
+		Enabled: true,
+		Findings: []domain.RedundancyFinding{
+			{Device: "stagebox", Issue: domain.RedundancySingleNetwork},
+			{Device: "amp", Issue: domain.RedundancySingleNetwork},
+			{Device: "mixer", Issue: domain.RedundancySameSubnet},
+		},
+	}
+
+	updateRedundancyMetrics(report)
+
+	assert.EqualValues(t, 2, testutil.ToFloat64(redundancyIssues.WithLabelValues("single-network")))
+	assert.EqualValues(t, 0, testutil.ToFloat64(redundancyIssues.WithLabelValues("wrong-subnet")))
+	assert.EqualValues(t, 1, testutil.ToFloat64(redundancyIssues.WithLabelValues("same-subnet")))
+}
+
+func TestUpdateConflictMetricsSetsConflictCounts(t *testing.T) {
+	report := domain.ConflictReport{
+		Conflicts: []domain.Conflict{
+			{Type: domain.ConflictDuplicateIp, Devices: []string{"amp", "stagebox"}},
+			{Type: domain.ConflictLinkLocal, Devices: []string{"mixer"}},
+		},
+	}
+
+	updateConflictMetrics(report)
+
+	assert.EqualValues(t, 0, testutil.ToFloat64(conflicts.WithLabelValues("duplicate-name")))
+	assert.EqualValues(t, 1, testutil.ToFloat64(conflicts.WithLabelValues("duplicate-ip")))
+	assert.EqualValues(t, 1, testutil.ToFloat64(conflicts.WithLabelValues("link-local")))
+	assert.EqualValues(t, 0, testutil.ToFloat64(conflicts.WithLabelValues("outside-subnet")))
+}
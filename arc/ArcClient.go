@@ -0,0 +1,319 @@
+// package arc implements a client for the Dante audio routing control (ARC) protocol spoken on the _netaudio-arc._udp port
+//
+// Each request and response starts with a 10 byte header, all values big endian:
+//
+//	0-1  protocol id (0x27ff)
+//	2-3  length of the whole packet
+//	4-5  sequence number, echoed by the device
+//	6-7  opcode
+//	8-9  zero in requests, result code in responses (0x0001 = success)
+//
+// Strings in responses are null-terminated and referenced by their offset from the start of the packet.
+package arc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"sync/atomic"
+	"time"
+
+	"github.com/johannes-kuhfuss/alighieri/domain"
+)
+
+const (
+	protocolArc  uint16 = 0x27ff
+	headerLength        = 10
+	resultOk     uint16 = 0x0001
+	pageSize            = 16
+	maxPacket           = 2048
+
+	opChannelCount     uint16 = 0x1000
+	opSetDeviceName    uint16 = 0x1001
+	opDeviceName       uint16 = 0x1002
+	opTxChannels       uint16 = 0x2000
+	opTxChannelNames   uint16 = 0x2010
+	opSetTxChannelName uint16 = 0x2013
+	opRxChannels       uint16 = 0x3000
+	opSetRxChannelName uint16 = 0x3001
+	opSubscribe        uint16 = 0x3010
+	opUnsubscribe      uint16 = 0x3014
+
+	txChannelRecordLength     = 8
+	txChannelNameRecordLength = 6
+	rxChannelRecordLength     = 20
+)
+
+type ArcClient interface {
+	GetDeviceName() (string, error)
+	GetChannelCount() (int, int, error)
+	GetTxChannels(int) (domain.ChannelList, error)
+	GetRxChannels(int) (domain.ChannelList, error)
+	Subscribe(int, string, string) error
+	Unsubscribe(int) error
+	SetDeviceName(string) error
+	SetChannelName(domain.ChannelDirection, int, string) error
+}
+
+// The DefaultArcClient talks to the ARC port of a single device
+type DefaultArcClient struct {
+	Addr    *net.UDPAddr
+	Timeout time.Duration
+}
+
+var (
+	sequence atomic.Uint32
+)
+
+// NewArcClient creates a new ARC client for the device reachable at the given address and port
+func NewArcClient(ip net.IP, port int, timeout time.Duration) DefaultArcClient {
+	return DefaultArcClient{
+		Addr:    &net.UDPAddr{IP: ip, Port: port},
+		Timeout: timeout,
+	}
+}
+
+// GetDeviceName queries the name the device uses on the Dante network
+func (c DefaultArcClient) GetDeviceName() (string, error) {
+	resp, err := c.request(opDeviceName, nil)
+	if err != nil {
+		return "", err
+	}
+	return readString(resp, headerLength), nil
+}
+
+// GetChannelCount queries the number of transmit and receive channels of the device
+func (c DefaultArcClient) GetChannelCount() (txCount int, rxCount int, err error) {
+	resp, err := c.request(opChannelCount, nil)
+	if err != nil {
+		return 0, 0, err
+	}
+	if len(resp) < headerLength+6 {
+		return 0, 0, errors.New("channel count response too short")
+	}
+	txCount = int(binary.BigEndian.Uint16(resp[12:]))
+	rxCount = int(binary.BigEndian.Uint16(resp[14:]))
+	return txCount, rxCount, nil
+}
+
+// GetTxChannels queries the given number of transmit channels of the device, as returned by GetChannelCount. Friendly names set by the user take precedence over the default names
+func (c DefaultArcClient) GetTxChannels(txCount int) (domain.ChannelList, error) {
+	var channels domain.ChannelList
+	for start := 1; start <= txCount; start += pageSize {
+		resp, err := c.request(opTxChannels, pageArgs(start))
+		if err != nil {
+			return nil, err
+		}
+		err = forEachRecord(resp, txChannelRecordLength, func(rec []byte) {
+			channels = append(channels, domain.ChannelInfo{
+				Direction:  domain.ChannelTx,
+				Number:     int(binary.BigEndian.Uint16(rec[0:])),
+				SampleRate: readUint32(resp, binary.BigEndian.Uint16(rec[4:])),
+				Name:       readString(resp, binary.BigEndian.Uint16(rec[6:])),
+			})
+		})
+		if err != nil {
+			return nil, err
+		}
+		resp, err = c.request(opTxChannelNames, pageArgs(start))
+		if err != nil {
+			return nil, err
+		}
+		err = forEachRecord(resp, txChannelNameRecordLength, func(rec []byte) {
+			channel := channels.GetByNumber(int(binary.BigEndian.Uint16(rec[2:])))
+			if name := readString(resp, binary.BigEndian.Uint16(rec[4:])); channel != nil && name != "" {
+				channel.Name = name
+			}
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return channels, nil
+}
+
+// GetRxChannels queries the given number of receive channels of the device, as returned by GetChannelCount, including their current subscriptions
+func (c DefaultArcClient) GetRxChannels(rxCount int) (domain.ChannelList, error) {
+	var channels domain.ChannelList
+	for start := 1; start <= rxCount; start += pageSize {
+		resp, err := c.request(opRxChannels, pageArgs(start))
+		if err != nil {
+			return nil, err
+		}
+		err = forEachRecord(resp, rxChannelRecordLength, func(rec []byte) {
+			channels = append(channels, domain.ChannelInfo{
+				Direction:          domain.ChannelRx,
+				Number:             int(binary.BigEndian.Uint16(rec[0:])),
+				SampleRate:         readUint32(resp, binary.BigEndian.Uint16(rec[4:])),
+				TxChannelName:      readString(resp, binary.BigEndian.Uint16(rec[6:])),
+				TxDeviceName:       readString(resp, binary.BigEndian.Uint16(rec[8:])),
+				Name:               readString(resp, binary.BigEndian.Uint16(rec[10:])),
+				SubscriptionStatus: domain.SubscriptionStatus(binary.BigEndian.Uint16(rec[14:])),
+			})
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return channels, nil
+}
+
+// Subscribe subscribes the receive channel with the given number to a transmit channel identified by channel and device name
+//
+// Arguments: record count (1), receive channel number, offset of the transmit channel name, offset of the transmit device name, 4 reserved bytes, followed by the names
+func (c DefaultArcClient) Subscribe(rxChannel int, txChannelName string, txDeviceName string) error {
+	const recordEnd = headerLength + 12
+	args := make([]byte, recordEnd-headerLength, recordEnd-headerLength+len(txChannelName)+len(txDeviceName)+2)
+	binary.BigEndian.PutUint16(args[0:], 1)
+	binary.BigEndian.PutUint16(args[2:], uint16(rxChannel))
+	binary.BigEndian.PutUint16(args[4:], recordEnd)
+	binary.BigEndian.PutUint16(args[6:], uint16(recordEnd+len(txChannelName)+1))
+	args = append(args, txChannelName...)
+	args = append(args, 0)
+	args = append(args, txDeviceName...)
+	args = append(args, 0)
+	_, err := c.request(opSubscribe, args)
+	return err
+}
+
+// Unsubscribe clears the subscription of the receive channel with the given number
+//
+// Arguments: record count (1), receive channel number
+func (c DefaultArcClient) Unsubscribe(rxChannel int) error {
+	args := make([]byte, 4)
+	binary.BigEndian.PutUint16(args[0:], 1)
+	binary.BigEndian.PutUint16(args[2:], uint16(rxChannel))
+	_, err := c.request(opUnsubscribe, args)
+	return err
+}
+
+// SetDeviceName changes the name the device uses on the Dante network. The device re-advertises itself under the new name
+//
+// Arguments: the new name, null-terminated
+func (c DefaultArcClient) SetDeviceName(name string) error {
+	args := append([]byte(name), 0)
+	_, err := c.request(opSetDeviceName, args)
+	return err
+}
+
+// SetChannelName changes the label of the transmit or receive channel with the given number
+//
+// Arguments: record count (1), channel number, offset of the new name, followed by the name
+func (c DefaultArcClient) SetChannelName(direction domain.ChannelDirection, channel int, name string) error {
+	opcode := opSetRxChannelName
+	if direction == domain.ChannelTx {
+		opcode = opSetTxChannelName
+	}
+	const recordEnd = headerLength + 6
+	args := make([]byte, recordEnd-headerLength, recordEnd-headerLength+len(name)+1)
+	binary.BigEndian.PutUint16(args[0:], 1)
+	binary.BigEndian.PutUint16(args[2:], uint16(channel))
+	binary.BigEndian.PutUint16(args[4:], recordEnd)
+	args = append(args, name...)
+	args = append(args, 0)
+	_, err := c.request(opcode, args)
+	return err
+}
+
+// request sends a request to the device and waits for the matching response
+func (c DefaultArcClient) request(opcode uint16, args []byte) ([]byte, error) {
+	conn, err := net.DialUDP("udp", nil, c.Addr)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	seq := uint16(sequence.Add(1))
+	if _, err := conn.Write(buildPacket(opcode, seq, args)); err != nil {
+		return nil, err
+	}
+	if err := conn.SetReadDeadline(time.Now().Add(c.Timeout)); err != nil {
+		return nil, err
+	}
+	buf := make([]byte, maxPacket)
+	for {
+		n, err := conn.Read(buf)
+		if err != nil {
+			return nil, fmt.Errorf("no response from %v for opcode 0x%04x: %w", c.Addr, opcode, err)
+		}
+		resp := buf[:n]
+		if len(resp) < headerLength || binary.BigEndian.Uint16(resp[4:]) != seq {
+			// stale or foreign packet, keep waiting for ours
+			continue
+		}
+		return checkResponse(resp, opcode)
+	}
+}
+
+// buildPacket assembles a request packet
+func buildPacket(opcode uint16, seq uint16, args []byte) []byte {
+	packet := make([]byte, headerLength+len(args))
+	binary.BigEndian.PutUint16(packet[0:], protocolArc)
+	binary.BigEndian.PutUint16(packet[2:], uint16(len(packet)))
+	binary.BigEndian.PutUint16(packet[4:], seq)
+	binary.BigEndian.PutUint16(packet[6:], opcode)
+	copy(packet[headerLength:], args)
+	return packet
+}
+
+// checkResponse validates the header of a response
+func checkResponse(resp []byte, opcode uint16) ([]byte, error) {
+	if binary.BigEndian.Uint16(resp[0:]) != protocolArc {
+		return nil, fmt.Errorf("unexpected protocol id 0x%04x", binary.BigEndian.Uint16(resp[0:]))
+	}
+	if int(binary.BigEndian.Uint16(resp[2:])) != len(resp) {
+		return nil, fmt.Errorf("length field %v does not match packet length %v", binary.BigEndian.Uint16(resp[2:]), len(resp))
+	}
+	if binary.BigEndian.Uint16(resp[6:]) != opcode {
+		return nil, fmt.Errorf("unexpected opcode 0x%04x in response to 0x%04x", binary.BigEndian.Uint16(resp[6:]), opcode)
+	}
+	if result := binary.BigEndian.Uint16(resp[8:]); result != resultOk {
+		return nil, fmt.Errorf("device returned result code 0x%04x for opcode 0x%04x", result, opcode)
+	}
+	return resp, nil
+}
+
+// pageArgs returns the arguments requesting a page of channels starting with the given channel number
+func pageArgs(start int) []byte {
+	args := make([]byte, 8)
+	binary.BigEndian.PutUint16(args[2:], 1)
+	binary.BigEndian.PutUint16(args[4:], uint16(start))
+	return args
+}
+
+// forEachRecord calls fn for each channel record of a paged response. Byte 10 holds the number of records, which start at byte 12
+func forEachRecord(resp []byte, recordLength int, fn func([]byte)) error {
+	if len(resp) < headerLength+2 {
+		return errors.New("channel response too short")
+	}
+	count := int(resp[headerLength])
+	for i := 0; i < count; i++ {
+		pos := headerLength + 2 + i*recordLength
+		if pos+recordLength > len(resp) {
+			return fmt.Errorf("channel record %v exceeds response length", i+1)
+		}
+		fn(resp[pos : pos+recordLength])
+	}
+	return nil
+}
+
+// readString reads a null-terminated string starting at the given offset. Returns an empty string for offset 0 or invalid offsets
+func readString(packet []byte, offset uint16) string {
+	if offset == 0 || int(offset) >= len(packet) {
+		return ""
+	}
+	s := packet[offset:]
+	if end := bytes.IndexByte(s, 0); end >= 0 {
+		s = s[:end]
+	}
+	return string(s)
+}
+
+// readUint32 reads a 32 bit value at the given offset. Returns 0 for offset 0 or invalid offsets
+func readUint32(packet []byte, offset uint16) int {
+	if offset == 0 || int(offset)+4 > len(packet) {
+		return 0
+	}
+	return int(binary.BigEndian.Uint32(packet[offset:]))
+}
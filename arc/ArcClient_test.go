@@ -0,0 +1,247 @@
+package arc
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/johannes-kuhfuss/alighieri/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+// standIn replays recorded device responses from the testdata folder. Responses are looked up by opcode and, for paged requests, by start channel
+type standIn struct {
+	mu        sync.Mutex
+	conn      *net.UDPConn
+	responses map[string][]byte
+	requests  [][]byte
+}
+
+func loadResponses(t *testing.T) map[string][]byte {
+	files, err := filepath.Glob("testdata/*.hex")
+	if err != nil {
+		t.Fatal(err)
+	}
+	responses := make(map[string][]byte)
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := hex.DecodeString(strings.ReplaceAll(strings.TrimSpace(string(data)), "\n", ""))
+		if err != nil {
+			t.Fatalf("could not decode %v: %v", file, err)
+		}
+		responses[strings.TrimSuffix(filepath.Base(file), ".hex")] = resp
+	}
+	return responses
+}
+
+func startStandIn(t *testing.T, responses map[string][]byte) *standIn {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := standIn{
+		conn:      conn,
+		responses: responses,
+	}
+	go s.serve()
+	t.Cleanup(func() {
+		conn.Close()
+	})
+	return &s
+}
+
+func (s *standIn) serve() {
+	buf := make([]byte, maxPacket)
+	for {
+		n, addr, err := s.conn.ReadFromUDP(buf)
+		if err != nil {
+			return
+		}
+		req := append([]byte(nil), buf[:n]...)
+		s.mu.Lock()
+		s.requests = append(s.requests, req)
+		s.mu.Unlock()
+		opcode := binary.BigEndian.Uint16(req[6:])
+		key := fmt.Sprintf("%04x", opcode)
+		if opcode == opTxChannels || opcode == opTxChannelNames || opcode == opRxChannels {
+			key = fmt.Sprintf("%v-%04x", key, binary.BigEndian.Uint16(req[headerLength+4:]))
+		}
+		resp, ok := s.responses[key]
+		if !ok {
+			continue
+		}
+		resp = append([]byte(nil), resp...)
+		copy(resp[4:6], req[4:6])
+		s.conn.WriteToUDP(resp, addr)
+	}
+}
+
+func (s *standIn) received() [][]byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests
+}
+
+func (s *standIn) client() DefaultArcClient {
+	addr := s.conn.LocalAddr().(*net.UDPAddr)
+	return NewArcClient(addr.IP, addr.Port, 500*time.Millisecond)
+}
+
+func TestGetDeviceNameReturnsName(t *testing.T) {
+	s := startStandIn(t, loadResponses(t))
+	name, err := s.client().GetDeviceName()
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, "stagebox-1", name)
+}
+
+func TestGetChannelCountReturnsCounts(t *testing.T) {
+	s := startStandIn(t, loadResponses(t))
+	tx, rx, err := s.client().GetChannelCount()
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, 2, tx)
+	assert.EqualValues(t, 2, rx)
+}
+
+func TestGetTxChannelsPrefersFriendlyNames(t *testing.T) {
+	s := startStandIn(t, loadResponses(t))
+	channels, err := s.client().GetTxChannels(2)
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, 2, len(channels))
+	assert.EqualValues(t, "Vocal L", channels[0].Name)
+	assert.EqualValues(t, "02", channels[1].Name)
+	assert.EqualValues(t, 48000, channels[0].SampleRate)
+	assert.EqualValues(t, domain.ChannelTx, channels[0].Direction)
+}
+
+func TestGetTxChannelsDoesNotQueryChannelCount(t *testing.T) {
+	s := startStandIn(t, loadResponses(t))
+	s.client().GetTxChannels(2)
+
+	for _, req := range s.received() {
+		assert.NotEqualValues(t, opChannelCount, binary.BigEndian.Uint16(req[6:]))
+	}
+}
+
+func TestGetRxChannelsReturnsSubscriptions(t *testing.T) {
+	s := startStandIn(t, loadResponses(t))
+	channels, err := s.client().GetRxChannels(2)
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, 2, len(channels))
+	assert.EqualValues(t, "In 1", channels[0].Name)
+	assert.EqualValues(t, "Vocal L", channels[0].TxChannelName)
+	assert.EqualValues(t, "mixer", channels[0].TxDeviceName)
+	assert.EqualValues(t, domain.SubscriptionUnicast, channels[0].SubscriptionStatus)
+	assert.True(t, channels[0].IsSubscribed())
+	assert.False(t, channels[1].IsSubscribed())
+}
+
+func TestRequestSendsValidHeader(t *testing.T) {
+	s := startStandIn(t, loadResponses(t))
+	s.client().GetDeviceName()
+
+	requests := s.received()
+	assert.EqualValues(t, 1, len(requests))
+	req := requests[0]
+	assert.EqualValues(t, protocolArc, binary.BigEndian.Uint16(req[0:]))
+	assert.EqualValues(t, len(req), binary.BigEndian.Uint16(req[2:]))
+	assert.EqualValues(t, opDeviceName, binary.BigEndian.Uint16(req[6:]))
+}
+
+func TestRequestNoResponseReturnsError(t *testing.T) {
+	s := startStandIn(t, map[string][]byte{})
+	_, err := s.client().GetDeviceName()
+
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "no response from")
+}
+
+func TestRequestErrorResultReturnsError(t *testing.T) {
+	responses := loadResponses(t)
+	resp := append([]byte(nil), responses["1002"]...)
+	binary.BigEndian.PutUint16(resp[8:], 0x0022)
+	responses["1002"] = resp
+	s := startStandIn(t, responses)
+	_, err := s.client().GetDeviceName()
+
+	assert.NotNil(t, err)
+	assert.EqualValues(t, "device returned result code 0x0022 for opcode 0x1002", err.Error())
+}
+
+func TestReadStringInvalidOffsetReturnsEmpty(t *testing.T) {
+	assert.EqualValues(t, "", readString([]byte{1, 2, 3}, 0))
+	assert.EqualValues(t, "", readString([]byte{1, 2, 3}, 5))
+}
+
+func TestSubscribeSendsNames(t *testing.T) {
+	s := startStandIn(t, loadResponses(t))
+	err := s.client().Subscribe(2, "Vocal L", "mixer")
+
+	assert.Nil(t, err)
+	requests := s.received()
+	assert.EqualValues(t, 1, len(requests))
+	req := requests[0]
+	assert.EqualValues(t, opSubscribe, binary.BigEndian.Uint16(req[6:]))
+	assert.EqualValues(t, 2, binary.BigEndian.Uint16(req[12:]))
+	assert.EqualValues(t, "Vocal L", readString(req, binary.BigEndian.Uint16(req[14:])))
+	assert.EqualValues(t, "mixer", readString(req, binary.BigEndian.Uint16(req[16:])))
+}
+
+func TestUnsubscribeSendsChannel(t *testing.T) {
+	s := startStandIn(t, loadResponses(t))
+	err := s.client().Unsubscribe(1)
+
+	assert.Nil(t, err)
+	req := s.received()[0]
+	assert.EqualValues(t, opUnsubscribe, binary.BigEndian.Uint16(req[6:]))
+	assert.EqualValues(t, 1, binary.BigEndian.Uint16(req[12:]))
+}
+
+func TestSubscribeRejectedReturnsError(t *testing.T) {
+	responses := loadResponses(t)
+	responses["3010"] = responses["3010-rejected"]
+	s := startStandIn(t, responses)
+	err := s.client().Subscribe(9, "Vocal L", "mixer")
+
+	assert.NotNil(t, err)
+	assert.EqualValues(t, "device returned result code 0x8112 for opcode 0x3010", err.Error())
+}
+
+func TestSetDeviceNameSendsName(t *testing.T) {
+	s := startStandIn(t, loadResponses(t))
+	err := s.client().SetDeviceName("stagebox-2")
+
+	assert.Nil(t, err)
+	req := s.received()[0]
+	assert.EqualValues(t, opSetDeviceName, binary.BigEndian.Uint16(req[6:]))
+	assert.EqualValues(t, "stagebox-2", readString(req, headerLength))
+}
+
+func TestSetChannelNameUsesOpcodePerDirection(t *testing.T) {
+	s := startStandIn(t, loadResponses(t))
+	errTx := s.client().SetChannelName(domain.ChannelTx, 2, "Vocal R")
+	errRx := s.client().SetChannelName(domain.ChannelRx, 1, "Ambience")
+
+	assert.Nil(t, errTx)
+	assert.Nil(t, errRx)
+	requests := s.received()
+	assert.EqualValues(t, 2, len(requests))
+	assert.EqualValues(t, opSetTxChannelName, binary.BigEndian.Uint16(requests[0][6:]))
+	assert.EqualValues(t, 2, binary.BigEndian.Uint16(requests[0][12:]))
+	assert.EqualValues(t, "Vocal R", readString(requests[0], binary.BigEndian.Uint16(requests[0][14:])))
+	assert.EqualValues(t, opSetRxChannelName, binary.BigEndian.Uint16(requests[1][6:]))
+	assert.EqualValues(t, "Ambience", readString(requests[1], binary.BigEndian.Uint16(requests[1][14:])))
+}
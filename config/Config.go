@@ -7,6 +7,9 @@ import (
 	"net"
 	"os"
 	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
 	"sync"
 	"time"
 
@@ -27,6 +30,8 @@ type AppConfig struct {
 		CertFile             string `envconfig:"CERT_FILE" default:"./cert/cert.pem"`
 		KeyFile              string `envconfig:"KEY_FILE" default:"./cert/cert.key"`
 		LogFile              string `envconfig:"LOG_FILE"` // leave empty to disable logging to file
+		AdminUserName        string `envconfig:"ADMIN_USER_NAME" default:"admin"`
+		AdminPassword        string `envconfig:"ADMIN_PASSWORD"` // leave empty to disable all changes to devices
 	}
 	Gin struct {
 		Mode         string `envconfig:"GIN_MODE" default:"release"`
@@ -34,34 +39,254 @@ type AppConfig struct {
 		LogToLogger  bool   `envconfig:"LOG_TO_LOGGER" default:"false"`
 	}
 	DeviceScan struct {
-		ScanCycleSec   int    `envconfig:"SCAN_CYCLE_SEC" default:"10"`
-		ScanTimeOutSec int    `envconfig:"SCAN_TIME_OUT_SEC" default:"5"`
-		InterfaceName  string `envconfig:"INTERFACE_NAME"`                                // Leave mepty to use default interface
-		ServiceName    string `envconfig:"SERVICE_NAME" default:"_services._dns-sd._udp"` // _services._dns-sd._udp
-		DeviceScanRun  bool
+		ScanCycleSec        int      `envconfig:"SCAN_CYCLE_SEC" default:"10"`
+		ScanTimeOutSec      int      `envconfig:"SCAN_TIME_OUT_SEC" default:"5"`
+		InterfaceNames      []string `envconfig:"INTERFACE_NAME"`                                                                                       // comma-separated, e.g. primary and secondary Dante network. Leave empty to use default interface
+		ServiceName         string   `envconfig:"SERVICE_NAME"`                                                                                         // deprecated, added to SERVICE_NAMES or used instead of it if SERVICE_NAMES is not set. Its old default is ignored
+		ServiceNames        []string `envconfig:"SERVICE_NAMES" default:"_netaudio-arc._udp,_netaudio-cmc._udp,_netaudio-dbc._udp,_netaudio-chan._udp"` // queried at the same time in each scan cycle
+		ArcQuery            bool     `envconfig:"ARC_QUERY" default:"true"`                                                                             // query device details via the ARC control protocol after discovery
+		ArcTimeOutMs        int      `envconfig:"ARC_TIME_OUT_MS" default:"1000"`
+		SettingsQuery       bool     `envconfig:"SETTINGS_QUERY" default:"true"` // read sample rate, encoding, latency and pull-up/down from the device settings port after discovery
+		SettingsPort        int      `envconfig:"SETTINGS_PORT" default:"8700"`  // not advertised via mDNS
+		SettingsTimeOutMs   int      `envconfig:"SETTINGS_TIME_OUT_MS" default:"1000"`
+		IdentifyThrottleSec int      `envconfig:"IDENTIFY_THROTTLE_SEC" default:"5"`  // further identify requests for the same device are rejected for this long
+		StaleAfter          int      `envconfig:"STALE_AFTER_CYCLES" default:"2"`     // number of missed scan cycles after which a device is shown as stale
+		OfflineAfter        int      `envconfig:"OFFLINE_AFTER_CYCLES" default:"5"`   // number of missed scan cycles after which a device is shown as offline
+		RetentionHours      int      `envconfig:"RETENTION_HOURS" default:"0"`        // devices not seen for this long are removed from the list. 0 keeps them forever
+		Goodbyes            bool     `envconfig:"LISTEN_FOR_GOODBYES" default:"true"` // listen for mDNS goodbye packets between scans to detect devices going offline immediately
+		DeviceScanRun       bool
+	}
+	HouseStandard struct {
+		SampleRate int `envconfig:"HOUSE_SAMPLE_RATE" default:"48000"` // devices running other settings are flagged. 0 disables the check of a setting
+		Encoding   int `envconfig:"HOUSE_ENCODING" default:"24"`
+		LatencyUs  int `envconfig:"HOUSE_LATENCY_US" default:"1000"`
+	}
+	NamingPolicy struct {
+		Pattern     NamingPattern `envconfig:"NAMING_PATTERN" default:"^[A-Z0-9]+-[A-Z0-9]+-[0-9]{2}$"` // house naming convention ROOM-TYPE-NN, applies to devices not matched by a rule. Leave empty to only check devices matched by a rule
+		Rules       NamingRules   `envconfig:"NAMING_RULES"`                                            // manufacturer/model=pattern, rules separated by ";". The first matching rule wins
+		UniqueNames bool          `envconfig:"NAMING_UNIQUE_NAMES" default:"true"`                      // device names must differ in more than upper and lower case
+	}
+	Routing struct {
+		PresetFile string `envconfig:"PRESET_FILE" default:"./presets.json"`
+	}
+	Storage struct {
+		DeviceBackend string `envconfig:"DEVICE_STORE" default:"memory"` // "memory" keeps devices until restart, "bolt" persists them in DeviceFile
+		DeviceFile    string `envconfig:"DEVICE_STORE_FILE" default:"./devices.db"`
+	}
+	Events struct {
+		EventFile string `envconfig:"EVENT_FILE" default:"./events.json"`
+		MaxEvents int    `envconfig:"MAX_EVENTS" default:"1000"` // oldest events are dropped when the limit is reached
+	}
+	Jobs struct {
+		ScheduledJobs JobDefinitions `envconfig:"SCHEDULED_JOBS"` // name|cron schedule|action|argument, jobs separated by ";"
+	}
+	Webhooks struct {
+		Targets       WebhookTargets `envconfig:"WEBHOOK_TARGETS"` // name|url|event types separated by ","|template file, targets separated by ";"
+		TimeOutSec    int            `envconfig:"WEBHOOK_TIME_OUT_SEC" default:"5"`
+		Retries       int            `envconfig:"WEBHOOK_RETRIES" default:"3"`         // further attempts after a failed delivery
+		RetryDelaySec int            `envconfig:"WEBHOOK_RETRY_DELAY_SEC" default:"2"` // doubled after every failed attempt
+		MaxLog        int            `envconfig:"WEBHOOK_MAX_LOG" default:"200"`       // number of deliveries shown in the delivery log
+	}
+	Mqtt struct {
+		Broker      string `envconfig:"MQTT_BROKER"` // e.g. tcp://broker:1883. Leave empty to disable publishing to MQTT
+		ClientId    string `envconfig:"MQTT_CLIENT_ID" default:"alighieri"`
+		UserName    string `envconfig:"MQTT_USER_NAME"`
+		Password    string `envconfig:"MQTT_PASSWORD"`
+		TopicPrefix string `envconfig:"MQTT_TOPIC_PREFIX" default:"alighieri"`
+		Qos         byte   `envconfig:"MQTT_QOS" default:"1"`
+		TimeOutSec  int    `envconfig:"MQTT_TIME_OUT_SEC" default:"5"`
 	}
 	Misc struct {
 	}
 	Metrics struct {
+		UpdateSec int `envconfig:"METRICS_UPDATE_SEC" default:"3"`
 	}
 	RunTime struct {
-		Mu                  sync.Mutex
-		Router              *gin.Engine
-		BgJobs              *cron.Cron
-		ListenAddr          string
-		StartDate           time.Time
-		DeviceScanInterface *net.Interface
-		DeviceScanNumber    int
-		LastDeviceScanDate  time.Time
-		DevicesInList       int
-		DeviceScanRunning   bool
+		Mu                   sync.Mutex
+		Router               *gin.Engine
+		BgJobs               *cron.Cron
+		ListenAddr           string
+		StartDate            time.Time
+		DeviceScanInterfaces []*net.Interface
+		DeviceScanNumber     int
+		LastDeviceScanDate   time.Time
+		DevicesInList        int
+		DeviceScanRunning    bool
+		ScheduledJobs        []JobStatus
+		MdnsEntries          map[string]int  // mDNS entries received per service type since start
+		ConversionErrors     int             // mDNS entries that could not be converted since start
+		ScanDurations        []time.Duration // durations of the scans finished since the last metrics update
 	}
 }
 
+// JobDefinition defines a job run by the background scheduler
+type JobDefinition struct {
+	Name     string
+	Schedule string
+	Action   string
+	Argument string
+}
+
+type JobDefinitions []JobDefinition
+
+// JobStatus holds the runtime state of a scheduled job
+type JobStatus struct {
+	Definition  JobDefinition
+	EntryId     int
+	LastRun     time.Time
+	LastResult  string
+	LastSuccess bool
+}
+
+// WebhookTarget defines a URL the device events are posted to
+type WebhookTarget struct {
+	Name         string
+	Url          string
+	Events       []string // event types sent to the target. Empty sends all events
+	TemplateFile string   // Go template rendering the JSON payload. Empty sends the default payload
+}
+
+type WebhookTargets []WebhookTarget
+
+// NamingPattern is a regular expression device names have to match. The expression matches the whole name
+type NamingPattern struct {
+	*regexp.Regexp
+}
+
+// NamingRule defines the pattern for the names of devices by manufacturer and model
+type NamingRule struct {
+	Manufacturer string // empty or "*" matches all manufacturers
+	Model        string // empty or "*" matches all models
+	Pattern      NamingPattern
+}
+
+type NamingRules []NamingRule
+
 var (
 	EnvFile = ".env"
+	// legacyServiceName is the old default of SERVICE_NAME. It lists the service types, not devices, so it is not queried
+	legacyServiceName = "_services._dns-sd._udp"
 )
 
+// Decode parses job definitions in the format "name|cron schedule|action|argument;...", e.g. "evening|0 18 * * *|recall|lecture-hall-evening"
+func (jd *JobDefinitions) Decode(value string) error {
+	var jobs JobDefinitions
+	for _, job := range strings.Split(value, ";") {
+		if strings.TrimSpace(job) == "" {
+			continue
+		}
+		parts := strings.Split(job, "|")
+		if len(parts) != 4 {
+			return fmt.Errorf("job definition %q needs 4 parts separated by \"|\"", job)
+		}
+		jobs = append(jobs, JobDefinition{
+			Name:     strings.TrimSpace(parts[0]),
+			Schedule: strings.TrimSpace(parts[1]),
+			Action:   strings.TrimSpace(parts[2]),
+			Argument: strings.TrimSpace(parts[3]),
+		})
+	}
+	*jd = jobs
+	return nil
+}
+
+// Decode parses webhook targets in the format "name|url|event types|template file;...", e.g. "oncall|https://chat.example.com/hook|offline,new device|./oncall.tmpl".
+// Event types and template file are optional, "*" as event types sends all events
+func (wt *WebhookTargets) Decode(value string) error {
+	var targets WebhookTargets
+	for _, target := range strings.Split(value, ";") {
+		if strings.TrimSpace(target) == "" {
+			continue
+		}
+		parts := strings.Split(target, "|")
+		if len(parts) < 2 || len(parts) > 4 {
+			return fmt.Errorf("webhook target %q needs 2 to 4 parts separated by \"|\"", target)
+		}
+		t := WebhookTarget{
+			Name: strings.TrimSpace(parts[0]),
+			Url:  strings.TrimSpace(parts[1]),
+		}
+		if t.Name == "" || t.Url == "" {
+			return fmt.Errorf("webhook target %q needs a name and a URL", target)
+		}
+		if len(parts) > 2 {
+			for _, eventType := range strings.Split(parts[2], ",") {
+				if eventType = strings.TrimSpace(eventType); eventType != "" && eventType != "*" {
+					t.Events = append(t.Events, eventType)
+				}
+			}
+		}
+		if len(parts) > 3 {
+			t.TemplateFile = strings.TrimSpace(parts[3])
+		}
+		targets = append(targets, t)
+	}
+	*wt = targets
+	return nil
+}
+
+// Decode compiles a naming pattern. An empty value leaves the pattern unset
+func (np *NamingPattern) Decode(value string) error {
+	if strings.TrimSpace(value) == "" {
+		np.Regexp = nil
+		return nil
+	}
+	re, err := regexp.Compile(strings.TrimSpace(value))
+	if err != nil {
+		return fmt.Errorf("invalid naming pattern %q: %w", value, err)
+	}
+	np.Regexp = re
+	return nil
+}
+
+// Decode parses naming rules in the format "manufacturer/model=pattern;...", e.g. "Audinate/AVIO*=^[A-Z0-9]+-AVIO-[0-9]{2}$".
+// Manufacturer and model are matched regardless of case, "*" matches all and a trailing "*" matches all values with the given prefix. Patterns must not contain ";"
+func (nr *NamingRules) Decode(value string) error {
+	var rules NamingRules
+	for _, rule := range strings.Split(value, ";") {
+		if strings.TrimSpace(rule) == "" {
+			continue
+		}
+		selector, pattern, ok := strings.Cut(rule, "=")
+		if !ok || strings.TrimSpace(pattern) == "" {
+			return fmt.Errorf("naming rule %q needs a pattern after \"=\"", rule)
+		}
+		manufacturer, model, _ := strings.Cut(selector, "/")
+		r := NamingRule{
+			Manufacturer: strings.TrimSpace(manufacturer),
+			Model:        strings.TrimSpace(model),
+		}
+		if err := r.Pattern.Decode(pattern); err != nil {
+			return err
+		}
+		rules = append(rules, r)
+	}
+	*nr = rules
+	return nil
+}
+
+// Matches checks whether the rule applies to devices of the given manufacturer and model
+func (r NamingRule) Matches(manufacturer string, model string) bool {
+	return matchSelector(r.Manufacturer, manufacturer) && matchSelector(r.Model, model)
+}
+
+// matchSelector compares a value with the manufacturer or model given in a naming rule regardless of case
+func matchSelector(selector string, value string) bool {
+	if selector == "" || selector == "*" {
+		return true
+	}
+	if prefix, ok := strings.CutSuffix(selector, "*"); ok {
+		return len(value) >= len(prefix) && strings.EqualFold(value[:len(prefix)], prefix)
+	}
+	return strings.EqualFold(selector, value)
+}
+
+// DeviceStateThresholds returns the time without an answer after which a device is considered stale or offline. One scan cycle queries all service types one after the other and then pauses
+func DeviceStateThresholds(config *AppConfig) (staleAfter time.Duration, offlineAfter time.Duration) {
+	cycle := time.Duration(config.DeviceScan.ScanCycleSec+config.DeviceScan.ScanTimeOutSec*len(config.DeviceScan.ServiceNames)) * time.Second
+	return cycle * time.Duration(config.DeviceScan.StaleAfter), cycle * time.Duration(config.DeviceScan.OfflineAfter)
+}
+
 // InitConfig initializes the configuration and sets the defaults
 func InitConfig(file string, config *AppConfig) error {
 	log.Printf("Initializing configuration from file %v...", file)
@@ -93,6 +318,22 @@ func checkFilePath(filePath *string) {
 // setDefaults sets defaults for some configurations items
 func setDefaults(config *AppConfig) {
 	config.DeviceScan.DeviceScanRun = true
+	if config.DeviceScan.StaleAfter < 1 {
+		config.DeviceScan.StaleAfter = 1
+	}
+	if config.DeviceScan.OfflineAfter < config.DeviceScan.StaleAfter {
+		config.DeviceScan.OfflineAfter = config.DeviceScan.StaleAfter
+	}
+	if name := strings.TrimSpace(config.DeviceScan.ServiceName); name == legacyServiceName {
+		log.Printf("SERVICE_NAME is deprecated and its old default %v is ignored, use SERVICE_NAMES instead", name)
+	} else if name != "" {
+		log.Print("SERVICE_NAME is deprecated, use SERVICE_NAMES instead")
+		if _, ok := os.LookupEnv("SERVICE_NAMES"); !ok {
+			config.DeviceScan.ServiceNames = []string{name}
+		} else if !slices.Contains(config.DeviceScan.ServiceNames, name) {
+			config.DeviceScan.ServiceNames = append(config.DeviceScan.ServiceNames, name)
+		}
+	}
 }
 
 // loadConfig loads the configuration from file. Returns an error if loading fails
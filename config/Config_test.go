@@ -91,3 +91,98 @@ func TestCheckFilePathWeirdPathReturnsCorrectPath(t *testing.T) {
 	checkFilePath(&testPath)
 	assert.EqualValues(t, "C:\\etc", testPath)
 }
+
+func TestDecodeJobDefinitionsReturnsJobs(t *testing.T) {
+	var jobs JobDefinitions
+	err := jobs.Decode("morning|0 7 * * *|recall|day; night|0 22 * * *|unsubscribe|stagebox/1")
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, 2, len(jobs))
+	assert.EqualValues(t, "night", jobs[1].Name)
+	assert.EqualValues(t, "stagebox/1", jobs[1].Argument)
+}
+
+func TestDecodeJobDefinitionsMissingPartReturnsError(t *testing.T) {
+	var jobs JobDefinitions
+	err := jobs.Decode("morning|0 7 * * *|recall")
+
+	assert.NotNil(t, err)
+}
+
+func TestDecodeWebhookTargetsReturnsTargets(t *testing.T) {
+	var targets WebhookTargets
+	err := targets.Decode("oncall|http://chat/hook|offline, new device|./oncall.tmpl;log|http://log/hook")
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, 2, len(targets))
+	assert.EqualValues(t, []string{"offline", "new device"}, targets[0].Events)
+	assert.EqualValues(t, "./oncall.tmpl", targets[0].TemplateFile)
+	assert.Nil(t, targets[1].Events)
+	assert.EqualValues(t, "http://log/hook", targets[1].Url)
+}
+
+func TestDecodeWebhookTargetsMissingUrlReturnsError(t *testing.T) {
+	var targets WebhookTargets
+	err := targets.Decode("oncall")
+
+	assert.NotNil(t, err)
+}
+
+func TestDecodeNamingRulesReturnsRules(t *testing.T) {
+	var rules NamingRules
+	err := rules.Decode("Audinate/AVIO*=^[A-Z0-9]+-AVIO-[0-9]{2}$; Yamaha=^(FOH|MON)-[A-Z]+-[0-9]{2}$")
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, 2, len(rules))
+	assert.EqualValues(t, "AVIO*", rules[0].Model)
+	assert.True(t, rules[0].Matches("audinate", "AVIO USB"))
+	assert.False(t, rules[0].Matches("Audinate", "Brooklyn II"))
+	assert.EqualValues(t, "", rules[1].Model)
+	assert.True(t, rules[1].Matches("Yamaha", "CL5"))
+	assert.True(t, rules[1].Pattern.MatchString("MON-MIX-01"))
+}
+
+func TestDecodeNamingRulesInvalidPatternReturnsError(t *testing.T) {
+	var rules NamingRules
+	err := rules.Decode("Yamaha/*=^[A-Z+$")
+
+	assert.NotNil(t, err)
+}
+
+func TestInitConfigSetsDefaultNamingPattern(t *testing.T) {
+	var config AppConfig
+	InitConfig("", &config)
+
+	assert.NotNil(t, config.NamingPolicy.Pattern.Regexp)
+	assert.True(t, config.NamingPolicy.Pattern.MatchString("STUDIOA-MIC-01"))
+	assert.False(t, config.NamingPolicy.Pattern.MatchString("stagebox-1"))
+}
+
+func TestInitConfigUsesDeprecatedServiceName(t *testing.T) {
+	var config AppConfig
+	os.Setenv("SERVICE_NAME", "_netaudio-arc._udp")
+	defer os.Unsetenv("SERVICE_NAME")
+	InitConfig("", &config)
+
+	assert.EqualValues(t, []string{"_netaudio-arc._udp"}, config.DeviceScan.ServiceNames)
+}
+
+func TestInitConfigIgnoresOldDefaultServiceName(t *testing.T) {
+	var config AppConfig
+	os.Setenv("SERVICE_NAME", "_services._dns-sd._udp")
+	defer os.Unsetenv("SERVICE_NAME")
+	InitConfig("", &config)
+
+	assert.EqualValues(t, []string{"_netaudio-arc._udp", "_netaudio-cmc._udp", "_netaudio-dbc._udp", "_netaudio-chan._udp"}, config.DeviceScan.ServiceNames)
+}
+
+func TestInitConfigAddsDeprecatedServiceNameToServiceNames(t *testing.T) {
+	var config AppConfig
+	os.Setenv("SERVICE_NAME", "_netaudio-chan._udp")
+	os.Setenv("SERVICE_NAMES", "_netaudio-arc._udp")
+	defer os.Unsetenv("SERVICE_NAME")
+	defer os.Unsetenv("SERVICE_NAMES")
+	InitConfig("", &config)
+
+	assert.EqualValues(t, []string{"_netaudio-arc._udp", "_netaudio-chan._udp"}, config.DeviceScan.ServiceNames)
+}
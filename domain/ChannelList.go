@@ -0,0 +1,87 @@
+// package domain defines the core data structures
+package domain
+
+import "fmt"
+
+// ChannelDirection distinguishes transmit from receive channels
+type ChannelDirection string
+
+const (
+	ChannelTx ChannelDirection = "tx"
+	ChannelRx ChannelDirection = "rx"
+)
+
+// SubscriptionStatus is the status of a receive channel's subscription as reported by the device
+type SubscriptionStatus int
+
+const (
+	SubscriptionNone          SubscriptionStatus = 0
+	SubscriptionUnresolved    SubscriptionStatus = 1
+	SubscriptionResolved      SubscriptionStatus = 2
+	SubscriptionResolveFailed SubscriptionStatus = 3
+	SubscriptionUnicast       SubscriptionStatus = 9
+	SubscriptionMulticast     SubscriptionStatus = 10
+	SubscriptionNoConnection  SubscriptionStatus = 16
+	SubscriptionSelf          SubscriptionStatus = 17
+)
+
+var subscriptionStatusNames = map[SubscriptionStatus]string{
+	SubscriptionNone:          "none",
+	SubscriptionUnresolved:    "unresolved",
+	SubscriptionResolved:      "resolved",
+	SubscriptionResolveFailed: "resolve failed",
+	SubscriptionUnicast:       "connected (unicast)",
+	SubscriptionMulticast:     "connected (multicast)",
+	SubscriptionNoConnection:  "no connection",
+	SubscriptionSelf:          "connected (self)",
+}
+
+// String returns the display name of a subscription status
+func (ss SubscriptionStatus) String() string {
+	if name, ok := subscriptionStatusNames[ss]; ok {
+		return name
+	}
+	return fmt.Sprintf("unknown (%d)", int(ss))
+}
+
+// ChannelInfo defines the information maintained per audio channel of a device
+type ChannelInfo struct {
+	DeviceName string
+	Direction  ChannelDirection
+	Number     int
+	Name       string
+	SampleRate int
+	Encoding   int
+	LatencyNs  int
+	// subscription of a receive channel, empty if the channel is not subscribed
+	TxDeviceName       string
+	TxChannelName      string
+	SubscriptionStatus SubscriptionStatus
+}
+
+// IsSubscribed checks whether a receive channel is subscribed to a transmit channel
+func (c ChannelInfo) IsSubscribed() bool {
+	return c.TxChannelName != ""
+}
+
+type ChannelList []ChannelInfo
+
+// GetByNumber returns the channel with the given number. If no channel matches, the method returns nil
+func (cl ChannelList) GetByNumber(number int) *ChannelInfo {
+	for i := range cl {
+		if cl[i].Number == number {
+			return &cl[i]
+		}
+	}
+	return nil
+}
+
+// GetByName returns the channel with the given name. If no channel matches, the method returns nil
+func (cl ChannelList) GetByName(name string) *ChannelInfo {
+	for i := range cl {
+		if cl[i].Name == name {
+			return &cl[i]
+		}
+	}
+	return nil
+}
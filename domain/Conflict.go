@@ -0,0 +1,50 @@
+// package domain defines the core data structures
+package domain
+
+import (
+	"sync"
+	"time"
+)
+
+// ConflictType describes a misconfiguration found by comparing the devices with each other and with the scanned networks
+type ConflictType string
+
+const (
+	ConflictDuplicateName ConflictType = "duplicate-name"
+	ConflictDuplicateIp   ConflictType = "duplicate-ip"
+	ConflictLinkLocal     ConflictType = "link-local"
+	ConflictOutsideSubnet ConflictType = "outside-subnet"
+)
+
+// ConflictTypes lists all conflict types in the order they are reported
+var ConflictTypes = []ConflictType{ConflictDuplicateName, ConflictDuplicateIp, ConflictLinkLocal, ConflictOutsideSubnet}
+
+// Conflict is a single finding, involving one or more devices
+type Conflict struct {
+	Type    ConflictType
+	Devices []string
+	Detail  string
+}
+
+// ConflictReport is the result of analyzing the device list after a scan
+type ConflictReport struct {
+	Date      time.Time
+	Checked   int
+	Conflicts []Conflict
+}
+
+// SafeConflictReport adds a mutex to allow thread-safe access of the last report
+type SafeConflictReport struct {
+	sync.RWMutex
+	Report ConflictReport
+}
+
+// Count returns the number of conflicts of a type
+func (r ConflictReport) Count(conflictType ConflictType) (count int) {
+	for _, conflict := range r.Conflicts {
+		if conflict.Type == conflictType {
+			count++
+		}
+	}
+	return
+}
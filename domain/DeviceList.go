@@ -3,27 +3,67 @@ package domain
 
 import (
 	"net"
+	"slices"
 	"sync"
 	"time"
 )
 
+// mDNS service types advertised by Dante devices
+const (
+	ServiceArc  = "_netaudio-arc._udp"
+	ServiceCmc  = "_netaudio-cmc._udp"
+	ServiceDbc  = "_netaudio-dbc._udp"
+	ServiceChan = "_netaudio-chan._udp"
+)
+
+// DeviceState describes whether a device is still answering the scans
+type DeviceState string
+
+const (
+	DeviceOnline  DeviceState = "online"
+	DeviceStale   DeviceState = "stale"
+	DeviceOffline DeviceState = "offline"
+)
+
 // DeviceInfo defines the information maintained per device entry
 type DeviceInfo struct {
-	Name          string
-	FullName      string
-	HostName      string
-	IPv4          net.IP
-	Port          int
-	Id            string
-	Process       string
-	CmcpVersion   string
-	CmcpMin       string
-	ServerVersion string
-	Channels      string
-	Manufacturer  string
-	Model         string
-	FirstSeen     time.Time
-	LastSeen      time.Time
+	Name           string
+	FullName       string
+	HostName       string
+	IPv4           net.IP
+	ArcPort        int
+	CmcPort        int
+	DbcPort        int
+	Services       []string
+	Id             string
+	Process        string
+	CmcpVersion    string
+	CmcpMin        string
+	ServerVersion  string
+	Channels       string
+	Manufacturer   string
+	Model          string
+	DanteName      string
+	TxChannelCount int
+	RxChannelCount int
+	TxChannels     ChannelList
+	RxChannels     ChannelList
+	FirstSeen      time.Time
+	LastSeen       time.Time
+	GoodbyeAt      time.Time
+	OfflineSince   time.Time
+	Sightings      []Sighting
+	Addresses      []net.IPAddr // all IPv4 and IPv6 addresses on all interfaces. Link-local IPv6 addresses carry the interface as zone
+	PreviousNames  []string     // names the device was known by before it was renamed, oldest first
+	Settings       DeviceSettings
+}
+
+// Sighting records on which network interface and with which address a device answered the scans
+type Sighting struct {
+	Interface string
+	IPv4      net.IP
+	Addresses []net.IPAddr
+	LastSeen  time.Time
 }
 
 type DeviceList []DeviceInfo
@@ -33,3 +73,68 @@ type SafeDeviceList struct {
 	sync.RWMutex
 	Devices map[string]DeviceInfo
 }
+
+// Key returns the identity the device is stored under: the Dante device id announced in the TXT records, which is derived from the device's MAC address.
+// Devices not announcing an id are identified by their name
+func (d DeviceInfo) Key() string {
+	if d.Id != "" {
+		return d.Id
+	}
+	return d.Name
+}
+
+// WithPreviousName returns the device with a name added to its previous names, unless it is the current name or already recorded
+func (d DeviceInfo) WithPreviousName(name string) DeviceInfo {
+	if name != "" && name != d.Name && !slices.Contains(d.PreviousNames, name) {
+		d.PreviousNames = append(append([]string(nil), d.PreviousNames...), name)
+	}
+	return d
+}
+
+// HasService checks whether the device advertises the given mDNS service type
+func (d DeviceInfo) HasService(service string) bool {
+	for _, s := range d.Services {
+		if s == service {
+			return true
+		}
+	}
+	return false
+}
+
+// State derives the device's state from the time it was last seen. A goodbye received after the device was last seen marks it offline immediately
+func (d DeviceInfo) State(now time.Time, staleAfter time.Duration, offlineAfter time.Duration) DeviceState {
+	if !d.GoodbyeAt.IsZero() && !d.GoodbyeAt.Before(d.LastSeen) {
+		return DeviceOffline
+	}
+	age := now.Sub(d.LastSeen)
+	switch {
+	case age >= offlineAfter:
+		return DeviceOffline
+	case age >= staleAfter:
+		return DeviceStale
+	}
+	return DeviceOnline
+}
+
+// MissingOn returns the sightings on interfaces the device stopped answering on while still answering on others, e.g. an unplugged secondary port
+func (d DeviceInfo) MissingOn(staleAfter time.Duration) (missing []Sighting) {
+	for _, sighting := range d.Sightings {
+		if d.LastSeen.Sub(sighting.LastSeen) > staleAfter {
+			missing = append(missing, sighting)
+		}
+	}
+	return
+}
+
+// HasAddress checks whether the device uses an address. The zone only needs to match if both addresses carry one
+func (d DeviceInfo) HasAddress(addr net.IPAddr) bool {
+	if d.IPv4 != nil && d.IPv4.Equal(addr.IP) {
+		return true
+	}
+	for _, known := range d.Addresses {
+		if known.IP.Equal(addr.IP) && (known.Zone == "" || addr.Zone == "" || known.Zone == addr.Zone) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,84 @@
+// package domain defines the core data structures
+package domain
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+// PullUp is the pull-up or pull-down of a device's sample rate, used when audio follows video running at a different frame rate
+type PullUp int
+
+const (
+	PullUpNone     PullUp = 0
+	PullUpPlus4167 PullUp = 1 // +4.1667%
+	PullUpPlus01   PullUp = 2 // +0.1%
+	PullUpMinus01  PullUp = 3 // -0.1%
+	PullUpMinus4   PullUp = 4 // -4.0%
+)
+
+var pullUpNames = map[PullUp]string{
+	PullUpNone:     "none",
+	PullUpPlus4167: "+4.1667%",
+	PullUpPlus01:   "+0.1%",
+	PullUpMinus01:  "-0.1%",
+	PullUpMinus4:   "-4.0%",
+}
+
+// Values a device's settings can be changed to. Not every device supports all of them
+var (
+	SampleRates = []int{44100, 48000, 88200, 96000, 176400, 192000}
+	Encodings   = []int{16, 24, 32}
+	LatenciesNs = []int{250000, 500000, 1000000, 2000000, 5000000}
+	PullUps     = []PullUp{PullUpNone, PullUpPlus4167, PullUpPlus01, PullUpMinus01, PullUpMinus4}
+)
+
+// DeviceSettings holds the device-wide settings read from a device's settings port
+type DeviceSettings struct {
+	SampleRate int
+	Encoding   int // bit depth of the PCM encoding
+	LatencyNs  int
+	PullUp     PullUp
+	ReadAt     time.Time // zero if the settings were never read
+}
+
+// String returns the display name of a pull-up or pull-down
+func (p PullUp) String() string {
+	if name, ok := pullUpNames[p]; ok {
+		return name
+	}
+	return fmt.Sprintf("unknown (%d)", int(p))
+}
+
+// ParsePullUp converts a display name as returned by String back to the pull-up or pull-down
+func ParsePullUp(name string) (PullUp, error) {
+	for _, pullUp := range PullUps {
+		if pullUp.String() == name {
+			return pullUp, nil
+		}
+	}
+	return PullUpNone, fmt.Errorf("unknown pull-up/down %v", name)
+}
+
+// Known checks whether the settings were read from the device
+func (s DeviceSettings) Known() bool {
+	return !s.ReadAt.IsZero()
+}
+
+// Validate checks that all settings hold values a device can be changed to
+func (s DeviceSettings) Validate() error {
+	if !slices.Contains(SampleRates, s.SampleRate) {
+		return fmt.Errorf("unsupported sample rate %v", s.SampleRate)
+	}
+	if !slices.Contains(Encodings, s.Encoding) {
+		return fmt.Errorf("unsupported encoding %v", s.Encoding)
+	}
+	if !slices.Contains(LatenciesNs, s.LatencyNs) {
+		return fmt.Errorf("unsupported latency %v", time.Duration(s.LatencyNs))
+	}
+	if !slices.Contains(PullUps, s.PullUp) {
+		return fmt.Errorf("unsupported pull-up/down %v", s.PullUp)
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+// package domain defines the core data structures
+package domain
+
+import (
+	"sync"
+	"time"
+)
+
+// EventType describes what happened to a device
+type EventType string
+
+const (
+	EventNewDevice      EventType = "new device"
+	EventBackOnline     EventType = "back online"
+	EventOffline        EventType = "offline"
+	EventIpChanged      EventType = "IP changed"
+	EventPortChanged    EventType = "port changed"
+	EventNameChanged    EventType = "name changed"
+	EventVersionChanged EventType = "version changed"
+)
+
+// EventTypes lists all event types in display order
+var EventTypes = []EventType{EventNewDevice, EventBackOnline, EventOffline, EventIpChanged, EventPortChanged, EventNameChanged, EventVersionChanged}
+
+// Event records a change of a device detected while scanning
+type Event struct {
+	Date     time.Time
+	Type     EventType
+	Device   string
+	Property string
+	OldValue string
+	NewValue string
+}
+
+type EventList []Event
+
+// SafeEventList adds a mutex to allow thread-safe access of the events
+type SafeEventList struct {
+	sync.RWMutex
+	Events EventList
+}
@@ -0,0 +1,69 @@
+// package domain defines the core data structures
+package domain
+
+import "fmt"
+
+// FormatIssue describes why a device or subscription is flagged by the format analysis
+type FormatIssue string
+
+const (
+	FormatOffStandard FormatIssue = "off-standard"
+	FormatMismatch    FormatIssue = "subscription-mismatch"
+)
+
+// FormatIssues lists all format issues in the order they are reported
+var FormatIssues = []FormatIssue{FormatOffStandard, FormatMismatch}
+
+// Format is the clock and audio format a device runs with. Devices can only exchange audio if their formats match
+type Format struct {
+	SampleRate int
+	PullUp     PullUp
+	Encoding   int
+}
+
+// FormatGroup lists the devices running with the same format
+type FormatGroup struct {
+	Format   Format
+	Devices  []string
+	Standard bool
+}
+
+// FormatFinding is a device deviating from the house standard or a subscription between devices running different formats
+type FormatFinding struct {
+	Issue   FormatIssue
+	Devices []string
+	Detail  string
+}
+
+// FormatReport is the result of comparing the settings of all devices with each other and with the house standard
+type FormatReport struct {
+	Standard DeviceSettings // zero values are not checked
+	Checked  int
+	Unknown  int // devices not checked as their settings were not read
+	Groups   []FormatGroup
+	Findings []FormatFinding
+}
+
+// Format returns the clock and audio format of the settings
+func (s DeviceSettings) Format() Format {
+	return Format{SampleRate: s.SampleRate, PullUp: s.PullUp, Encoding: s.Encoding}
+}
+
+// String returns the display format of a format, e.g. "48000 Hz, 24 bit" or "48000 Hz, 24 bit, pull-up/down +0.1%"
+func (f Format) String() string {
+	formatted := fmt.Sprintf("%d Hz, %d bit", f.SampleRate, f.Encoding)
+	if f.PullUp != PullUpNone {
+		formatted += ", pull-up/down " + f.PullUp.String()
+	}
+	return formatted
+}
+
+// Count returns the number of findings of an issue
+func (r FormatReport) Count(issue FormatIssue) (count int) {
+	for _, finding := range r.Findings {
+		if finding.Issue == issue {
+			count++
+		}
+	}
+	return
+}
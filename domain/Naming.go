@@ -0,0 +1,51 @@
+// package domain defines the core data structures
+package domain
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// MaxNameLength is the maximum length of device names and channel labels accepted by Dante devices
+const MaxNameLength = 31
+
+var (
+	// device names may consist of letters, digits and hyphens, but must not start or end with a hyphen
+	deviceNamePattern = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9-]*[A-Za-z0-9])?$`)
+	// channel labels may contain any printable character except those used to address channels, e.g. "label@device"
+	channelNameForbidden = "=.@"
+)
+
+// ValidateDeviceName checks a device name against the Dante naming rules
+func ValidateDeviceName(name string) error {
+	if name == "" {
+		return fmt.Errorf("device name must not be empty")
+	}
+	if len(name) > MaxNameLength {
+		return fmt.Errorf("device name %v is longer than %v characters", name, MaxNameLength)
+	}
+	if !deviceNamePattern.MatchString(name) {
+		return fmt.Errorf("device name %v may only contain letters, digits and hyphens and must not start or end with a hyphen", name)
+	}
+	return nil
+}
+
+// ValidateChannelName checks a channel label against the Dante naming rules
+func ValidateChannelName(name string) error {
+	if name == "" {
+		return fmt.Errorf("channel name must not be empty")
+	}
+	if len(name) > MaxNameLength {
+		return fmt.Errorf("channel name %v is longer than %v characters", name, MaxNameLength)
+	}
+	if strings.ContainsAny(name, channelNameForbidden) {
+		return fmt.Errorf("channel name %v must not contain any of %v", name, channelNameForbidden)
+	}
+	for _, r := range name {
+		if r < 0x20 || r == 0x7f {
+			return fmt.Errorf("channel name %v must not contain control characters", name)
+		}
+	}
+	return nil
+}
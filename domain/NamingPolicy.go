@@ -0,0 +1,47 @@
+// package domain defines the core data structures
+package domain
+
+// NamingIssue describes why a device name violates the naming policy
+type NamingIssue string
+
+const (
+	NamingPattern   NamingIssue = "pattern"
+	NamingDuplicate NamingIssue = "duplicate"
+)
+
+// NamingIssues lists all naming issues in the order they are reported
+var NamingIssues = []NamingIssue{NamingPattern, NamingDuplicate}
+
+// NamingFinding is a violation of the naming policy by a single device
+type NamingFinding struct {
+	Device string
+	Issue  NamingIssue
+	Detail string
+}
+
+// NamingRename is a rename suggested to make a device comply with the naming policy. Applied and Error are only set once the rename was sent to the device
+type NamingRename struct {
+	Device  string
+	Key     string
+	NewName string
+	Applied bool
+	Error   string
+}
+
+// NamingReport is the result of checking the names of all devices against the naming policy
+type NamingReport struct {
+	Checked   int
+	Compliant int
+	Findings  []NamingFinding
+	Renames   []NamingRename // devices without a suggestion have to be renamed manually
+}
+
+// Count returns the number of findings of an issue
+func (r NamingReport) Count(issue NamingIssue) (count int) {
+	for _, finding := range r.Findings {
+		if finding.Issue == issue {
+			count++
+		}
+	}
+	return
+}
@@ -0,0 +1,19 @@
+// package domain defines the core data structures
+package domain
+
+// NotificationType describes what changed in a notification pushed to live clients
+type NotificationType string
+
+const (
+	NotificationDeviceAdded   NotificationType = "device-added"
+	NotificationDeviceChanged NotificationType = "device-changed"
+	NotificationDeviceRemoved NotificationType = "device-removed"
+	NotificationScanStatus    NotificationType = "scan-status"
+)
+
+// Notification announces a change of a device or of the scan status. Device and Key are empty for scan status changes
+type Notification struct {
+	Type   NotificationType
+	Device string
+	Key    string
+}
@@ -0,0 +1,67 @@
+// package domain defines the core data structures
+package domain
+
+import (
+	"sync"
+	"time"
+)
+
+// PresetSubscription defines the state of a single receive channel within a preset. An empty transmit channel means unsubscribed
+type PresetSubscription struct {
+	RxDevice  string
+	RxChannel int
+	TxDevice  string
+	TxChannel string
+}
+
+// Preset is a named snapshot of the subscriptions of all receive channels in the network
+type Preset struct {
+	Name          string
+	Created       time.Time
+	Subscriptions []PresetSubscription
+	LastRecall    *RecallResult
+}
+
+type PresetList []Preset
+
+// SafePresetList adds a mutex to allow thread-safe access of the presets
+type SafePresetList struct {
+	sync.RWMutex
+	Presets map[string]Preset
+}
+
+// PresetChange defines a difference between a preset and the live routing
+type PresetChange struct {
+	RxDevice         string
+	RxChannel        int
+	CurrentTxDevice  string
+	CurrentTxChannel string
+	PresetTxDevice   string
+	PresetTxChannel  string
+}
+
+// RecallFailure defines a change that could not be applied when recalling a preset
+type RecallFailure struct {
+	Change PresetChange
+	Error  string
+}
+
+// RecallResult defines the outcome of recalling a preset. Failures are grouped by receiving device
+type RecallResult struct {
+	Date     time.Time
+	Applied  []PresetChange
+	Failures map[string][]RecallFailure
+}
+
+// IsUnsubscribe checks whether the change clears a subscription
+func (pc PresetChange) IsUnsubscribe() bool {
+	return pc.PresetTxChannel == ""
+}
+
+// FailureCount returns the number of changes that failed across all devices
+func (rr RecallResult) FailureCount() (count int) {
+	for _, failures := range rr.Failures {
+		count += len(failures)
+	}
+	return
+}
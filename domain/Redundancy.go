@@ -0,0 +1,49 @@
+// package domain defines the core data structures
+package domain
+
+import "net"
+
+// RedundancyIssue describes a problem with the connection of a device to the primary and secondary network
+type RedundancyIssue string
+
+const (
+	RedundancySingleNetwork RedundancyIssue = "single-network"
+	RedundancyWrongSubnet   RedundancyIssue = "wrong-subnet"
+	RedundancySameSubnet    RedundancyIssue = "same-subnet"
+)
+
+// RedundancyIssues lists all issues in the order they are reported
+var RedundancyIssues = []RedundancyIssue{RedundancySingleNetwork, RedundancyWrongSubnet, RedundancySameSubnet}
+
+// Network is one of the networks scanned for devices. Subnet is nil if the interface has no IPv4 address
+type Network struct {
+	Interface string
+	Subnet    *net.IPNet
+}
+
+// RedundancyFinding is an issue found for a single device
+type RedundancyFinding struct {
+	Device string
+	Issue  RedundancyIssue
+	Detail string
+}
+
+// RedundancyReport is the result of checking all devices for their primary and secondary network connection.
+// The checks only run if at least two networks are scanned, the first being the primary and the second the secondary network
+type RedundancyReport struct {
+	Primary   Network
+	Secondary Network
+	Enabled   bool
+	Checked   int
+	Findings  []RedundancyFinding
+}
+
+// Count returns the number of findings of an issue
+func (r RedundancyReport) Count(issue RedundancyIssue) (count int) {
+	for _, finding := range r.Findings {
+		if finding.Issue == issue {
+			count++
+		}
+	}
+	return
+}
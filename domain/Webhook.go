@@ -0,0 +1,27 @@
+// package domain defines the core data structures
+package domain
+
+import (
+	"sync"
+	"time"
+)
+
+// WebhookDelivery records the result of posting an event to a webhook target
+type WebhookDelivery struct {
+	Date       time.Time
+	Target     string
+	Url        string
+	Event      Event
+	Attempts   int
+	StatusCode int
+	Success    bool
+	Error      string
+}
+
+type WebhookDeliveryList []WebhookDelivery
+
+// SafeWebhookDeliveryList adds a mutex to allow thread-safe access of the deliveries
+type SafeWebhookDeliveryList struct {
+	sync.RWMutex
+	Deliveries WebhookDeliveryList
+}
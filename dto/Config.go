@@ -2,7 +2,9 @@
 package dto
 
 import (
+	"fmt"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/johannes-kuhfuss/alighieri/config"
@@ -11,24 +13,30 @@ import (
 
 // ConfigResp converted configuration data for display on the web UI
 type ConfigResp struct {
-	ServerHost                 string
-	ServerPort                 string
-	ServerTlsPort              string
-	ServerGracefulShutdownTime string
-	ServerUseTls               string
-	ServerCertFile             string
-	ServerKeyFile              string
-	GinMode                    string
-	StartDate                  string
-	LogFile                    string
-	ScanCycleSec               string
-	DeviceScanNumber           string
-	LastDeviceScanDate         string
-	DevicesInList              string
-	DeviceScanRunning          string
-	DeviceScanTimeOut          string
-	DeviceScanInterfaceName    string
-	DeviceScanServiceName      string
+	ServerHost                 string `json:"serverHost"`
+	ServerPort                 string `json:"serverPort"`
+	ServerTlsPort              string `json:"serverTlsPort"`
+	ServerGracefulShutdownTime string `json:"serverGracefulShutdownTime"`
+	ServerUseTls               string `json:"serverUseTls"`
+	ServerCertFile             string `json:"serverCertFile"`
+	ServerKeyFile              string `json:"serverKeyFile"`
+	GinMode                    string `json:"ginMode"`
+	StartDate                  string `json:"startDate"`
+	LogFile                    string `json:"logFile"`
+	ScanCycleSec               string `json:"scanCycleSec"`
+	DeviceScanNumber           string `json:"deviceScanNumber"`
+	LastDeviceScanDate         string `json:"lastDeviceScanDate"`
+	DevicesInList              string `json:"devicesInList"`
+	DeviceScanRunning          string `json:"deviceScanRunning"`
+	DeviceScanTimeOut          string `json:"deviceScanTimeOut"`
+	DeviceScanInterfaceName    string `json:"deviceScanInterfaceName"`
+	DeviceScanServiceNames     string `json:"deviceScanServiceNames"`
+	DeviceStaleAfter           string `json:"deviceStaleAfter"`
+	DeviceOfflineAfter         string `json:"deviceOfflineAfter"`
+	DeviceRetention            string `json:"deviceRetention"`
+	DeviceGoodbyes             string `json:"deviceGoodbyes"`
+	DeviceStore                string `json:"deviceStore"`
+	MqttBroker                 string `json:"mqttBroker"`
 }
 
 // setStartDate sets the service start date and adds the run duration
@@ -82,8 +90,28 @@ func GetConfig(cfg *config.AppConfig) (resp ConfigResp) {
 		DevicesInList:              strconv.Itoa(cfg.RunTime.DevicesInList),
 		DeviceScanRunning:          strconv.FormatBool(cfg.RunTime.DeviceScanRunning),
 		DeviceScanTimeOut:          strconv.Itoa(cfg.DeviceScan.ScanTimeOutSec),
-		DeviceScanInterfaceName:    cfg.RunTime.DeviceScanInterface.Name,
-		DeviceScanServiceName:      cfg.DeviceScan.ServiceName,
+		DeviceScanServiceNames:     strings.Join(cfg.DeviceScan.ServiceNames, ", "),
+	}
+	var ifaceNames []string
+	for _, iface := range cfg.RunTime.DeviceScanInterfaces {
+		ifaceNames = append(ifaceNames, iface.Name)
+	}
+	resp.DeviceScanInterfaceName = strings.Join(ifaceNames, ", ")
+	staleAfter, offlineAfter := config.DeviceStateThresholds(cfg)
+	resp.DeviceStaleAfter = fmt.Sprintf("%v missed scan cycle(s) (%v)", cfg.DeviceScan.StaleAfter, staleAfter)
+	resp.DeviceOfflineAfter = fmt.Sprintf("%v missed scan cycle(s) (%v)", cfg.DeviceScan.OfflineAfter, offlineAfter)
+	resp.DeviceRetention = "Devices are kept forever"
+	if cfg.DeviceScan.RetentionHours > 0 {
+		resp.DeviceRetention = fmt.Sprintf("%v hour(s)", cfg.DeviceScan.RetentionHours)
+	}
+	resp.DeviceGoodbyes = strconv.FormatBool(cfg.DeviceScan.Goodbyes)
+	resp.DeviceStore = cfg.Storage.DeviceBackend
+	if cfg.Storage.DeviceBackend == "bolt" {
+		resp.DeviceStore += " (" + cfg.Storage.DeviceFile + ")"
+	}
+	resp.MqttBroker = "disabled"
+	if cfg.Mqtt.Broker != "" {
+		resp.MqttBroker = cfg.Mqtt.Broker + " (topic prefix " + cfg.Mqtt.TopicPrefix + ")"
 	}
 	resp.StartDate = setStartDate(cfg.RunTime.StartDate)
 	if cfg.Server.Host == "" {
@@ -52,3 +52,16 @@ func TestGetNextJobDateJobReturnsDate(t *testing.T) {
 	j := getNextJobDate(&testConfig, int(id))
 	assert.EqualValues(t, "0001-01-01 00:00:00 +0000 UTC", j)
 }
+
+func TestGetJobsUnscheduledJobReturnsNA(t *testing.T) {
+	config.InitConfig("", &testConfig)
+	testConfig.RunTime.ScheduledJobs = []config.JobStatus{
+		{Definition: config.JobDefinition{Name: "morning", Schedule: "never", Action: "recall", Argument: "day"}},
+	}
+	jobs := GetJobs(&testConfig)
+
+	assert.EqualValues(t, 1, len(jobs))
+	assert.EqualValues(t, "N/A", jobs[0].NextRun)
+	assert.EqualValues(t, "N/A", jobs[0].LastRun)
+	assert.EqualValues(t, "N/A", jobs[0].LastResult)
+}
@@ -0,0 +1,45 @@
+// package dto defines the data structures used to exchange information
+package dto
+
+import (
+	"github.com/johannes-kuhfuss/alighieri/domain"
+)
+
+// ConflictReportResp defines the result of the last conflict analysis for display on the status page and in the API
+type ConflictReportResp struct {
+	Date           string         `json:"date"`
+	DevicesChecked int            `json:"devicesChecked"`
+	DuplicateName  int            `json:"duplicateName"`
+	DuplicateIp    int            `json:"duplicateIp"`
+	LinkLocal      int            `json:"linkLocal"`
+	OutsideSubnet  int            `json:"outsideSubnet"`
+	Conflicts      []ConflictResp `json:"conflicts"`
+}
+
+// ConflictResp defines a single conflict
+type ConflictResp struct {
+	Type    string   `json:"type"`
+	Devices []string `json:"devices"`
+	Detail  string   `json:"detail"`
+}
+
+// GetConflictReport converts the conflict report to its display format
+func GetConflictReport(report domain.ConflictReport) ConflictReportResp {
+	dta := ConflictReportResp{
+		Date:           convertDate(report.Date),
+		DevicesChecked: report.Checked,
+		DuplicateName:  report.Count(domain.ConflictDuplicateName),
+		DuplicateIp:    report.Count(domain.ConflictDuplicateIp),
+		LinkLocal:      report.Count(domain.ConflictLinkLocal),
+		OutsideSubnet:  report.Count(domain.ConflictOutsideSubnet),
+		Conflicts:      []ConflictResp{},
+	}
+	for _, conflict := range report.Conflicts {
+		dta.Conflicts = append(dta.Conflicts, ConflictResp{
+			Type:    string(conflict.Type),
+			Devices: append([]string{}, conflict.Devices...),
+			Detail:  conflict.Detail,
+		})
+	}
+	return dta
+}
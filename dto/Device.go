@@ -3,45 +3,72 @@ package dto
 
 import (
 	"fmt"
+	"net"
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/johannes-kuhfuss/alighieri/config"
 	"github.com/johannes-kuhfuss/alighieri/domain"
 	"github.com/johannes-kuhfuss/alighieri/repositories"
 )
 
-// DeviceResp defines the data to be displayed in the device list
+// DeviceResp defines the data to be displayed in the device list and returned by the JSON API
 type DeviceResp struct {
-	Name         string
-	FullName     string
-	HostName     string
-	IPv4         string
-	Port         string
-	Manufacturer string
-	Model        string
-	Info         string
-	FirstSeen    string
-	LastSeen     string
+	Name          string         `json:"name"`
+	Id            string         `json:"id"`
+	Key           string         `json:"key"`
+	PreviousNames []string       `json:"previousNames"`
+	FullName      string         `json:"fullName"`
+	HostName      string         `json:"hostName"`
+	IPv4          string         `json:"ipv4"`
+	Addresses     []string       `json:"addresses"`
+	ArcPort       string         `json:"arcPort"`
+	CmcPort       string         `json:"cmcPort"`
+	DbcPort       string         `json:"dbcPort"`
+	Services      string         `json:"services"`
+	Manufacturer  string         `json:"manufacturer"`
+	Model         string         `json:"model"`
+	Info          string         `json:"info"`
+	FirstSeen     string         `json:"firstSeen"`
+	LastSeen      string         `json:"lastSeen"`
+	State         string         `json:"state"`
+	DanteName     string         `json:"danteName"`
+	TxChannels    []ChannelResp  `json:"txChannels"`
+	RxChannels    []ChannelResp  `json:"rxChannels"`
+	Networks      string         `json:"networks"`
+	Sightings     []SightingResp `json:"sightings"`
+	Settings      SettingsResp   `json:"settings"`
+}
+
+// SightingResp defines the data to be displayed and returned per network interface a device answered on
+type SightingResp struct {
+	Interface string   `json:"interface"`
+	IPv4      string   `json:"ipv4"`
+	Addresses []string `json:"addresses"`
+	LastSeen  string   `json:"lastSeen"`
+	Missing   bool     `json:"missing"`
+}
+
+// ChannelResp defines the data to be displayed and returned per channel of a device
+type ChannelResp struct {
+	Number       string `json:"number"`
+	Name         string `json:"name"`
+	SampleRate   string `json:"sampleRate"`
+	Encoding     string `json:"encoding"`
+	Latency      string `json:"latency"`
+	Subscription string `json:"subscription"`
+	Status       string `json:"status"`
 }
 
 // GetDevices retrives all devices maintained in the repository and formats them for display purposes
 func GetDevices(repo *repositories.DefaultDeviceRepository) (deviceDta []DeviceResp) {
+	staleAfter, offlineAfter := config.DeviceStateThresholds(repo.Cfg)
+	now := time.Now()
 	if devices := repo.GetAll(); devices != nil {
 		for _, device := range *devices {
-			dta := DeviceResp{
-				Name:         device.Name,
-				FullName:     device.FullName,
-				HostName:     device.HostName,
-				IPv4:         device.IPv4.String(),
-				Port:         strconv.Itoa(device.Port),
-				Manufacturer: device.Manufacturer,
-				Model:        device.Model,
-				Info:         combineInfo(device),
-				FirstSeen:    device.FirstSeen.Format("2006-01-02 15:04:05"),
-				LastSeen:     device.LastSeen.Format("2006-01-02 15:04:05"),
-			}
-			deviceDta = append(deviceDta, dta)
+			deviceDta = append(deviceDta, getDevice(device, device.State(now, staleAfter, offlineAfter), staleAfter))
 		}
 	}
 	sort.SliceStable(deviceDta, func(i, j int) bool {
@@ -54,6 +81,190 @@ func GetDevices(repo *repositories.DefaultDeviceRepository) (deviceDta []DeviceR
 	return
 }
 
+// GetDevice retrieves a single device identified by its id or its name and formats it for display purposes, see DefaultDeviceRepository.Find.
+// Returns nil if the device does not exist or its name is not unique
+func GetDevice(repo *repositories.DefaultDeviceRepository, ref string) *DeviceResp {
+	return formatDevice(repo, repo.Find(ref))
+}
+
+// GetDeviceByName retrieves the most recently seen device with the given name and formats it for display purposes. Returns nil if no device has the name
+func GetDeviceByName(repo *repositories.DefaultDeviceRepository, name string) *DeviceResp {
+	return formatDevice(repo, repo.GetByName(name))
+}
+
+// GetDeviceByKey retrieves a single device identified by its identity, see domain.DeviceInfo.Key, and formats it for display purposes. Returns nil if the device does not exist
+func GetDeviceByKey(repo *repositories.DefaultDeviceRepository, key string) *DeviceResp {
+	return formatDevice(repo, repo.GetByKey(key))
+}
+
+// formatDevice formats a device for display purposes. Returns nil if there is no device
+func formatDevice(repo *repositories.DefaultDeviceRepository, device *domain.DeviceInfo) *DeviceResp {
+	if device == nil {
+		return nil
+	}
+	staleAfter, offlineAfter := config.DeviceStateThresholds(repo.Cfg)
+	dta := getDevice(*device, device.State(time.Now(), staleAfter, offlineAfter), staleAfter)
+	return &dta
+}
+
+// FilterDevices returns the devices matching manufacturer, model and state, ignoring case. Empty values match all
+func FilterDevices(devices []DeviceResp, manufacturer string, model string, state string) (filtered []DeviceResp) {
+	matches := func(value string, filter string) bool {
+		return filter == "" || strings.EqualFold(value, filter)
+	}
+	for _, device := range devices {
+		if matches(device.Manufacturer, manufacturer) && matches(device.Model, model) && matches(device.State, state) {
+			filtered = append(filtered, device)
+		}
+	}
+	return
+}
+
+// FilterDevicesByAddress returns the device using an IPv4 or IPv6 address, see DefaultDeviceRepository.GetByAddress. An empty address matches all
+func FilterDevicesByAddress(repo *repositories.DefaultDeviceRepository, devices []DeviceResp, address string) (filtered []DeviceResp) {
+	if address == "" {
+		return devices
+	}
+	device := repo.GetByAddress(address)
+	if device == nil {
+		return
+	}
+	for _, dta := range devices {
+		if dta.Key == device.Key() {
+			filtered = append(filtered, dta)
+		}
+	}
+	return
+}
+
+// getDevice formats a single device for display purposes
+func getDevice(device domain.DeviceInfo, state domain.DeviceState, staleAfter time.Duration) DeviceResp {
+	sightings := getSightings(device, staleAfter)
+	return DeviceResp{
+		Name:          device.Name,
+		Id:            device.Id,
+		Key:           device.Key(),
+		PreviousNames: append([]string{}, device.PreviousNames...),
+		FullName:      device.FullName,
+		HostName:      device.HostName,
+		IPv4:          device.IPv4.String(),
+		Addresses:     formatAddresses(device.Addresses),
+		ArcPort:       formatNumber(device.ArcPort),
+		CmcPort:       formatNumber(device.CmcPort),
+		DbcPort:       formatNumber(device.DbcPort),
+		Services:      formatServices(device.Services),
+		Manufacturer:  device.Manufacturer,
+		Model:         device.Model,
+		Info:          combineInfo(device),
+		FirstSeen:     device.FirstSeen.Format("2006-01-02 15:04:05"),
+		LastSeen:      device.LastSeen.Format("2006-01-02 15:04:05"),
+		State:         string(state),
+		DanteName:     device.DanteName,
+		TxChannels:    getChannels(device.TxChannels),
+		RxChannels:    getChannels(device.RxChannels),
+		Networks:      formatSightings(sightings),
+		Sightings:     sightings,
+		Settings:      GetSettings(device.Settings),
+	}
+}
+
+// getSightings formats the network interfaces a device answered on. Interfaces the device stopped answering on are marked as missing
+func getSightings(device domain.DeviceInfo, staleAfter time.Duration) (sightingDta []SightingResp) {
+	missing := device.MissingOn(staleAfter)
+	for _, sighting := range device.Sightings {
+		dta := SightingResp{
+			Interface: sighting.Interface,
+			IPv4:      sighting.IPv4.String(),
+			Addresses: formatAddresses(sighting.Addresses),
+			LastSeen:  sighting.LastSeen.Format("2006-01-02 15:04:05"),
+		}
+		for _, m := range missing {
+			if m.Interface == sighting.Interface {
+				dta.Missing = true
+			}
+		}
+		sightingDta = append(sightingDta, dta)
+	}
+	return
+}
+
+// formatAddresses converts IPv4 and IPv6 addresses to strings, link-local IPv6 addresses including their zone, e.g. "fe80::1%eth0"
+func formatAddresses(addrs []net.IPAddr) []string {
+	formatted := []string{}
+	for _, addr := range addrs {
+		formatted = append(formatted, addr.String())
+	}
+	return formatted
+}
+
+// formatSightings lists the network interfaces with their addresses in one line, e.g. "eth0: 192.168.1.10, eth1: 192.168.2.10 (not seen since 2024-05-01 10:00:00)"
+func formatSightings(sightings []SightingResp) string {
+	var formatted []string
+	for _, sighting := range sightings {
+		entry := sighting.Interface + ": " + sighting.IPv4
+		if sighting.Missing {
+			entry += " (not seen since " + sighting.LastSeen + ")"
+		}
+		formatted = append(formatted, entry)
+	}
+	return strings.Join(formatted, ", ")
+}
+
+// getChannels formats a device's channels for display purposes
+func getChannels(channels domain.ChannelList) (channelDta []ChannelResp) {
+	for _, channel := range channels {
+		dta := ChannelResp{
+			Number:     strconv.Itoa(channel.Number),
+			Name:       channel.Name,
+			SampleRate: formatNumber(channel.SampleRate),
+			Encoding:   formatNumber(channel.Encoding),
+			Latency:    formatLatency(channel.LatencyNs),
+		}
+		if channel.Direction == domain.ChannelRx {
+			dta.Subscription = formatSource(channel.TxChannelName, channel.TxDeviceName)
+			dta.Status = channel.SubscriptionStatus.String()
+		}
+		channelDta = append(channelDta, dta)
+	}
+	return
+}
+
 func combineInfo(device domain.DeviceInfo) string {
-	return fmt.Sprintf("Id: %s, Process: %s, CMCP Version: %s, CMCP Min: %s, Server Version: %s, Channels: %s", device.Id, device.Process, device.CmcpVersion, device.CmcpMin, device.ServerVersion, device.Channels)
+	info := fmt.Sprintf("Id: %s, Process: %s, CMCP Version: %s, CMCP Min: %s, Server Version: %s, Channels: %s", device.Id, device.Process, device.CmcpVersion, device.CmcpMin, device.ServerVersion, device.Channels)
+	if device.DanteName != "" {
+		info += fmt.Sprintf(", Dante Name: %s, TX Channels: %d, RX Channels: %d", device.DanteName, device.TxChannelCount, device.RxChannelCount)
+	}
+	if settings := formatSettings(device.Settings); settings != "" {
+		info += ", Settings: " + settings
+	}
+	if len(device.PreviousNames) > 0 {
+		info += ", Previous Names: " + strings.Join(device.PreviousNames, ", ")
+	}
+	return info
+}
+
+// formatNumber converts a number to its display format
+func formatNumber(number int) string {
+	if number == 0 {
+		return "N/A"
+	}
+	return strconv.Itoa(number)
+}
+
+// formatLatency converts a latency in nanoseconds to its display format
+func formatLatency(latencyNs int) string {
+	if latencyNs == 0 {
+		return "N/A"
+	}
+	return (time.Duration(latencyNs) * time.Nanosecond).String()
+}
+
+// formatServices converts the list of advertised service types to a short display format, e.g. "arc, cmc"
+func formatServices(services []string) string {
+	var short []string
+	for _, service := range services {
+		short = append(short, strings.TrimSuffix(strings.TrimPrefix(service, "_netaudio-"), "._udp"))
+	}
+	sort.Strings(short)
+	return strings.Join(short, ", ")
 }
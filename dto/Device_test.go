@@ -0,0 +1,29 @@
+package dto
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/johannes-kuhfuss/alighieri/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetDeviceMarksMissingInterface(t *testing.T) {
+	lastSeen := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	device := domain.DeviceInfo{
+		Name:     "stagebox",
+		LastSeen: lastSeen,
+		Sightings: []domain.Sighting{
+			{Interface: "eth0", IPv4: net.ParseIP("192.168.1.10"), LastSeen: lastSeen},
+			{Interface: "eth1", IPv4: net.ParseIP("192.168.2.10"), LastSeen: lastSeen.Add(-time.Hour)},
+		},
+	}
+
+	dta := getDevice(device, domain.DeviceOnline, time.Minute)
+
+	assert.EqualValues(t, 2, len(dta.Sightings))
+	assert.False(t, dta.Sightings[0].Missing)
+	assert.True(t, dta.Sightings[1].Missing)
+	assert.EqualValues(t, "eth0: 192.168.1.10, eth1: 192.168.2.10 (not seen since 2024-05-01 09:00:00)", dta.Networks)
+}
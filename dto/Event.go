@@ -0,0 +1,59 @@
+// package dto defines the data structures used to exchange information
+package dto
+
+import (
+	"sort"
+
+	"github.com/johannes-kuhfuss/alighieri/domain"
+	"github.com/johannes-kuhfuss/alighieri/repositories"
+)
+
+// EventResp defines an event for display in the event history
+type EventResp struct {
+	Date     string
+	Type     string
+	Device   string
+	Property string
+	OldValue string
+	NewValue string
+}
+
+// GetEvents retrieves the events matching the device and event type, newest first, and formats them for display purposes
+func GetEvents(repo *repositories.DefaultEventRepository, device string, eventType string) (eventDta []EventResp) {
+	if events := repo.Find(device, domain.EventType(eventType)); events != nil {
+		for _, e := range *events {
+			eventDta = append(eventDta, EventResp{
+				Date:     convertDate(e.Date),
+				Type:     string(e.Type),
+				Device:   e.Device,
+				Property: e.Property,
+				OldValue: e.OldValue,
+				NewValue: e.NewValue,
+			})
+		}
+	}
+	return
+}
+
+// GetEventTypes returns all event types for the filter selection
+func GetEventTypes() (types []string) {
+	for _, eventType := range domain.EventTypes {
+		types = append(types, string(eventType))
+	}
+	return
+}
+
+// GetEventDevices returns the names of all devices having events for the filter selection, sorted by name
+func GetEventDevices(repo *repositories.DefaultEventRepository) (devices []string) {
+	seen := make(map[string]bool)
+	if events := repo.Find("", ""); events != nil {
+		for _, e := range *events {
+			if !seen[e.Device] {
+				seen[e.Device] = true
+				devices = append(devices, e.Device)
+			}
+		}
+	}
+	sort.Strings(devices)
+	return
+}
@@ -0,0 +1,87 @@
+// package dto defines the data structures used to exchange information
+package dto
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/johannes-kuhfuss/alighieri/domain"
+)
+
+// FormatReportResp defines the result of the format analysis for display on the status page and in the API
+type FormatReportResp struct {
+	Standard             string              `json:"standard"`
+	DevicesChecked       int                 `json:"devicesChecked"`
+	DevicesUnknown       int                 `json:"devicesUnknown"`
+	OffStandard          int                 `json:"offStandard"`
+	SubscriptionMismatch int                 `json:"subscriptionMismatch"`
+	Groups               []FormatGroupResp   `json:"groups"`
+	Findings             []FormatFindingResp `json:"findings"`
+}
+
+// FormatGroupResp defines a group of devices running with the same format
+type FormatGroupResp struct {
+	Format     string   `json:"format"`
+	SampleRate int      `json:"sampleRate"`
+	Encoding   int      `json:"encoding"`
+	PullUp     string   `json:"pullUp"`
+	Standard   bool     `json:"standard"`
+	Devices    []string `json:"devices"`
+}
+
+// FormatFindingResp defines a device deviating from the house standard or a subscription between devices running different formats
+type FormatFindingResp struct {
+	Issue   string   `json:"issue"`
+	Devices []string `json:"devices"`
+	Detail  string   `json:"detail"`
+}
+
+// GetFormatReport converts the format report to its display format
+func GetFormatReport(report domain.FormatReport) FormatReportResp {
+	dta := FormatReportResp{
+		Standard:             formatStandard(report.Standard),
+		DevicesChecked:       report.Checked,
+		DevicesUnknown:       report.Unknown,
+		OffStandard:          report.Count(domain.FormatOffStandard),
+		SubscriptionMismatch: report.Count(domain.FormatMismatch),
+		Groups:               []FormatGroupResp{},
+		Findings:             []FormatFindingResp{},
+	}
+	for _, group := range report.Groups {
+		dta.Groups = append(dta.Groups, FormatGroupResp{
+			Format:     group.Format.String(),
+			SampleRate: group.Format.SampleRate,
+			Encoding:   group.Format.Encoding,
+			PullUp:     group.Format.PullUp.String(),
+			Standard:   group.Standard,
+			Devices:    append([]string{}, group.Devices...),
+		})
+	}
+	for _, finding := range report.Findings {
+		dta.Findings = append(dta.Findings, FormatFindingResp{
+			Issue:   string(finding.Issue),
+			Devices: append([]string{}, finding.Devices...),
+			Detail:  finding.Detail,
+		})
+	}
+	return dta
+}
+
+// formatStandard converts the house standard to its display format, e.g. "48000 Hz, 24 bit, 1ms latency". Settings not checked are left out
+func formatStandard(standard domain.DeviceSettings) string {
+	var parts []string
+	if standard.SampleRate != 0 {
+		parts = append(parts, strconv.Itoa(standard.SampleRate)+" Hz")
+	}
+	if standard.Encoding != 0 {
+		parts = append(parts, strconv.Itoa(standard.Encoding)+" bit")
+	}
+	if standard.LatencyNs != 0 {
+		parts = append(parts, time.Duration(standard.LatencyNs).String()+" latency")
+	}
+	if len(parts) == 0 {
+		return "N/A"
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,44 @@
+// package dto defines the data structures used to exchange information
+package dto
+
+import (
+	"github.com/johannes-kuhfuss/alighieri/config"
+)
+
+// JobResp defines a scheduled job and its last result for display on the web UI
+type JobResp struct {
+	Name        string
+	Schedule    string
+	Action      string
+	Argument    string
+	NextRun     string
+	LastRun     string
+	LastResult  string
+	LastSuccess bool
+}
+
+// GetJobs converts the scheduled jobs to their display format
+func GetJobs(cfg *config.AppConfig) (jobDta []JobResp) {
+	cfg.RunTime.Mu.Lock()
+	defer cfg.RunTime.Mu.Unlock()
+	for _, job := range cfg.RunTime.ScheduledJobs {
+		dta := JobResp{
+			Name:        job.Definition.Name,
+			Schedule:    job.Definition.Schedule,
+			Action:      job.Definition.Action,
+			Argument:    job.Definition.Argument,
+			NextRun:     "N/A",
+			LastRun:     convertDate(job.LastRun),
+			LastResult:  job.LastResult,
+			LastSuccess: job.LastSuccess,
+		}
+		if job.EntryId != 0 {
+			dta.NextRun = getNextJobDate(cfg, job.EntryId)
+		}
+		if dta.LastResult == "" {
+			dta.LastResult = "N/A"
+		}
+		jobDta = append(jobDta, dta)
+	}
+	return
+}
@@ -0,0 +1,130 @@
+// package dto defines the data structures used to exchange information
+package dto
+
+import (
+	"sort"
+	"strconv"
+
+	"github.com/johannes-kuhfuss/alighieri/domain"
+	"github.com/johannes-kuhfuss/alighieri/repositories"
+)
+
+// MatrixResp defines the data to be displayed in the routing matrix with transmit channels as columns and receive channels as rows
+type MatrixResp struct {
+	Columns   int
+	TxDevices []MatrixTxDevice
+	RxDevices []MatrixRxDevice
+}
+
+// MatrixTxDevice defines a transmitting device and its channels, making up a group of columns
+type MatrixTxDevice struct {
+	Index    int
+	Name     string
+	Channels []string
+}
+
+// MatrixRxDevice defines a receiving device and its channels, making up a group of rows
+type MatrixRxDevice struct {
+	Index    int
+	Name     string
+	Channels []MatrixRxChannel
+}
+
+// MatrixRxChannel defines a row of the matrix
+type MatrixRxChannel struct {
+	Number string
+	Name   string
+	Groups []MatrixCellGroup
+}
+
+// MatrixCellGroup holds the crosspoints of a row with all channels of one transmitting device
+type MatrixCellGroup struct {
+	TxIndex    int
+	Subscribed int
+	Cells      []MatrixCell
+}
+
+// MatrixCell defines a single crosspoint
+type MatrixCell struct {
+	TxDevice   string
+	TxChannel  string
+	Subscribed bool
+}
+
+// GetMatrix builds the routing matrix from all devices in the repository
+func GetMatrix(repo *repositories.DefaultDeviceRepository) (matrix MatrixResp) {
+	var txDevices, rxDevices domain.DeviceList
+	if devices := repo.GetAll(); devices != nil {
+		for _, device := range *devices {
+			if len(device.TxChannels) > 0 {
+				txDevices = append(txDevices, device)
+			}
+			if len(device.RxChannels) > 0 {
+				rxDevices = append(rxDevices, device)
+			}
+		}
+	}
+	sortByName(txDevices)
+	sortByName(rxDevices)
+	for i, device := range txDevices {
+		txDevice := MatrixTxDevice{
+			Index: i,
+			Name:  device.Name,
+		}
+		for _, channel := range device.TxChannels {
+			txDevice.Channels = append(txDevice.Channels, channel.Name)
+		}
+		matrix.TxDevices = append(matrix.TxDevices, txDevice)
+		// each transmitting device has its channel columns plus a summary column shown when collapsed
+		matrix.Columns += len(txDevice.Channels) + 1
+	}
+	// channel number and name
+	matrix.Columns += 2
+	for i, device := range rxDevices {
+		rxDevice := MatrixRxDevice{
+			Index: i,
+			Name:  device.Name,
+		}
+		for _, channel := range device.RxChannels {
+			row := MatrixRxChannel{
+				Number: strconv.Itoa(channel.Number),
+				Name:   channel.Name,
+			}
+			for j, tx := range txDevices {
+				group := MatrixCellGroup{
+					TxIndex: j,
+				}
+				for _, txChannel := range tx.TxChannels {
+					cell := MatrixCell{
+						TxDevice:   tx.Name,
+						TxChannel:  txChannel.Name,
+						Subscribed: isSubscribedTo(channel, tx, txChannel.Name),
+					}
+					if cell.Subscribed {
+						group.Subscribed++
+					}
+					group.Cells = append(group.Cells, cell)
+				}
+				row.Groups = append(row.Groups, group)
+			}
+			rxDevice.Channels = append(rxDevice.Channels, row)
+		}
+		matrix.RxDevices = append(matrix.RxDevices, rxDevice)
+	}
+	return
+}
+
+// isSubscribedTo checks whether a receive channel is subscribed to the given transmit channel. Devices refer to each other by their Dante name
+func isSubscribedTo(rx domain.ChannelInfo, txDevice domain.DeviceInfo, txChannelName string) bool {
+	if !rx.IsSubscribed() || rx.TxChannelName != txChannelName {
+		return false
+	}
+	return rx.TxDeviceName == txDevice.Name || (txDevice.DanteName != "" && rx.TxDeviceName == txDevice.DanteName)
+}
+
+// sortByName sorts a device list by device name
+func sortByName(devices domain.DeviceList) {
+	sort.SliceStable(devices, func(i, j int) bool {
+		return devices[i].Name < devices[j].Name
+	})
+}
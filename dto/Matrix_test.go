@@ -0,0 +1,43 @@
+package dto
+
+import (
+	"testing"
+
+	"github.com/johannes-kuhfuss/alighieri/config"
+	"github.com/johannes-kuhfuss/alighieri/domain"
+	"github.com/johannes-kuhfuss/alighieri/repositories"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetMatrixEmptyRepoReturnsEmptyMatrix(t *testing.T) {
+	config.InitConfig("", &testConfig)
+	repo := repositories.NewDeviceRepository(&testConfig)
+	matrix := GetMatrix(&repo)
+
+	assert.EqualValues(t, 0, len(matrix.TxDevices))
+	assert.EqualValues(t, 0, len(matrix.RxDevices))
+}
+
+func TestGetMatrixMarksSubscriptionsByDanteName(t *testing.T) {
+	config.InitConfig("", &testConfig)
+	repo := repositories.NewDeviceRepository(&testConfig)
+	repo.Store(domain.DeviceInfo{
+		Name:       "stagebox",
+		RxChannels: domain.ChannelList{{Number: 1, Name: "In 1", TxChannelName: "Out 2", TxDeviceName: "mixer-dante"}, {Number: 2, Name: "In 2"}},
+	})
+	repo.Store(domain.DeviceInfo{
+		Name:       "mixer",
+		DanteName:  "mixer-dante",
+		TxChannels: domain.ChannelList{{Number: 1, Name: "Out 1"}, {Number: 2, Name: "Out 2"}},
+	})
+	matrix := GetMatrix(&repo)
+
+	assert.EqualValues(t, 1, len(matrix.TxDevices))
+	assert.EqualValues(t, 1, len(matrix.RxDevices))
+	assert.EqualValues(t, 5, matrix.Columns)
+	row := matrix.RxDevices[0].Channels[0]
+	assert.False(t, row.Groups[0].Cells[0].Subscribed)
+	assert.True(t, row.Groups[0].Cells[1].Subscribed)
+	assert.EqualValues(t, 1, row.Groups[0].Subscribed)
+	assert.EqualValues(t, 0, matrix.RxDevices[0].Channels[1].Groups[0].Subscribed)
+}
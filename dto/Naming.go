@@ -0,0 +1,102 @@
+// package dto defines the data structures used to exchange information
+package dto
+
+import (
+	"github.com/johannes-kuhfuss/alighieri/config"
+	"github.com/johannes-kuhfuss/alighieri/domain"
+)
+
+// NamingReportResp defines the naming policy compliance report for display on the web UI and in the API
+type NamingReportResp struct {
+	Pattern           string              `json:"pattern"`
+	Rules             []NamingRuleResp    `json:"rules"`
+	UniqueNames       bool                `json:"uniqueNames"`
+	DevicesChecked    int                 `json:"devicesChecked"`
+	Compliant         int                 `json:"compliant"`
+	PatternViolations int                 `json:"patternViolations"`
+	Duplicates        int                 `json:"duplicates"`
+	Findings          []NamingFindingResp `json:"findings"`
+	Renames           []NamingRenameResp  `json:"renames"`
+}
+
+// NamingRuleResp defines the pattern applying to the devices of a manufacturer and model
+type NamingRuleResp struct {
+	Manufacturer string `json:"manufacturer"`
+	Model        string `json:"model"`
+	Pattern      string `json:"pattern"`
+}
+
+// NamingFindingResp defines a violation of the naming policy by a device
+type NamingFindingResp struct {
+	Device string `json:"device"`
+	Issue  string `json:"issue"`
+	Detail string `json:"detail"`
+}
+
+// NamingRenameResp defines a suggested rename and, once sent to the device, its result
+type NamingRenameResp struct {
+	Device  string `json:"device"`
+	NewName string `json:"newName"`
+	Applied bool   `json:"applied"`
+	Error   string `json:"error,omitempty"`
+}
+
+// GetNamingReport converts the naming report and the policy it was checked against to its display format
+func GetNamingReport(report domain.NamingReport, cfg *config.AppConfig) NamingReportResp {
+	dta := NamingReportResp{
+		Pattern:           formatPattern(cfg.NamingPolicy.Pattern),
+		Rules:             []NamingRuleResp{},
+		UniqueNames:       cfg.NamingPolicy.UniqueNames,
+		DevicesChecked:    report.Checked,
+		Compliant:         report.Compliant,
+		PatternViolations: report.Count(domain.NamingPattern),
+		Duplicates:        report.Count(domain.NamingDuplicate),
+		Findings:          []NamingFindingResp{},
+		Renames:           GetNamingRenames(report.Renames),
+	}
+	for _, rule := range cfg.NamingPolicy.Rules {
+		dta.Rules = append(dta.Rules, NamingRuleResp{
+			Manufacturer: formatSelector(rule.Manufacturer),
+			Model:        formatSelector(rule.Model),
+			Pattern:      formatPattern(rule.Pattern),
+		})
+	}
+	for _, finding := range report.Findings {
+		dta.Findings = append(dta.Findings, NamingFindingResp{
+			Device: finding.Device,
+			Issue:  string(finding.Issue),
+			Detail: finding.Detail,
+		})
+	}
+	return dta
+}
+
+// GetNamingRenames converts suggested renames and their results to their display format
+func GetNamingRenames(renames []domain.NamingRename) []NamingRenameResp {
+	dta := []NamingRenameResp{}
+	for _, rename := range renames {
+		dta = append(dta, NamingRenameResp{
+			Device:  rename.Device,
+			NewName: rename.NewName,
+			Applied: rename.Applied,
+			Error:   rename.Error,
+		})
+	}
+	return dta
+}
+
+// formatPattern returns the expression of a naming pattern or "N/A" if it is not set
+func formatPattern(pattern config.NamingPattern) string {
+	if pattern.Regexp == nil {
+		return "N/A"
+	}
+	return pattern.String()
+}
+
+// formatSelector returns the manufacturer or model of a naming rule, "*" if it matches all
+func formatSelector(selector string) string {
+	if selector == "" {
+		return "*"
+	}
+	return selector
+}
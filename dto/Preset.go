@@ -0,0 +1,136 @@
+// package dto defines the data structures used to exchange information
+package dto
+
+import (
+	"fmt"
+
+	"github.com/johannes-kuhfuss/alighieri/domain"
+)
+
+// PresetReq defines the data needed to save the current routing as a preset
+type PresetReq struct {
+	Name string `json:"name" binding:"required"`
+}
+
+// PresetResp defines a preset for display and for the JSON API
+type PresetResp struct {
+	Name          string                   `json:"name"`
+	Created       string                   `json:"created"`
+	Channels      int                      `json:"channels"`
+	Subscribed    int                      `json:"subscribed"`
+	Subscriptions []PresetSubscriptionResp `json:"subscriptions,omitempty"`
+	LastRecall    *RecallResp              `json:"lastRecall,omitempty"`
+}
+
+// PresetSubscriptionResp defines the state of a receive channel within a preset
+type PresetSubscriptionResp struct {
+	RxDevice  string `json:"rxDevice"`
+	RxChannel int    `json:"rxChannel"`
+	TxDevice  string `json:"txDevice"`
+	TxChannel string `json:"txChannel"`
+}
+
+// PresetChangeResp defines a difference between a preset and the live routing
+type PresetChangeResp struct {
+	RxDevice  string `json:"rxDevice"`
+	RxChannel int    `json:"rxChannel"`
+	Current   string `json:"current"`
+	Preset    string `json:"preset"`
+}
+
+// RecallResp defines the outcome of recalling a preset
+type RecallResp struct {
+	Date     string                         `json:"date"`
+	Applied  []PresetChangeResp             `json:"applied"`
+	Failures map[string][]RecallFailureResp `json:"failures"`
+	Summary  string                         `json:"summary"`
+}
+
+// RecallFailureResp defines a change that failed when recalling a preset
+type RecallFailureResp struct {
+	Change PresetChangeResp `json:"change"`
+	Error  string           `json:"error"`
+}
+
+// GetPresets converts all presets for display purposes, leaving out the subscriptions
+func GetPresets(presets *domain.PresetList) (presetDta []PresetResp) {
+	if presets == nil {
+		return
+	}
+	for _, preset := range *presets {
+		dta := GetPreset(preset)
+		dta.Subscriptions = nil
+		presetDta = append(presetDta, dta)
+	}
+	return
+}
+
+// GetPreset converts a preset including its subscriptions
+func GetPreset(preset domain.Preset) PresetResp {
+	dta := PresetResp{
+		Name:     preset.Name,
+		Created:  convertDate(preset.Created),
+		Channels: len(preset.Subscriptions),
+	}
+	for _, sub := range preset.Subscriptions {
+		if sub.TxChannel != "" {
+			dta.Subscribed++
+		}
+		dta.Subscriptions = append(dta.Subscriptions, PresetSubscriptionResp{
+			RxDevice:  sub.RxDevice,
+			RxChannel: sub.RxChannel,
+			TxDevice:  sub.TxDevice,
+			TxChannel: sub.TxChannel,
+		})
+	}
+	if preset.LastRecall != nil {
+		recall := GetRecallResult(*preset.LastRecall)
+		dta.LastRecall = &recall
+	}
+	return dta
+}
+
+// GetPresetChanges converts the differences between a preset and the live routing
+func GetPresetChanges(changes []domain.PresetChange) []PresetChangeResp {
+	changeDta := []PresetChangeResp{}
+	for _, change := range changes {
+		changeDta = append(changeDta, getPresetChange(change))
+	}
+	return changeDta
+}
+
+// GetRecallResult converts the outcome of recalling a preset
+func GetRecallResult(result domain.RecallResult) RecallResp {
+	dta := RecallResp{
+		Date:     convertDate(result.Date),
+		Applied:  GetPresetChanges(result.Applied),
+		Failures: make(map[string][]RecallFailureResp),
+		Summary:  fmt.Sprintf("%v change(s) applied, %v failed", len(result.Applied), result.FailureCount()),
+	}
+	for device, failures := range result.Failures {
+		for _, failure := range failures {
+			dta.Failures[device] = append(dta.Failures[device], RecallFailureResp{
+				Change: getPresetChange(failure.Change),
+				Error:  failure.Error,
+			})
+		}
+	}
+	return dta
+}
+
+func getPresetChange(change domain.PresetChange) PresetChangeResp {
+	return PresetChangeResp{
+		RxDevice:  change.RxDevice,
+		RxChannel: change.RxChannel,
+		Current:   formatSource(change.CurrentTxChannel, change.CurrentTxDevice),
+		Preset:    formatSource(change.PresetTxChannel, change.PresetTxDevice),
+	}
+}
+
+// formatSource converts a transmit channel and device to the display format "channel@device"
+func formatSource(channel string, device string) string {
+	if channel == "" {
+		return ""
+	}
+	return channel + "@" + device
+}
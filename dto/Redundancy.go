@@ -0,0 +1,59 @@
+// package dto defines the data structures used to exchange information
+package dto
+
+import (
+	"github.com/johannes-kuhfuss/alighieri/domain"
+)
+
+// RedundancyResp defines the redundancy report for display on the web UI and in the API
+type RedundancyResp struct {
+	Enabled         bool                    `json:"enabled"`
+	Primary         string                  `json:"primary"`
+	PrimarySubnet   string                  `json:"primarySubnet"`
+	Secondary       string                  `json:"secondary"`
+	SecondarySubnet string                  `json:"secondarySubnet"`
+	DevicesChecked  int                     `json:"devicesChecked"`
+	SingleNetwork   int                     `json:"singleNetwork"`
+	WrongSubnet     int                     `json:"wrongSubnet"`
+	SameSubnet      int                     `json:"sameSubnet"`
+	Findings        []RedundancyFindingResp `json:"findings"`
+}
+
+// RedundancyFindingResp defines an issue found for a device
+type RedundancyFindingResp struct {
+	Device string `json:"device"`
+	Issue  string `json:"issue"`
+	Detail string `json:"detail"`
+}
+
+// GetRedundancyReport converts the redundancy report to its display format
+func GetRedundancyReport(report domain.RedundancyReport) RedundancyResp {
+	dta := RedundancyResp{
+		Enabled:         report.Enabled,
+		Primary:         report.Primary.Interface,
+		PrimarySubnet:   formatSubnet(report.Primary),
+		Secondary:       report.Secondary.Interface,
+		SecondarySubnet: formatSubnet(report.Secondary),
+		DevicesChecked:  report.Checked,
+		SingleNetwork:   report.Count(domain.RedundancySingleNetwork),
+		WrongSubnet:     report.Count(domain.RedundancyWrongSubnet),
+		SameSubnet:      report.Count(domain.RedundancySameSubnet),
+		Findings:        []RedundancyFindingResp{},
+	}
+	for _, finding := range report.Findings {
+		dta.Findings = append(dta.Findings, RedundancyFindingResp{
+			Device: finding.Device,
+			Issue:  string(finding.Issue),
+			Detail: finding.Detail,
+		})
+	}
+	return dta
+}
+
+// formatSubnet returns the subnet of a network or "N/A" if it is unknown
+func formatSubnet(network domain.Network) string {
+	if network.Subnet == nil {
+		return "N/A"
+	}
+	return network.Subnet.String()
+}
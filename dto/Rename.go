@@ -0,0 +1,7 @@
+// package dto defines the data structures used to exchange information
+package dto
+
+// RenameReq defines the new name of a device or channel
+type RenameReq struct {
+	Name string `json:"name" binding:"required"`
+}
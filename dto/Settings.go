@@ -0,0 +1,104 @@
+// package dto defines the data structures used to exchange information
+package dto
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/johannes-kuhfuss/alighieri/domain"
+)
+
+// SettingsReq defines the settings a device is changed to. All settings are required, unchanged settings are not written to the device
+type SettingsReq struct {
+	SampleRate int    `json:"sampleRate" binding:"required"`
+	Encoding   int    `json:"encoding" binding:"required"`
+	LatencyUs  int    `json:"latencyUs" binding:"required"`
+	PullUp     string `json:"pullUp" binding:"required"`
+}
+
+// SettingsResp defines the settings of a device for display on the web UI and in the API
+type SettingsResp struct {
+	SampleRate int    `json:"sampleRate"`
+	Encoding   int    `json:"encoding"`
+	LatencyUs  int    `json:"latencyUs"`
+	PullUp     string `json:"pullUp"`
+	ReadAt     string `json:"readAt"`
+}
+
+// SettingsFormResp defines the data needed to display the settings form of a device
+type SettingsFormResp struct {
+	Device      string
+	Known       bool
+	Current     SettingsResp
+	SampleRates []SettingsOptionResp
+	Encodings   []SettingsOptionResp
+	Latencies   []SettingsOptionResp
+	PullUps     []SettingsOptionResp
+}
+
+// SettingsOptionResp defines a value a setting can be changed to
+type SettingsOptionResp struct {
+	Value    string
+	Label    string
+	Selected bool
+}
+
+// ToSettings converts the request to the settings a device is changed to
+func (req SettingsReq) ToSettings() (domain.DeviceSettings, error) {
+	pullUp, err := domain.ParsePullUp(req.PullUp)
+	if err != nil {
+		return domain.DeviceSettings{}, err
+	}
+	return domain.DeviceSettings{
+		SampleRate: req.SampleRate,
+		Encoding:   req.Encoding,
+		LatencyNs:  req.LatencyUs * int(time.Microsecond),
+		PullUp:     pullUp,
+	}, nil
+}
+
+// GetSettings converts a device's settings to their display format
+func GetSettings(settings domain.DeviceSettings) SettingsResp {
+	dta := SettingsResp{
+		SampleRate: settings.SampleRate,
+		Encoding:   settings.Encoding,
+		LatencyUs:  settings.LatencyNs / int(time.Microsecond),
+		PullUp:     settings.PullUp.String(),
+		ReadAt:     "N/A",
+	}
+	if settings.Known() {
+		dta.ReadAt = settings.ReadAt.Format("2006-01-02 15:04:05")
+	}
+	return dta
+}
+
+// GetSettingsForm lists the values each setting of a device can be changed to, the device's current values selected
+func GetSettingsForm(device domain.DeviceInfo) SettingsFormResp {
+	current := device.Settings
+	dta := SettingsFormResp{
+		Device:  device.Name,
+		Known:   current.Known(),
+		Current: GetSettings(current),
+	}
+	for _, sampleRate := range domain.SampleRates {
+		dta.SampleRates = append(dta.SampleRates, SettingsOptionResp{Value: strconv.Itoa(sampleRate), Label: strconv.Itoa(sampleRate) + " Hz", Selected: sampleRate == current.SampleRate})
+	}
+	for _, encoding := range domain.Encodings {
+		dta.Encodings = append(dta.Encodings, SettingsOptionResp{Value: strconv.Itoa(encoding), Label: strconv.Itoa(encoding) + " bit", Selected: encoding == current.Encoding})
+	}
+	for _, latencyNs := range domain.LatenciesNs {
+		dta.Latencies = append(dta.Latencies, SettingsOptionResp{Value: strconv.Itoa(latencyNs / int(time.Microsecond)), Label: formatLatency(latencyNs), Selected: latencyNs == current.LatencyNs})
+	}
+	for _, pullUp := range domain.PullUps {
+		dta.PullUps = append(dta.PullUps, SettingsOptionResp{Value: pullUp.String(), Label: pullUp.String(), Selected: current.Known() && pullUp == current.PullUp})
+	}
+	return dta
+}
+
+// formatSettings lists the settings of a device in one line, e.g. "48000 Hz, 24 bit, 1ms, pull-up/down none". Returns an empty string if the settings are unknown
+func formatSettings(settings domain.DeviceSettings) string {
+	if !settings.Known() {
+		return ""
+	}
+	return strconv.Itoa(settings.SampleRate) + " Hz, " + strconv.Itoa(settings.Encoding) + " bit, " + formatLatency(settings.LatencyNs) + ", pull-up/down " + settings.PullUp.String()
+}
@@ -0,0 +1,81 @@
+// package dto defines the data structures used to exchange information
+package dto
+
+import (
+	"sort"
+	"strconv"
+
+	"github.com/johannes-kuhfuss/alighieri/repositories"
+)
+
+// SubscriptionReq defines the data needed to subscribe a receive channel to a transmit channel
+type SubscriptionReq struct {
+	RxDevice  string `json:"rxDevice" binding:"required"`
+	RxChannel int    `json:"rxChannel" binding:"required"`
+	TxDevice  string `json:"txDevice" binding:"required"`
+	TxChannel string `json:"txChannel" binding:"required"`
+}
+
+// SubscriptionResp defines the data to be displayed per receive channel in the subscription list
+type SubscriptionResp struct {
+	RxDevice      string
+	RxChannel     string
+	RxChannelName string
+	TxChannel     string
+	TxDevice      string
+	Status        string
+	Subscribed    bool
+}
+
+// TxChannelResp defines a transmit channel available as subscription source
+type TxChannelResp struct {
+	Device  string
+	Channel string
+}
+
+// GetSubscriptions retrieves all receive channels and their subscriptions from the repository and formats them for display purposes
+func GetSubscriptions(repo *repositories.DefaultDeviceRepository) (subscriptionDta []SubscriptionResp) {
+	if devices := repo.GetAll(); devices != nil {
+		for _, device := range *devices {
+			for _, channel := range device.RxChannels {
+				dta := SubscriptionResp{
+					RxDevice:      device.Name,
+					RxChannel:     strconv.Itoa(channel.Number),
+					RxChannelName: channel.Name,
+					TxChannel:     channel.TxChannelName,
+					TxDevice:      channel.TxDeviceName,
+					Status:        channel.SubscriptionStatus.String(),
+					Subscribed:    channel.IsSubscribed(),
+				}
+				subscriptionDta = append(subscriptionDta, dta)
+			}
+		}
+	}
+	sort.SliceStable(subscriptionDta, func(i, j int) bool {
+		if subscriptionDta[i].RxDevice != subscriptionDta[j].RxDevice {
+			return subscriptionDta[i].RxDevice < subscriptionDta[j].RxDevice
+		}
+		a, _ := strconv.Atoi(subscriptionDta[i].RxChannel)
+		b, _ := strconv.Atoi(subscriptionDta[j].RxChannel)
+		return a < b
+	})
+	return
+}
+
+// GetTxChannels retrieves all transmit channels from the repository, sorted by device
+func GetTxChannels(repo *repositories.DefaultDeviceRepository) (channelDta []TxChannelResp) {
+	if devices := repo.GetAll(); devices != nil {
+		for _, device := range *devices {
+			for _, channel := range device.TxChannels {
+				channelDta = append(channelDta, TxChannelResp{
+					Device:  device.Name,
+					Channel: channel.Name,
+				})
+			}
+		}
+	}
+	sort.SliceStable(channelDta, func(i, j int) bool {
+		return channelDta[i].Device < channelDta[j].Device
+	})
+	return
+}
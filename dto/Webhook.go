@@ -0,0 +1,71 @@
+// package dto defines the data structures used to exchange information
+package dto
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/johannes-kuhfuss/alighieri/config"
+	"github.com/johannes-kuhfuss/alighieri/domain"
+)
+
+// WebhookTargetResp defines a webhook target for display on the web UI
+type WebhookTargetResp struct {
+	Name     string
+	Url      string
+	Events   string
+	Template string
+}
+
+// WebhookDeliveryResp defines a webhook delivery for display in the delivery log
+type WebhookDeliveryResp struct {
+	Date       string
+	Target     string
+	Device     string
+	Event      string
+	Attempts   string
+	StatusCode string
+	Success    bool
+	Error      string
+}
+
+// GetWebhookTargets converts the configured webhook targets to their display format
+func GetWebhookTargets(cfg *config.AppConfig) (targetDta []WebhookTargetResp) {
+	for _, target := range cfg.Webhooks.Targets {
+		dta := WebhookTargetResp{
+			Name:     target.Name,
+			Url:      target.Url,
+			Events:   strings.Join(target.Events, ", "),
+			Template: target.TemplateFile,
+		}
+		if dta.Events == "" {
+			dta.Events = "all"
+		}
+		if dta.Template == "" {
+			dta.Template = "default payload"
+		}
+		targetDta = append(targetDta, dta)
+	}
+	return
+}
+
+// GetWebhookDeliveries converts the logged webhook deliveries to their display format
+func GetWebhookDeliveries(deliveries domain.WebhookDeliveryList) (deliveryDta []WebhookDeliveryResp) {
+	for _, d := range deliveries {
+		dta := WebhookDeliveryResp{
+			Date:       convertDate(d.Date),
+			Target:     d.Target,
+			Device:     d.Event.Device,
+			Event:      string(d.Event.Type),
+			Attempts:   strconv.Itoa(d.Attempts),
+			StatusCode: "N/A",
+			Success:    d.Success,
+			Error:      d.Error,
+		}
+		if d.StatusCode != 0 {
+			dta.StatusCode = strconv.Itoa(d.StatusCode)
+		}
+		deliveryDta = append(deliveryDta, dta)
+	}
+	return
+}
@@ -0,0 +1,89 @@
+// package handlers sets up the handlers for the Web UI
+package handlers
+
+import (
+	_ "embed"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/johannes-kuhfuss/alighieri/config"
+	"github.com/johannes-kuhfuss/alighieri/dto"
+	"github.com/johannes-kuhfuss/alighieri/repositories"
+	"github.com/johannes-kuhfuss/alighieri/service"
+	"github.com/johannes-kuhfuss/services_utils/api_error"
+)
+
+//go:embed openapi.yaml
+var openApiDoc []byte
+
+type ApiHandler struct {
+	Cfg       *config.AppConfig
+	Repo      *repositories.DefaultDeviceRepository
+	Scan      service.DeviceScanService
+	Conflicts service.ConflictService
+	Formats   service.FormatService
+}
+
+// NewApiHandler creates a new JSON API handler and injects its dependencies
+func NewApiHandler(cfg *config.AppConfig, repo *repositories.DefaultDeviceRepository, scan service.DeviceScanService, conflicts service.ConflictService, formats service.FormatService) ApiHandler {
+	return ApiHandler{
+		Cfg:       cfg,
+		Repo:      repo,
+		Scan:      scan,
+		Conflicts: conflicts,
+		Formats:   formats,
+	}
+}
+
+// GetDevices is the handler returning all devices, optionally filtered by manufacturer, model, state and address
+func (ah *ApiHandler) GetDevices(c *gin.Context) {
+	devices := dto.FilterDevices(dto.GetDevices(ah.Repo), c.Query("manufacturer"), c.Query("model"), c.Query("state"))
+	devices = dto.FilterDevicesByAddress(ah.Repo, devices, c.Query("address"))
+	if devices == nil {
+		devices = []dto.DeviceResp{}
+	}
+	c.JSON(http.StatusOK, devices)
+}
+
+// GetDevice is the handler returning a single device including its channels
+func (ah *ApiHandler) GetDevice(c *gin.Context) {
+	name := c.Param("name")
+	device := dto.GetDevice(ah.Repo, name)
+	if device == nil {
+		apiErr := api_error.NewNotFoundError(fmt.Sprintf("device %v does not exist or its name is not unique", name))
+		c.JSON(apiErr.StatusCode(), apiErr)
+		return
+	}
+	c.JSON(http.StatusOK, device)
+}
+
+// GetConflicts is the handler returning the conflicts found after the last scan
+func (ah *ApiHandler) GetConflicts(c *gin.Context) {
+	c.JSON(http.StatusOK, dto.GetConflictReport(ah.Conflicts.Report()))
+}
+
+// GetFormats is the handler returning the devices and subscriptions not matching the house standard or each other
+func (ah *ApiHandler) GetFormats(c *gin.Context) {
+	c.JSON(http.StatusOK, dto.GetFormatReport(ah.Formats.Report()))
+}
+
+// GetStatus is the handler returning the scan status and configuration shown on the status page
+func (ah *ApiHandler) GetStatus(c *gin.Context) {
+	c.JSON(http.StatusOK, dto.GetConfig(ah.Cfg))
+}
+
+// TriggerScan is the handler requesting an immediate device scan. The scan runs in the background
+func (ah *ApiHandler) TriggerScan(c *gin.Context) {
+	if !ah.Scan.TriggerScan() {
+		apiErr := api_error.NewProcessingConflictError("a device scan has already been requested")
+		c.JSON(apiErr.StatusCode(), apiErr)
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"message": "device scan requested"})
+}
+
+// OpenApi is the handler returning the OpenAPI document describing the JSON API
+func (ah *ApiHandler) OpenApi(c *gin.Context) {
+	c.Data(http.StatusOK, "application/yaml", openApiDoc)
+}
@@ -0,0 +1,239 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/johannes-kuhfuss/alighieri/config"
+	"github.com/johannes-kuhfuss/alighieri/domain"
+	"github.com/johannes-kuhfuss/alighieri/dto"
+	"github.com/johannes-kuhfuss/alighieri/repositories"
+	"github.com/johannes-kuhfuss/alighieri/service"
+	"github.com/stretchr/testify/assert"
+)
+
+type scanServiceMock struct {
+	pending bool
+}
+
+func (m *scanServiceMock) Scan() {}
+
+func (m *scanServiceMock) ScanRun() error {
+	return nil
+}
+
+func (m *scanServiceMock) TriggerScan() bool {
+	if m.pending {
+		return false
+	}
+	m.pending = true
+	return true
+}
+
+var (
+	ah          ApiHandler
+	scanMock    scanServiceMock
+	conflictSvc service.DefaultConflictService
+)
+
+func setupApiTest() func() {
+	config.InitConfig("", &cfg)
+	repo = repositories.NewDeviceRepository(&cfg)
+	scanMock = scanServiceMock{}
+	conflictSvc = service.NewConflictService(&cfg, &repo)
+	ah = NewApiHandler(&cfg, &repo, &scanMock, conflictSvc, service.NewFormatService(&cfg, &repo))
+	router = gin.Default()
+	router.GET("/api/v1/devices", ah.GetDevices)
+	router.GET("/api/v1/devices/:name", ah.GetDevice)
+	router.GET("/api/v1/status", ah.GetStatus)
+	router.GET("/api/v1/conflicts", ah.GetConflicts)
+	router.GET("/api/v1/formats", ah.GetFormats)
+	router.POST("/api/v1/scan", ah.TriggerScan)
+	router.GET("/api/v1/openapi.yaml", ah.OpenApi)
+	recorder = httptest.NewRecorder()
+	repo.Store(domain.DeviceInfo{Name: "stagebox", Manufacturer: "Audinate", Model: "DAI2", LastSeen: time.Now()})
+	repo.Store(domain.DeviceInfo{Name: "mixer", Manufacturer: "Yamaha", Model: "CL5", LastSeen: time.Now().Add(-24 * time.Hour)})
+	return func() {
+		router = nil
+	}
+}
+
+func TestGetDevicesReturnsAllDevices(t *testing.T) {
+	teardown := setupApiTest()
+	defer teardown()
+	request := httptest.NewRequest(http.MethodGet, "/api/v1/devices", nil)
+
+	router.ServeHTTP(recorder, request)
+	var devices []dto.DeviceResp
+	err := json.Unmarshal(recorder.Body.Bytes(), &devices)
+
+	assert.EqualValues(t, http.StatusOK, recorder.Code)
+	assert.Nil(t, err)
+	assert.EqualValues(t, 2, len(devices))
+	assert.EqualValues(t, "mixer", devices[0].Name)
+}
+
+func TestGetDevicesFiltersByManufacturerAndState(t *testing.T) {
+	teardown := setupApiTest()
+	defer teardown()
+	request := httptest.NewRequest(http.MethodGet, "/api/v1/devices?manufacturer=yamaha&state=offline", nil)
+
+	router.ServeHTTP(recorder, request)
+	var devices []dto.DeviceResp
+	json.Unmarshal(recorder.Body.Bytes(), &devices)
+
+	assert.EqualValues(t, 1, len(devices))
+	assert.EqualValues(t, "mixer", devices[0].Name)
+}
+
+func TestGetDevicesFiltersByIPv6Address(t *testing.T) {
+	teardown := setupApiTest()
+	defer teardown()
+	repo.Store(domain.DeviceInfo{Name: "stagebox", LastSeen: time.Now(), Addresses: []net.IPAddr{{IP: net.ParseIP("fe80::1"), Zone: "eth0"}}})
+	request := httptest.NewRequest(http.MethodGet, "/api/v1/devices?address=fe80::1%25eth0", nil)
+
+	router.ServeHTTP(recorder, request)
+	var devices []dto.DeviceResp
+	json.Unmarshal(recorder.Body.Bytes(), &devices)
+
+	assert.EqualValues(t, 1, len(devices))
+	assert.EqualValues(t, "stagebox", devices[0].Name)
+	assert.EqualValues(t, []string{"fe80::1%eth0"}, devices[0].Addresses)
+}
+
+func TestGetDevicesFilterByAddressSkipsDevicesWithSameName(t *testing.T) {
+	teardown := setupApiTest()
+	defer teardown()
+	repo.Store(domain.DeviceInfo{Name: "stagebox", Id: "001dc1fffe000001", LastSeen: time.Now(), IPv4: net.ParseIP("192.168.1.10")})
+	repo.Store(domain.DeviceInfo{Name: "stagebox", Id: "001dc1fffe000002", LastSeen: time.Now(), IPv4: net.ParseIP("192.168.2.10")})
+	request := httptest.NewRequest(http.MethodGet, "/api/v1/devices?address=192.168.2.10", nil)
+
+	router.ServeHTTP(recorder, request)
+	var devices []dto.DeviceResp
+	json.Unmarshal(recorder.Body.Bytes(), &devices)
+
+	assert.EqualValues(t, 1, len(devices))
+	assert.EqualValues(t, "001dc1fffe000002", devices[0].Id)
+}
+
+func TestGetDevicesNoMatchReturnsEmptyList(t *testing.T) {
+	teardown := setupApiTest()
+	defer teardown()
+	request := httptest.NewRequest(http.MethodGet, "/api/v1/devices?model=unknown", nil)
+
+	router.ServeHTTP(recorder, request)
+
+	assert.EqualValues(t, http.StatusOK, recorder.Code)
+	assert.EqualValues(t, "[]", recorder.Body.String())
+}
+
+func TestGetDeviceUnknownDeviceReturnsNotFound(t *testing.T) {
+	teardown := setupApiTest()
+	defer teardown()
+	request := httptest.NewRequest(http.MethodGet, "/api/v1/devices/unknown", nil)
+
+	router.ServeHTTP(recorder, request)
+
+	assert.EqualValues(t, http.StatusNotFound, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), "device unknown does not exist")
+}
+
+func TestGetDeviceReturnsDevice(t *testing.T) {
+	teardown := setupApiTest()
+	defer teardown()
+	request := httptest.NewRequest(http.MethodGet, "/api/v1/devices/stagebox", nil)
+
+	router.ServeHTTP(recorder, request)
+	var device dto.DeviceResp
+	json.Unmarshal(recorder.Body.Bytes(), &device)
+
+	assert.EqualValues(t, http.StatusOK, recorder.Code)
+	assert.EqualValues(t, "DAI2", device.Model)
+	assert.EqualValues(t, "online", device.State)
+}
+
+func TestGetStatusReturnsConfig(t *testing.T) {
+	teardown := setupApiTest()
+	defer teardown()
+	request := httptest.NewRequest(http.MethodGet, "/api/v1/status", nil)
+
+	router.ServeHTTP(recorder, request)
+	var status dto.ConfigResp
+	json.Unmarshal(recorder.Body.Bytes(), &status)
+
+	assert.EqualValues(t, http.StatusOK, recorder.Code)
+	assert.EqualValues(t, "release", status.GinMode)
+}
+
+func TestTriggerScanTwiceReturnsConflict(t *testing.T) {
+	teardown := setupApiTest()
+	defer teardown()
+	request := httptest.NewRequest(http.MethodPost, "/api/v1/scan", nil)
+	router.ServeHTTP(recorder, request)
+
+	assert.EqualValues(t, http.StatusAccepted, recorder.Code)
+
+	recorder = httptest.NewRecorder()
+	request = httptest.NewRequest(http.MethodPost, "/api/v1/scan", nil)
+	router.ServeHTTP(recorder, request)
+
+	assert.EqualValues(t, http.StatusConflict, recorder.Code)
+}
+
+func TestOpenApiReturnsDocument(t *testing.T) {
+	teardown := setupApiTest()
+	defer teardown()
+	request := httptest.NewRequest(http.MethodGet, "/api/v1/openapi.yaml", nil)
+
+	router.ServeHTTP(recorder, request)
+	res := recorder.Result()
+	defer res.Body.Close()
+	data, _ := io.ReadAll(res.Body)
+
+	assert.EqualValues(t, http.StatusOK, res.StatusCode)
+	assert.True(t, strings.HasPrefix(string(data), "openapi: 3.0.3"))
+	assert.Contains(t, string(data), "/api/v1/devices/{name}:")
+}
+
+func TestGetConflictsReturnsLastAnalysis(t *testing.T) {
+	teardown := setupApiTest()
+	defer teardown()
+	repo.Store(domain.DeviceInfo{Name: "amp", IPv4: net.ParseIP("192.168.1.10"), LastSeen: time.Now()})
+	repo.Store(domain.DeviceInfo{Name: "stagebox", IPv4: net.ParseIP("192.168.1.10"), LastSeen: time.Now()})
+	conflictSvc.Analyze()
+	request := httptest.NewRequest(http.MethodGet, "/api/v1/conflicts", nil)
+
+	router.ServeHTTP(recorder, request)
+	var report dto.ConflictReportResp
+	err := json.Unmarshal(recorder.Body.Bytes(), &report)
+
+	assert.EqualValues(t, http.StatusOK, recorder.Code)
+	assert.Nil(t, err)
+	assert.EqualValues(t, 1, report.DuplicateIp)
+	assert.EqualValues(t, []string{"amp", "stagebox"}, report.Conflicts[0].Devices)
+}
+
+func TestGetFormatsReportsDevicesOffStandard(t *testing.T) {
+	teardown := setupApiTest()
+	defer teardown()
+	repo.Store(domain.DeviceInfo{Name: "stagebox", LastSeen: time.Now(), Settings: domain.DeviceSettings{SampleRate: 96000, Encoding: 24, LatencyNs: 1000000, ReadAt: time.Now()}})
+	request := httptest.NewRequest(http.MethodGet, "/api/v1/formats", nil)
+
+	router.ServeHTTP(recorder, request)
+	var report dto.FormatReportResp
+	err := json.Unmarshal(recorder.Body.Bytes(), &report)
+
+	assert.EqualValues(t, http.StatusOK, recorder.Code)
+	assert.Nil(t, err)
+	assert.EqualValues(t, "48000 Hz, 24 bit, 1ms latency", report.Standard)
+	assert.EqualValues(t, 1, report.DevicesChecked)
+	assert.EqualValues(t, 1, report.OffStandard)
+	assert.EqualValues(t, "stagebox runs 96000 Hz instead of 48000 Hz", report.Findings[0].Detail)
+}
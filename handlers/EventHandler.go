@@ -0,0 +1,38 @@
+// package handlers sets up the handlers for the Web UI
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/johannes-kuhfuss/alighieri/config"
+	"github.com/johannes-kuhfuss/alighieri/dto"
+	"github.com/johannes-kuhfuss/alighieri/repositories"
+)
+
+type EventHandler struct {
+	Cfg  *config.AppConfig
+	Repo *repositories.DefaultEventRepository
+}
+
+// NewEventHandler creates a new event handler and injects its dependencies
+func NewEventHandler(cfg *config.AppConfig, repo *repositories.DefaultEventRepository) EventHandler {
+	return EventHandler{
+		Cfg:  cfg,
+		Repo: repo,
+	}
+}
+
+// EventsPage is the handler for the event history page, optionally filtered by device and event type
+func (eh *EventHandler) EventsPage(c *gin.Context) {
+	device := c.Query("device")
+	eventType := c.Query("type")
+	c.HTML(http.StatusOK, "events.page.tmpl", gin.H{
+		"title":        "Events",
+		"events":       dto.GetEvents(eh.Repo, device, eventType),
+		"devices":      dto.GetEventDevices(eh.Repo),
+		"types":        dto.GetEventTypes(),
+		"selecteddev":  device,
+		"selectedtype": eventType,
+	})
+}
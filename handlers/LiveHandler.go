@@ -0,0 +1,74 @@
+// package handlers sets up the handlers for the Web UI
+package handlers
+
+import (
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/johannes-kuhfuss/alighieri/config"
+	"github.com/johannes-kuhfuss/alighieri/domain"
+	"github.com/johannes-kuhfuss/alighieri/dto"
+	"github.com/johannes-kuhfuss/alighieri/repositories"
+	"github.com/johannes-kuhfuss/alighieri/service"
+	"github.com/johannes-kuhfuss/services_utils/logger"
+)
+
+// interval of the keep-alive messages keeping proxies from closing idle streams
+const liveKeepAlive = 30 * time.Second
+
+type LiveHandler struct {
+	Cfg  *config.AppConfig
+	Repo *repositories.DefaultDeviceRepository
+	Svc  service.BroadcastService
+}
+
+// NewLiveHandler creates a new live update handler and injects its dependencies
+func NewLiveHandler(cfg *config.AppConfig, repo *repositories.DefaultDeviceRepository, svc service.BroadcastService) LiveHandler {
+	return LiveHandler{
+		Cfg:  cfg,
+		Repo: repo,
+		Svc:  svc,
+	}
+}
+
+// Stream is the handler streaming device and scan status changes as Server-Sent Events until the client disconnects
+func (lh *LiveHandler) Stream(c *gin.Context) {
+	notifications, unsubscribe := lh.Svc.Subscribe()
+	defer unsubscribe()
+	// the server's write time out would end the stream after a few seconds
+	if err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{}); err != nil {
+		logger.Warnf("Could not lift write time out for live stream: %v", err)
+	}
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case n, ok := <-notifications:
+			if !ok {
+				return false
+			}
+			c.SSEvent(string(n.Type), lh.payload(n))
+		case <-time.After(liveKeepAlive):
+			c.SSEvent("keep-alive", "")
+		case <-c.Request.Context().Done():
+			return false
+		}
+		return true
+	})
+}
+
+// payload converts a notification to the data sent to the client. Removed devices only carry their name and identity
+func (lh *LiveHandler) payload(n domain.Notification) any {
+	switch n.Type {
+	case domain.NotificationScanStatus:
+		return dto.GetConfig(lh.Cfg)
+	case domain.NotificationDeviceAdded, domain.NotificationDeviceChanged:
+		if device := dto.GetDeviceByKey(lh.Repo, n.Key); device != nil {
+			return device
+		}
+	}
+	return gin.H{"name": n.Device, "key": n.Key}
+}
@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/johannes-kuhfuss/alighieri/config"
+	"github.com/johannes-kuhfuss/alighieri/domain"
+	"github.com/johannes-kuhfuss/alighieri/repositories"
+	"github.com/stretchr/testify/assert"
+)
+
+type broadcastServiceMock struct {
+	notifications []domain.Notification
+}
+
+func (m *broadcastServiceMock) Publish(domain.Notification) {}
+
+func (m *broadcastServiceMock) DeviceChanged(string, string, bool, bool) {}
+
+// Subscribe returns all prepared notifications and closes the channel, which ends the stream
+func (m *broadcastServiceMock) Subscribe() (<-chan domain.Notification, func()) {
+	ch := make(chan domain.Notification, len(m.notifications))
+	for _, n := range m.notifications {
+		ch <- n
+	}
+	close(ch)
+	return ch, func() {}
+}
+
+// closeNotifyingRecorder adds the close notification gin needs for streaming to the response recorder
+type closeNotifyingRecorder struct {
+	*httptest.ResponseRecorder
+}
+
+func (r closeNotifyingRecorder) CloseNotify() <-chan bool {
+	return make(chan bool)
+}
+
+func TestStreamSendsNotificationsAsEvents(t *testing.T) {
+	config.InitConfig("", &cfg)
+	repo = repositories.NewDeviceRepository(&cfg)
+	repo.Store(domain.DeviceInfo{Name: "stagebox", Model: "DAI1"})
+	repo.Store(domain.DeviceInfo{Name: "stagebox", Id: "001dc1fffe000001", Model: "DAI2"})
+	broadcastMock := broadcastServiceMock{notifications: []domain.Notification{
+		{Type: domain.NotificationDeviceAdded, Device: "stagebox", Key: "001dc1fffe000001"},
+		{Type: domain.NotificationDeviceRemoved, Device: "mixer", Key: "001dc1fffe000002"},
+		{Type: domain.NotificationScanStatus},
+	}}
+	lh := NewLiveHandler(&cfg, &repo, &broadcastMock)
+	router = gin.Default()
+	router.GET("/live", lh.Stream)
+	recorder = httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodGet, "/live", nil)
+
+	router.ServeHTTP(closeNotifyingRecorder{recorder}, request)
+	body := recorder.Body.String()
+
+	assert.EqualValues(t, http.StatusOK, recorder.Code)
+	assert.Contains(t, recorder.Header().Get("Content-Type"), "text/event-stream")
+	assert.Contains(t, body, "event:device-added\ndata:{\"name\":\"stagebox\"")
+	assert.Contains(t, body, "\"key\":\"001dc1fffe000001\"")
+	assert.Contains(t, body, "\"model\":\"DAI2\"")
+	assert.NotContains(t, body, "\"model\":\"DAI1\"")
+	assert.Contains(t, body, "event:device-removed\ndata:{\"key\":\"001dc1fffe000002\",\"name\":\"mixer\"}")
+	assert.Contains(t, body, "event:scan-status\ndata:{\"serverHost\"")
+}
+
+func TestDeviceListPageContainsLiveScript(t *testing.T) {
+	teardown := setupUiTest()
+	defer teardown()
+	router.GET("/devicelist", uh.DeviceListPage)
+	request := httptest.NewRequest(http.MethodGet, "/devicelist", nil)
+
+	router.ServeHTTP(recorder, request)
+
+	assert.EqualValues(t, http.StatusOK, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), "new EventSource(\"/live\")")
+}
@@ -0,0 +1,51 @@
+// package handlers sets up the handlers for the Web UI
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/johannes-kuhfuss/alighieri/config"
+	"github.com/johannes-kuhfuss/alighieri/dto"
+	"github.com/johannes-kuhfuss/alighieri/service"
+	"github.com/johannes-kuhfuss/services_utils/api_error"
+)
+
+type NamingHandler struct {
+	Cfg *config.AppConfig
+	Svc service.NamingService
+}
+
+// NewNamingHandler creates a new naming handler and injects its dependencies
+func NewNamingHandler(cfg *config.AppConfig, svc service.NamingService) NamingHandler {
+	return NamingHandler{
+		Cfg: cfg,
+		Svc: svc,
+	}
+}
+
+// NamingPage is the handler for the page listing devices violating the naming policy and the renames suggested to fix them
+func (nh *NamingHandler) NamingPage(c *gin.Context) {
+	c.HTML(http.StatusOK, "naming.page.tmpl", gin.H{
+		"title":          "Naming",
+		"report":         dto.GetNamingReport(nh.Svc.Report(), nh.Cfg),
+		"changesenabled": nh.Cfg.Server.AdminPassword != "",
+	})
+}
+
+// GetReport is the handler returning the naming policy compliance report
+func (nh *NamingHandler) GetReport(c *gin.Context) {
+	c.JSON(http.StatusOK, dto.GetNamingReport(nh.Svc.Report(), nh.Cfg))
+}
+
+// ApplyRenames is the handler renaming all devices a new name is suggested for. With "dryRun=true", the renames are only listed
+func (nh *NamingHandler) ApplyRenames(c *gin.Context) {
+	dryRun, err := strconv.ParseBool(c.DefaultQuery("dryRun", "false"))
+	if err != nil {
+		apiErr := api_error.NewBadRequestError("invalid value for dryRun")
+		c.JSON(apiErr.StatusCode(), apiErr)
+		return
+	}
+	c.JSON(http.StatusOK, dto.GetNamingRenames(nh.Svc.ApplyRenames(dryRun)))
+}
@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/johannes-kuhfuss/alighieri/config"
+	"github.com/johannes-kuhfuss/alighieri/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+type namingServiceMock struct {
+	lastDryRun bool
+}
+
+func (m *namingServiceMock) Report() domain.NamingReport {
+	return domain.NamingReport{
+		Checked:  2,
+		Findings: []domain.NamingFinding{{Device: "stagebox 1", Issue: domain.NamingPattern, Detail: "stagebox 1 does not match ^[A-Z]{2}$"}},
+		Renames:  []domain.NamingRename{{Device: "stagebox 1", Key: "stagebox 1", NewName: "STAGEBOX-01"}},
+	}
+}
+
+func (m *namingServiceMock) ApplyRenames(dryRun bool) []domain.NamingRename {
+	m.lastDryRun = dryRun
+	return []domain.NamingRename{{Device: "stagebox 1", Key: "stagebox 1", NewName: "STAGEBOX-01", Applied: !dryRun}}
+}
+
+var (
+	nh         NamingHandler
+	namingMock namingServiceMock
+)
+
+func setupNamingTest() func() {
+	config.InitConfig("", &cfg)
+	namingMock = namingServiceMock{}
+	nh = NewNamingHandler(&cfg, &namingMock)
+	router = gin.Default()
+	router.LoadHTMLGlob("../templates/*.tmpl")
+	router.GET("/naming", nh.NamingPage)
+	router.GET("/api/v1/naming", nh.GetReport)
+	router.POST("/api/v1/naming/apply", nh.ApplyRenames)
+	recorder = httptest.NewRecorder()
+	return func() {
+		router = nil
+	}
+}
+
+func TestNamingPageListsFindingsAndRenames(t *testing.T) {
+	teardown := setupNamingTest()
+	defer teardown()
+	request := httptest.NewRequest(http.MethodGet, "/naming", nil)
+
+	router.ServeHTTP(recorder, request)
+
+	assert.EqualValues(t, http.StatusOK, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), "<title>Naming</title>")
+	assert.Contains(t, recorder.Body.String(), "<td>stagebox 1 does not match ^[A-Z]{2}$</td>")
+	assert.Contains(t, recorder.Body.String(), "<td>STAGEBOX-01</td>")
+	assert.Contains(t, recorder.Body.String(), "Changes are disabled")
+}
+
+func TestGetNamingReportReturnsPolicy(t *testing.T) {
+	teardown := setupNamingTest()
+	defer teardown()
+	request := httptest.NewRequest(http.MethodGet, "/api/v1/naming", nil)
+
+	router.ServeHTTP(recorder, request)
+
+	assert.EqualValues(t, http.StatusOK, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), `"pattern":"^[A-Z0-9]+-[A-Z0-9]+-[0-9]{2}$"`)
+	assert.Contains(t, recorder.Body.String(), `"patternViolations":1`)
+}
+
+func TestApplyRenamesDryRunPassesDryRun(t *testing.T) {
+	teardown := setupNamingTest()
+	defer teardown()
+	request := httptest.NewRequest(http.MethodPost, "/api/v1/naming/apply?dryRun=true", nil)
+
+	router.ServeHTTP(recorder, request)
+
+	assert.EqualValues(t, http.StatusOK, recorder.Code)
+	assert.True(t, namingMock.lastDryRun)
+	assert.Contains(t, recorder.Body.String(), `"applied":false`)
+}
+
+func TestApplyRenamesInvalidDryRunReturnsBadRequest(t *testing.T) {
+	teardown := setupNamingTest()
+	defer teardown()
+	request := httptest.NewRequest(http.MethodPost, "/api/v1/naming/apply?dryRun=maybe", nil)
+
+	router.ServeHTTP(recorder, request)
+
+	assert.EqualValues(t, http.StatusBadRequest, recorder.Code)
+}
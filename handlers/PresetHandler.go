@@ -0,0 +1,105 @@
+// package handlers sets up the handlers for the Web UI
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/johannes-kuhfuss/alighieri/config"
+	"github.com/johannes-kuhfuss/alighieri/dto"
+	"github.com/johannes-kuhfuss/alighieri/repositories"
+	"github.com/johannes-kuhfuss/alighieri/service"
+	"github.com/johannes-kuhfuss/services_utils/api_error"
+)
+
+type PresetHandler struct {
+	Cfg  *config.AppConfig
+	Repo *repositories.DefaultPresetRepository
+	Svc  service.PresetService
+}
+
+// NewPresetHandler creates a new preset handler and injects its dependencies
+func NewPresetHandler(cfg *config.AppConfig, repo *repositories.DefaultPresetRepository, svc service.PresetService) PresetHandler {
+	return PresetHandler{
+		Cfg:  cfg,
+		Repo: repo,
+		Svc:  svc,
+	}
+}
+
+// PresetsPage is the handler for the page listing all routing presets
+func (ph *PresetHandler) PresetsPage(c *gin.Context) {
+	presets := dto.GetPresets(ph.Repo.GetAll())
+	c.HTML(http.StatusOK, "presets.page.tmpl", gin.H{
+		"title":          "Presets",
+		"presets":        presets,
+		"changesenabled": ph.Cfg.Server.AdminPassword != "",
+	})
+}
+
+// GetPresets is the handler returning all presets without their subscriptions
+func (ph *PresetHandler) GetPresets(c *gin.Context) {
+	presets := dto.GetPresets(ph.Repo.GetAll())
+	if presets == nil {
+		presets = []dto.PresetResp{}
+	}
+	c.JSON(http.StatusOK, presets)
+}
+
+// GetPreset is the handler returning a single preset including its subscriptions
+func (ph *PresetHandler) GetPreset(c *gin.Context) {
+	name := c.Param("name")
+	preset := ph.Repo.GetByName(name)
+	if preset == nil {
+		apiErr := api_error.NewNotFoundError(fmt.Sprintf("preset %v does not exist", name))
+		c.JSON(apiErr.StatusCode(), apiErr)
+		return
+	}
+	c.JSON(http.StatusOK, dto.GetPreset(*preset))
+}
+
+// DiffPreset is the handler returning the changes needed to recall a preset
+func (ph *PresetHandler) DiffPreset(c *gin.Context) {
+	changes, apiErr := ph.Svc.Diff(c.Param("name"))
+	if apiErr != nil {
+		c.JSON(apiErr.StatusCode(), apiErr)
+		return
+	}
+	c.JSON(http.StatusOK, dto.GetPresetChanges(changes))
+}
+
+// SavePreset is the handler saving the current routing as a preset
+func (ph *PresetHandler) SavePreset(c *gin.Context) {
+	var req dto.PresetReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apiErr := api_error.NewBadRequestError("invalid preset request")
+		c.JSON(apiErr.StatusCode(), apiErr)
+		return
+	}
+	preset, apiErr := ph.Svc.Save(req.Name)
+	if apiErr != nil {
+		c.JSON(apiErr.StatusCode(), apiErr)
+		return
+	}
+	c.JSON(http.StatusCreated, dto.GetPreset(*preset))
+}
+
+// RecallPreset is the handler recalling a preset
+func (ph *PresetHandler) RecallPreset(c *gin.Context) {
+	result, apiErr := ph.Svc.Recall(c.Param("name"))
+	if apiErr != nil {
+		c.JSON(apiErr.StatusCode(), apiErr)
+		return
+	}
+	c.JSON(http.StatusOK, dto.GetRecallResult(*result))
+}
+
+// DeletePreset is the handler deleting a preset
+func (ph *PresetHandler) DeletePreset(c *gin.Context) {
+	if apiErr := ph.Svc.Delete(c.Param("name")); apiErr != nil {
+		c.JSON(apiErr.StatusCode(), apiErr)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/johannes-kuhfuss/alighieri/config"
+	"github.com/johannes-kuhfuss/alighieri/domain"
+	"github.com/johannes-kuhfuss/alighieri/repositories"
+	"github.com/johannes-kuhfuss/services_utils/api_error"
+	"github.com/stretchr/testify/assert"
+)
+
+type presetServiceMock struct {
+	err api_error.ApiErr
+}
+
+func (m presetServiceMock) Save(name string) (*domain.Preset, api_error.ApiErr) {
+	return &domain.Preset{Name: name}, m.err
+}
+
+func (m presetServiceMock) Diff(name string) ([]domain.PresetChange, api_error.ApiErr) {
+	return []domain.PresetChange{{RxDevice: "stagebox", RxChannel: 1, PresetTxDevice: "mixer", PresetTxChannel: "Out 1"}}, m.err
+}
+
+func (m presetServiceMock) Recall(name string) (*domain.RecallResult, api_error.ApiErr) {
+	return &domain.RecallResult{}, m.err
+}
+
+func (m presetServiceMock) Delete(name string) api_error.ApiErr {
+	return m.err
+}
+
+var (
+	ph         PresetHandler
+	presetRepo repositories.DefaultPresetRepository
+)
+
+func setupPresetTest(t *testing.T, svcErr api_error.ApiErr) func() {
+	config.InitConfig("", &cfg)
+	cfg.Routing.PresetFile = filepath.Join(t.TempDir(), "presets.json")
+	presetRepo = repositories.NewPresetRepository(&cfg)
+	ph = NewPresetHandler(&cfg, &presetRepo, presetServiceMock{err: svcErr})
+	router = gin.Default()
+	router.LoadHTMLGlob("../templates/*.tmpl")
+	router.GET("/presets", ph.PresetsPage)
+	router.GET("/api/v1/presets", ph.GetPresets)
+	router.GET("/api/v1/presets/:name", ph.GetPreset)
+	router.GET("/api/v1/presets/:name/diff", ph.DiffPreset)
+	router.POST("/api/v1/presets", ph.SavePreset)
+	router.DELETE("/api/v1/presets/:name", ph.DeletePreset)
+	recorder = httptest.NewRecorder()
+	return func() {
+		router = nil
+	}
+}
+
+func TestPresetsPageReturnsPresets(t *testing.T) {
+	teardown := setupPresetTest(t, nil)
+	defer teardown()
+	presetRepo.Store(domain.Preset{Name: "evening"})
+	request := httptest.NewRequest(http.MethodGet, "/presets", nil)
+
+	router.ServeHTTP(recorder, request)
+
+	assert.EqualValues(t, http.StatusOK, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), "<title>Presets</title>")
+	assert.Contains(t, recorder.Body.String(), "<td>evening</td>")
+}
+
+func TestGetPresetsEmptyRepoReturnsEmptyList(t *testing.T) {
+	teardown := setupPresetTest(t, nil)
+	defer teardown()
+	request := httptest.NewRequest(http.MethodGet, "/api/v1/presets", nil)
+
+	router.ServeHTTP(recorder, request)
+
+	assert.EqualValues(t, http.StatusOK, recorder.Code)
+	assert.EqualValues(t, "[]", recorder.Body.String())
+}
+
+func TestGetPresetUnknownReturnsNotFound(t *testing.T) {
+	teardown := setupPresetTest(t, nil)
+	defer teardown()
+	request := httptest.NewRequest(http.MethodGet, "/api/v1/presets/unknown", nil)
+
+	router.ServeHTTP(recorder, request)
+
+	assert.EqualValues(t, http.StatusNotFound, recorder.Code)
+}
+
+func TestDiffPresetReturnsChanges(t *testing.T) {
+	teardown := setupPresetTest(t, nil)
+	defer teardown()
+	request := httptest.NewRequest(http.MethodGet, "/api/v1/presets/evening/diff", nil)
+
+	router.ServeHTTP(recorder, request)
+
+	assert.EqualValues(t, http.StatusOK, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), "\"preset\":\"Out 1@mixer\"")
+}
+
+func TestSavePresetInvalidBodyReturnsBadRequest(t *testing.T) {
+	teardown := setupPresetTest(t, nil)
+	defer teardown()
+	request := httptest.NewRequest(http.MethodPost, "/api/v1/presets", strings.NewReader("{}"))
+
+	router.ServeHTTP(recorder, request)
+
+	assert.EqualValues(t, http.StatusBadRequest, recorder.Code)
+}
+
+func TestSavePresetReturnsCreated(t *testing.T) {
+	teardown := setupPresetTest(t, nil)
+	defer teardown()
+	request := httptest.NewRequest(http.MethodPost, "/api/v1/presets", strings.NewReader("{\"name\": \"evening\"}"))
+
+	router.ServeHTTP(recorder, request)
+
+	assert.EqualValues(t, http.StatusCreated, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), "\"name\":\"evening\"")
+}
+
+func TestDeletePresetServiceErrorReturnsError(t *testing.T) {
+	teardown := setupPresetTest(t, api_error.NewNotFoundError("preset with name evening does not exist"))
+	defer teardown()
+	request := httptest.NewRequest(http.MethodDelete, "/api/v1/presets/evening", nil)
+
+	router.ServeHTTP(recorder, request)
+
+	assert.EqualValues(t, http.StatusNotFound, recorder.Code)
+}
@@ -0,0 +1,37 @@
+// package handlers sets up the handlers for the Web UI
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/johannes-kuhfuss/alighieri/config"
+	"github.com/johannes-kuhfuss/alighieri/dto"
+	"github.com/johannes-kuhfuss/alighieri/service"
+)
+
+type RedundancyHandler struct {
+	Cfg *config.AppConfig
+	Svc service.RedundancyService
+}
+
+// NewRedundancyHandler creates a new redundancy handler and injects its dependencies
+func NewRedundancyHandler(cfg *config.AppConfig, svc service.RedundancyService) RedundancyHandler {
+	return RedundancyHandler{
+		Cfg: cfg,
+		Svc: svc,
+	}
+}
+
+// RedundancyPage is the handler for the page listing devices with issues on the primary and secondary network
+func (rh *RedundancyHandler) RedundancyPage(c *gin.Context) {
+	c.HTML(http.StatusOK, "redundancy.page.tmpl", gin.H{
+		"title":  "Redundancy",
+		"report": dto.GetRedundancyReport(rh.Svc.Report()),
+	})
+}
+
+// GetReport is the handler returning the redundancy report
+func (rh *RedundancyHandler) GetReport(c *gin.Context) {
+	c.JSON(http.StatusOK, dto.GetRedundancyReport(rh.Svc.Report()))
+}
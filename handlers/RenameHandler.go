@@ -0,0 +1,63 @@
+// package handlers sets up the handlers for the Web UI
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/johannes-kuhfuss/alighieri/config"
+	"github.com/johannes-kuhfuss/alighieri/domain"
+	"github.com/johannes-kuhfuss/alighieri/dto"
+	"github.com/johannes-kuhfuss/alighieri/service"
+	"github.com/johannes-kuhfuss/services_utils/api_error"
+)
+
+type RenameHandler struct {
+	Cfg *config.AppConfig
+	Svc service.RenameService
+}
+
+// NewRenameHandler creates a new rename handler and injects its dependencies
+func NewRenameHandler(cfg *config.AppConfig, svc service.RenameService) RenameHandler {
+	return RenameHandler{
+		Cfg: cfg,
+		Svc: svc,
+	}
+}
+
+// RenameDevice is the handler changing the name of a device. The device is listed under its new name after the next scan
+func (rh *RenameHandler) RenameDevice(c *gin.Context) {
+	var req dto.RenameReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apiErr := api_error.NewBadRequestError("invalid rename request")
+		c.JSON(apiErr.StatusCode(), apiErr)
+		return
+	}
+	if apiErr := rh.Svc.RenameDevice(c.Param("name"), req.Name); apiErr != nil {
+		c.JSON(apiErr.StatusCode(), apiErr)
+		return
+	}
+	c.Status(http.StatusAccepted)
+}
+
+// RenameChannel is the handler changing the label of a transmit or receive channel. The new label shows up after the next scan
+func (rh *RenameHandler) RenameChannel(c *gin.Context) {
+	channel, err := strconv.Atoi(c.Param("channel"))
+	if err != nil {
+		apiErr := api_error.NewBadRequestError("invalid channel number")
+		c.JSON(apiErr.StatusCode(), apiErr)
+		return
+	}
+	var req dto.RenameReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apiErr := api_error.NewBadRequestError("invalid rename request")
+		c.JSON(apiErr.StatusCode(), apiErr)
+		return
+	}
+	if apiErr := rh.Svc.RenameChannel(c.Param("name"), domain.ChannelDirection(c.Param("direction")), channel, req.Name); apiErr != nil {
+		c.JSON(apiErr.StatusCode(), apiErr)
+		return
+	}
+	c.Status(http.StatusAccepted)
+}
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/johannes-kuhfuss/alighieri/config"
+	"github.com/johannes-kuhfuss/alighieri/domain"
+	"github.com/johannes-kuhfuss/services_utils/api_error"
+	"github.com/stretchr/testify/assert"
+)
+
+type renameServiceMock struct {
+	err     api_error.ApiErr
+	lastReq string
+}
+
+func (m *renameServiceMock) RenameDevice(deviceName string, newName string) api_error.ApiErr {
+	m.lastReq = deviceName + " -> " + newName
+	return m.err
+}
+
+func (m *renameServiceMock) RenameChannel(deviceName string, direction domain.ChannelDirection, channel int, newName string) api_error.ApiErr {
+	m.lastReq = fmt.Sprintf("%v %v %v -> %v", deviceName, direction, channel, newName)
+	return m.err
+}
+
+var (
+	rnh        RenameHandler
+	renameMock renameServiceMock
+)
+
+func setupRenameTest() func() {
+	config.InitConfig("", &cfg)
+	renameMock = renameServiceMock{}
+	rnh = NewRenameHandler(&cfg, &renameMock)
+	router = gin.Default()
+	router.PUT("/api/v1/devices/:name/name", rnh.RenameDevice)
+	router.PUT("/api/v1/devices/:name/channels/:direction/:channel/name", rnh.RenameChannel)
+	recorder = httptest.NewRecorder()
+	return func() {
+		router = nil
+	}
+}
+
+func TestRenameDeviceInvalidBodyReturnsBadRequest(t *testing.T) {
+	teardown := setupRenameTest()
+	defer teardown()
+	request := httptest.NewRequest(http.MethodPut, "/api/v1/devices/stagebox/name", strings.NewReader(`{}`))
+
+	router.ServeHTTP(recorder, request)
+
+	assert.EqualValues(t, http.StatusBadRequest, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), "invalid rename request")
+}
+
+func TestRenameDeviceValidBodyReturnsAccepted(t *testing.T) {
+	teardown := setupRenameTest()
+	defer teardown()
+	request := httptest.NewRequest(http.MethodPut, "/api/v1/devices/stagebox/name", strings.NewReader(`{"name": "Stage-Left"}`))
+
+	router.ServeHTTP(recorder, request)
+
+	assert.EqualValues(t, http.StatusAccepted, recorder.Code)
+	assert.EqualValues(t, "stagebox -> Stage-Left", renameMock.lastReq)
+}
+
+func TestRenameChannelInvalidNumberReturnsBadRequest(t *testing.T) {
+	teardown := setupRenameTest()
+	defer teardown()
+	request := httptest.NewRequest(http.MethodPut, "/api/v1/devices/stagebox/channels/tx/one/name", strings.NewReader(`{"name": "Vocal"}`))
+
+	router.ServeHTTP(recorder, request)
+
+	assert.EqualValues(t, http.StatusBadRequest, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), "invalid channel number")
+}
+
+func TestRenameChannelConflictReturnsConflict(t *testing.T) {
+	teardown := setupRenameTest()
+	defer teardown()
+	renameMock.err = api_error.NewProcessingConflictError("name Vocal is already used by tx channel 1 of device stagebox")
+	request := httptest.NewRequest(http.MethodPut, "/api/v1/devices/stagebox/channels/tx/2/name", strings.NewReader(`{"name": "Vocal"}`))
+
+	router.ServeHTTP(recorder, request)
+
+	assert.EqualValues(t, http.StatusConflict, recorder.Code)
+	assert.EqualValues(t, "stagebox tx 2 -> Vocal", renameMock.lastReq)
+}
@@ -0,0 +1,20 @@
+// package handlers sets up the handlers for the Web UI
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/johannes-kuhfuss/services_utils/api_error"
+)
+
+// RequireJson is the middleware rejecting requests not sent as JSON. Browsers only send JSON cross-site after a CORS preflight,
+// which this server never grants, so other sites cannot use the admin credentials cached by the browser to make changes
+func RequireJson(c *gin.Context) {
+	if c.ContentType() != gin.MIMEJSON {
+		apiErr := api_error.NewError("requests changing devices must be sent with content type application/json", http.StatusUnsupportedMediaType, nil)
+		c.AbortWithStatusJSON(apiErr.StatusCode(), apiErr)
+		return
+	}
+	c.Next()
+}
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupRequireJsonTest() func() {
+	router = gin.Default()
+	router.POST("/api/v1/naming/apply", RequireJson, func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	recorder = httptest.NewRecorder()
+	return func() {
+		router = nil
+	}
+}
+
+func TestRequireJsonWithoutContentTypeReturnsUnsupportedMediaType(t *testing.T) {
+	teardown := setupRequireJsonTest()
+	defer teardown()
+	request := httptest.NewRequest(http.MethodPost, "/api/v1/naming/apply", nil)
+
+	router.ServeHTTP(recorder, request)
+
+	assert.EqualValues(t, http.StatusUnsupportedMediaType, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), "content type application/json")
+}
+
+func TestRequireJsonWithFormContentTypeReturnsUnsupportedMediaType(t *testing.T) {
+	teardown := setupRequireJsonTest()
+	defer teardown()
+	request := httptest.NewRequest(http.MethodPost, "/api/v1/naming/apply", nil)
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	router.ServeHTTP(recorder, request)
+
+	assert.EqualValues(t, http.StatusUnsupportedMediaType, recorder.Code)
+}
+
+func TestRequireJsonWithJsonContentTypePassesRequest(t *testing.T) {
+	teardown := setupRequireJsonTest()
+	defer teardown()
+	request := httptest.NewRequest(http.MethodPost, "/api/v1/naming/apply", nil)
+	request.Header.Set("Content-Type", "application/json; charset=utf-8")
+
+	router.ServeHTTP(recorder, request)
+
+	assert.EqualValues(t, http.StatusOK, recorder.Code)
+}
@@ -0,0 +1,56 @@
+// package handlers sets up the handlers for the Web UI
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/johannes-kuhfuss/alighieri/config"
+	"github.com/johannes-kuhfuss/alighieri/dto"
+	"github.com/johannes-kuhfuss/alighieri/service"
+	"github.com/johannes-kuhfuss/services_utils/api_error"
+)
+
+type RoutingHandler struct {
+	Cfg *config.AppConfig
+	Svc service.RoutingService
+}
+
+// NewRoutingHandler creates a new routing handler and injects its dependencies
+func NewRoutingHandler(cfg *config.AppConfig, svc service.RoutingService) RoutingHandler {
+	return RoutingHandler{
+		Cfg: cfg,
+		Svc: svc,
+	}
+}
+
+// Subscribe is the handler subscribing a receive channel to a transmit channel
+func (rh *RoutingHandler) Subscribe(c *gin.Context) {
+	var req dto.SubscriptionReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apiErr := api_error.NewBadRequestError("invalid subscription request")
+		c.JSON(apiErr.StatusCode(), apiErr)
+		return
+	}
+	if apiErr := rh.Svc.Subscribe(req.RxDevice, req.RxChannel, req.TxDevice, req.TxChannel); apiErr != nil {
+		c.JSON(apiErr.StatusCode(), apiErr)
+		return
+	}
+	c.Status(http.StatusCreated)
+}
+
+// Unsubscribe is the handler clearing the subscription of a receive channel
+func (rh *RoutingHandler) Unsubscribe(c *gin.Context) {
+	channel, err := strconv.Atoi(c.Param("channel"))
+	if err != nil {
+		apiErr := api_error.NewBadRequestError("invalid channel number")
+		c.JSON(apiErr.StatusCode(), apiErr)
+		return
+	}
+	if apiErr := rh.Svc.Unsubscribe(c.Param("device"), channel); apiErr != nil {
+		c.JSON(apiErr.StatusCode(), apiErr)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
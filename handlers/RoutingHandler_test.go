@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/johannes-kuhfuss/alighieri/config"
+	"github.com/johannes-kuhfuss/services_utils/api_error"
+	"github.com/stretchr/testify/assert"
+)
+
+type routingServiceMock struct {
+	err     api_error.ApiErr
+	lastReq string
+}
+
+func (m *routingServiceMock) Subscribe(rxDevice string, rxChannel int, txDevice string, txChannel string) api_error.ApiErr {
+	m.lastReq = rxDevice + "/" + txChannel + "@" + txDevice
+	return m.err
+}
+
+func (m *routingServiceMock) Unsubscribe(rxDevice string, rxChannel int) api_error.ApiErr {
+	m.lastReq = rxDevice
+	return m.err
+}
+
+var (
+	rh          RoutingHandler
+	routingMock routingServiceMock
+)
+
+func setupRoutingTest() func() {
+	config.InitConfig("", &cfg)
+	routingMock = routingServiceMock{}
+	rh = NewRoutingHandler(&cfg, &routingMock)
+	router = gin.Default()
+	router.POST("/subscriptions", rh.Subscribe)
+	router.DELETE("/subscriptions/:device/:channel", rh.Unsubscribe)
+	recorder = httptest.NewRecorder()
+	return func() {
+		router = nil
+	}
+}
+
+func TestSubscribeInvalidBodyReturnsBadRequest(t *testing.T) {
+	teardown := setupRoutingTest()
+	defer teardown()
+	request := httptest.NewRequest(http.MethodPost, "/subscriptions", strings.NewReader("{\"rxDevice\": \"a\"}"))
+
+	router.ServeHTTP(recorder, request)
+
+	assert.EqualValues(t, http.StatusBadRequest, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), "invalid subscription request")
+}
+
+func TestSubscribeValidBodyReturnsCreated(t *testing.T) {
+	teardown := setupRoutingTest()
+	defer teardown()
+	body := "{\"rxDevice\": \"stagebox\", \"rxChannel\": 1, \"txDevice\": \"mixer\", \"txChannel\": \"Out 1\"}"
+	request := httptest.NewRequest(http.MethodPost, "/subscriptions", strings.NewReader(body))
+
+	router.ServeHTTP(recorder, request)
+
+	assert.EqualValues(t, http.StatusCreated, recorder.Code)
+	assert.EqualValues(t, "stagebox/Out 1@mixer", routingMock.lastReq)
+}
+
+func TestSubscribeServiceErrorReturnsError(t *testing.T) {
+	teardown := setupRoutingTest()
+	defer teardown()
+	routingMock.err = api_error.NewNotFoundError("receiving device stagebox does not exist")
+	body := "{\"rxDevice\": \"stagebox\", \"rxChannel\": 1, \"txDevice\": \"mixer\", \"txChannel\": \"Out 1\"}"
+	request := httptest.NewRequest(http.MethodPost, "/subscriptions", strings.NewReader(body))
+
+	router.ServeHTTP(recorder, request)
+
+	assert.EqualValues(t, http.StatusNotFound, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), "receiving device stagebox does not exist")
+}
+
+func TestUnsubscribeInvalidChannelReturnsBadRequest(t *testing.T) {
+	teardown := setupRoutingTest()
+	defer teardown()
+	request := httptest.NewRequest(http.MethodDelete, "/subscriptions/stagebox/x", nil)
+
+	router.ServeHTTP(recorder, request)
+
+	assert.EqualValues(t, http.StatusBadRequest, recorder.Code)
+}
+
+func TestUnsubscribeValidChannelReturnsNoContent(t *testing.T) {
+	teardown := setupRoutingTest()
+	defer teardown()
+	request := httptest.NewRequest(http.MethodDelete, "/subscriptions/stagebox/1", nil)
+
+	router.ServeHTTP(recorder, request)
+
+	assert.EqualValues(t, http.StatusNoContent, recorder.Code)
+	assert.EqualValues(t, "stagebox", routingMock.lastReq)
+}
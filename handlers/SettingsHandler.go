@@ -0,0 +1,85 @@
+// package handlers sets up the handlers for the Web UI
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/johannes-kuhfuss/alighieri/config"
+	"github.com/johannes-kuhfuss/alighieri/dto"
+	"github.com/johannes-kuhfuss/alighieri/repositories"
+	"github.com/johannes-kuhfuss/alighieri/service"
+	"github.com/johannes-kuhfuss/services_utils/api_error"
+)
+
+type SettingsHandler struct {
+	Cfg  *config.AppConfig
+	Repo *repositories.DefaultDeviceRepository
+	Svc  service.SettingsService
+}
+
+// NewSettingsHandler creates a new settings handler and injects its dependencies
+func NewSettingsHandler(cfg *config.AppConfig, repo *repositories.DefaultDeviceRepository, svc service.SettingsService) SettingsHandler {
+	return SettingsHandler{
+		Cfg:  cfg,
+		Repo: repo,
+		Svc:  svc,
+	}
+}
+
+// SettingsPage is the handler for the page showing and changing the settings of a device. It shows the settings read during the last scan
+func (sh *SettingsHandler) SettingsPage(c *gin.Context) {
+	device := sh.Repo.Find(c.Param("name"))
+	if device == nil {
+		apiErr := api_error.NewNotFoundError(fmt.Sprintf("device %v does not exist or its name is not unique", c.Param("name")))
+		c.JSON(apiErr.StatusCode(), apiErr)
+		return
+	}
+	c.HTML(http.StatusOK, "settings.page.tmpl", gin.H{
+		"title":          "Settings",
+		"settings":       dto.GetSettingsForm(*device),
+		"changesenabled": sh.Cfg.Server.AdminPassword != "",
+	})
+}
+
+// GetSettings is the handler reading the current settings from a device
+func (sh *SettingsHandler) GetSettings(c *gin.Context) {
+	current, apiErr := sh.Svc.Read(c.Param("name"))
+	if apiErr != nil {
+		c.JSON(apiErr.StatusCode(), apiErr)
+		return
+	}
+	c.JSON(http.StatusOK, dto.GetSettings(current))
+}
+
+// UpdateSettings is the handler changing the settings of a device
+func (sh *SettingsHandler) UpdateSettings(c *gin.Context) {
+	var req dto.SettingsReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apiErr := api_error.NewBadRequestError("invalid settings request")
+		c.JSON(apiErr.StatusCode(), apiErr)
+		return
+	}
+	target, err := req.ToSettings()
+	if err != nil {
+		apiErr := api_error.NewBadRequestError(err.Error())
+		c.JSON(apiErr.StatusCode(), apiErr)
+		return
+	}
+	current, apiErr := sh.Svc.Update(c.Param("name"), target)
+	if apiErr != nil {
+		c.JSON(apiErr.StatusCode(), apiErr)
+		return
+	}
+	c.JSON(http.StatusOK, dto.GetSettings(current))
+}
+
+// Identify is the handler making a device flash its LEDs
+func (sh *SettingsHandler) Identify(c *gin.Context) {
+	if apiErr := sh.Svc.Identify(c.Param("name")); apiErr != nil {
+		c.JSON(apiErr.StatusCode(), apiErr)
+		return
+	}
+	c.Status(http.StatusAccepted)
+}
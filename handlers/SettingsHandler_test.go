@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/johannes-kuhfuss/alighieri/config"
+	"github.com/johannes-kuhfuss/alighieri/domain"
+	"github.com/johannes-kuhfuss/alighieri/repositories"
+	"github.com/johannes-kuhfuss/services_utils/api_error"
+	"github.com/stretchr/testify/assert"
+)
+
+type settingsServiceMock struct {
+	err        api_error.ApiErr
+	lastDevice string
+	lastTarget domain.DeviceSettings
+}
+
+func (m *settingsServiceMock) Read(deviceName string) (domain.DeviceSettings, api_error.ApiErr) {
+	m.lastDevice = deviceName
+	return domain.DeviceSettings{SampleRate: 48000, Encoding: 24, LatencyNs: 1000000, ReadAt: time.Now()}, m.err
+}
+
+func (m *settingsServiceMock) Identify(deviceName string) api_error.ApiErr {
+	m.lastDevice = deviceName
+	return m.err
+}
+
+func (m *settingsServiceMock) Update(deviceName string, target domain.DeviceSettings) (domain.DeviceSettings, api_error.ApiErr) {
+	m.lastDevice = deviceName
+	m.lastTarget = target
+	target.ReadAt = time.Now()
+	return target, m.err
+}
+
+var (
+	sh           SettingsHandler
+	settingsMock settingsServiceMock
+)
+
+func setupSettingsTest() func() {
+	config.InitConfig("", &cfg)
+	repo = repositories.NewDeviceRepository(&cfg)
+	repo.Store(domain.DeviceInfo{
+		Name:     "stagebox",
+		IPv4:     net.ParseIP("192.168.1.10"),
+		Settings: domain.DeviceSettings{SampleRate: 96000, Encoding: 24, LatencyNs: 1000000, PullUp: domain.PullUpMinus01, ReadAt: time.Now()},
+	})
+	settingsMock = settingsServiceMock{}
+	sh = NewSettingsHandler(&cfg, &repo, &settingsMock)
+	router = gin.Default()
+	router.LoadHTMLGlob("../templates/*.tmpl")
+	router.GET("/devices/:name/settings", sh.SettingsPage)
+	router.GET("/api/v1/devices/:name/settings", sh.GetSettings)
+	router.PUT("/api/v1/devices/:name/settings", sh.UpdateSettings)
+	router.POST("/api/v1/devices/:name/identify", sh.Identify)
+	recorder = httptest.NewRecorder()
+	return func() {
+		router = nil
+	}
+}
+
+func TestSettingsPageSelectsCurrentSettings(t *testing.T) {
+	teardown := setupSettingsTest()
+	defer teardown()
+	request := httptest.NewRequest(http.MethodGet, "/devices/stagebox/settings", nil)
+
+	router.ServeHTTP(recorder, request)
+
+	assert.EqualValues(t, http.StatusOK, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), "<title>Settings</title>")
+	assert.Contains(t, recorder.Body.String(), `<option value="96000" selected>96000 Hz</option>`)
+	assert.Contains(t, recorder.Body.String(), `<option value="-0.1%" selected>-0.1%</option>`)
+}
+
+func TestSettingsPageUnknownDeviceReturnsNotFound(t *testing.T) {
+	teardown := setupSettingsTest()
+	defer teardown()
+	request := httptest.NewRequest(http.MethodGet, "/devices/unknown/settings", nil)
+
+	router.ServeHTTP(recorder, request)
+
+	assert.EqualValues(t, http.StatusNotFound, recorder.Code)
+}
+
+func TestGetSettingsReturnsSettingsReadFromDevice(t *testing.T) {
+	teardown := setupSettingsTest()
+	defer teardown()
+	request := httptest.NewRequest(http.MethodGet, "/api/v1/devices/stagebox/settings", nil)
+
+	router.ServeHTTP(recorder, request)
+
+	assert.EqualValues(t, http.StatusOK, recorder.Code)
+	assert.EqualValues(t, "stagebox", settingsMock.lastDevice)
+	assert.Contains(t, recorder.Body.String(), `"sampleRate":48000`)
+	assert.Contains(t, recorder.Body.String(), `"latencyUs":1000`)
+}
+
+func TestUpdateSettingsInvalidBodyReturnsBadRequest(t *testing.T) {
+	teardown := setupSettingsTest()
+	defer teardown()
+	request := httptest.NewRequest(http.MethodPut, "/api/v1/devices/stagebox/settings", strings.NewReader(`{"sampleRate": 48000}`))
+
+	router.ServeHTTP(recorder, request)
+
+	assert.EqualValues(t, http.StatusBadRequest, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), "invalid settings request")
+}
+
+func TestUpdateSettingsUnknownPullUpReturnsBadRequest(t *testing.T) {
+	teardown := setupSettingsTest()
+	defer teardown()
+	request := httptest.NewRequest(http.MethodPut, "/api/v1/devices/stagebox/settings", strings.NewReader(`{"sampleRate": 48000, "encoding": 24, "latencyUs": 1000, "pullUp": "+5%"}`))
+
+	router.ServeHTTP(recorder, request)
+
+	assert.EqualValues(t, http.StatusBadRequest, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), "unknown pull-up/down +5%")
+}
+
+func TestUpdateSettingsValidBodyPassesSettings(t *testing.T) {
+	teardown := setupSettingsTest()
+	defer teardown()
+	request := httptest.NewRequest(http.MethodPut, "/api/v1/devices/stagebox/settings", strings.NewReader(`{"sampleRate": 96000, "encoding": 32, "latencyUs": 2000, "pullUp": "+0.1%"}`))
+
+	router.ServeHTTP(recorder, request)
+
+	assert.EqualValues(t, http.StatusOK, recorder.Code)
+	assert.EqualValues(t, domain.DeviceSettings{SampleRate: 96000, Encoding: 32, LatencyNs: 2000000, PullUp: domain.PullUpPlus01}, settingsMock.lastTarget)
+	assert.Contains(t, recorder.Body.String(), `"pullUp":"+0.1%"`)
+}
+
+func TestIdentifyReturnsAccepted(t *testing.T) {
+	teardown := setupSettingsTest()
+	defer teardown()
+	request := httptest.NewRequest(http.MethodPost, "/api/v1/devices/stagebox/identify", nil)
+
+	router.ServeHTTP(recorder, request)
+
+	assert.EqualValues(t, http.StatusAccepted, recorder.Code)
+	assert.EqualValues(t, "stagebox", settingsMock.lastDevice)
+}
+
+func TestIdentifyThrottledReturnsTooManyRequests(t *testing.T) {
+	teardown := setupSettingsTest()
+	defer teardown()
+	settingsMock.err = api_error.NewError("device stagebox was asked to identify itself less than 5s ago", http.StatusTooManyRequests, nil)
+	request := httptest.NewRequest(http.MethodPost, "/api/v1/devices/stagebox/identify", nil)
+
+	router.ServeHTTP(recorder, request)
+
+	assert.EqualValues(t, http.StatusTooManyRequests, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), "less than 5s ago")
+}
@@ -8,28 +8,37 @@ import (
 	"github.com/johannes-kuhfuss/alighieri/config"
 	"github.com/johannes-kuhfuss/alighieri/dto"
 	"github.com/johannes-kuhfuss/alighieri/repositories"
+	"github.com/johannes-kuhfuss/alighieri/service"
 	"github.com/johannes-kuhfuss/services_utils/logger"
 )
 
 type StatsUiHandler struct {
-	Cfg  *config.AppConfig
-	Repo *repositories.DefaultDeviceRepository
+	Cfg       *config.AppConfig
+	Repo      *repositories.DefaultDeviceRepository
+	Conflicts service.ConflictService
+	Formats   service.FormatService
 }
 
 // NewStatsUiHandler creates a new web UI handler and injects its dependencies
-func NewStatsUiHandler(cfg *config.AppConfig, repo *repositories.DefaultDeviceRepository) StatsUiHandler {
+func NewStatsUiHandler(cfg *config.AppConfig, repo *repositories.DefaultDeviceRepository, conflicts service.ConflictService, formats service.FormatService) StatsUiHandler {
 	return StatsUiHandler{
-		Cfg:  cfg,
-		Repo: repo,
+		Cfg:       cfg,
+		Repo:      repo,
+		Conflicts: conflicts,
+		Formats:   formats,
 	}
 }
 
 // StatusPage is the handler for the status page
 func (uh *StatsUiHandler) StatusPage(c *gin.Context) {
 	configData := dto.GetConfig(uh.Cfg)
+	jobs := dto.GetJobs(uh.Cfg)
 	c.HTML(http.StatusOK, "status.page.tmpl", gin.H{
 		"title":      "Status",
 		"configdata": configData,
+		"jobs":       jobs,
+		"conflicts":  dto.GetConflictReport(uh.Conflicts.Report()),
+		"formats":    dto.GetFormatReport(uh.Formats.Report()),
 	})
 }
 
@@ -37,8 +46,31 @@ func (uh *StatsUiHandler) StatusPage(c *gin.Context) {
 func (uh *StatsUiHandler) DeviceListPage(c *gin.Context) {
 	devices := dto.GetDevices(uh.Repo)
 	c.HTML(http.StatusOK, "devicelist.page.tmpl", gin.H{
-		"title":   "Device List",
-		"devices": devices,
+		"title":          "Device List",
+		"devices":        devices,
+		"changesenabled": uh.Cfg.Server.AdminPassword != "",
+	})
+}
+
+// SubscriptionsPage is the handler for the page listing all receive channels and their subscriptions
+func (uh *StatsUiHandler) SubscriptionsPage(c *gin.Context) {
+	subscriptions := dto.GetSubscriptions(uh.Repo)
+	txChannels := dto.GetTxChannels(uh.Repo)
+	c.HTML(http.StatusOK, "subscriptions.page.tmpl", gin.H{
+		"title":          "Subscriptions",
+		"subscriptions":  subscriptions,
+		"txchannels":     txChannels,
+		"changesenabled": uh.Cfg.Server.AdminPassword != "",
+	})
+}
+
+// RoutingPage is the handler for the page displaying the routing matrix
+func (uh *StatsUiHandler) RoutingPage(c *gin.Context) {
+	matrix := dto.GetMatrix(uh.Repo)
+	c.HTML(http.StatusOK, "routing.page.tmpl", gin.H{
+		"title":          "Routing",
+		"matrix":         matrix,
+		"changesenabled": uh.Cfg.Server.AdminPassword != "",
 	})
 }
 
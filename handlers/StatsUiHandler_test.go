@@ -2,14 +2,19 @@ package handlers
 
 import (
 	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/johannes-kuhfuss/alighieri/config"
+	"github.com/johannes-kuhfuss/alighieri/domain"
 	"github.com/johannes-kuhfuss/alighieri/repositories"
+	"github.com/johannes-kuhfuss/alighieri/service"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -23,8 +28,9 @@ var (
 
 func setupUiTest() func() {
 	config.InitConfig("", &cfg)
+	cfg.Events.EventFile = ""
 	repo = repositories.NewDeviceRepository(&cfg)
-	uh = NewStatsUiHandler(&cfg, &repo)
+	uh = NewStatsUiHandler(&cfg, &repo, service.NewConflictService(&cfg, &repo), service.NewFormatService(&cfg, &repo))
 	router = gin.Default()
 	router.LoadHTMLGlob("../templates/*.tmpl")
 	recorder = httptest.NewRecorder()
@@ -50,6 +56,25 @@ func TestStatusPageReturnsStatus(t *testing.T) {
 	assert.True(t, containsTitle)
 }
 
+func TestStatusPageListsConflicts(t *testing.T) {
+	teardown := setupUiTest()
+	defer teardown()
+	repo.Store(domain.DeviceInfo{Name: "stagebox", IPv4: net.ParseIP("192.168.1.10"), LastSeen: time.Now()})
+	repo.Store(domain.DeviceInfo{Name: "amp", IPv4: net.ParseIP("192.168.1.10"), LastSeen: time.Now()})
+	uh.Conflicts.Analyze()
+	router.GET("/", uh.StatusPage)
+	request := httptest.NewRequest(http.MethodGet, "/", nil)
+
+	router.ServeHTTP(recorder, request)
+	res := recorder.Result()
+	defer res.Body.Close()
+	data, err := io.ReadAll(res.Body)
+
+	assert.EqualValues(t, http.StatusOK, res.StatusCode)
+	assert.Nil(t, err)
+	assert.Contains(t, string(data), "192.168.1.10 is used by amp, stagebox")
+}
+
 func TestAboutPageReturnsAbout(t *testing.T) {
 	teardown := setupUiTest()
 	defer teardown()
@@ -100,3 +125,183 @@ func TestLogsPageReturnsLogs(t *testing.T) {
 	assert.Nil(t, err)
 	assert.True(t, containsTitle)
 }
+
+func TestDeviceListPageShowsChannels(t *testing.T) {
+	teardown := setupUiTest()
+	defer teardown()
+	repo.Store(domain.DeviceInfo{
+		Name:       "device",
+		TxChannels: domain.ChannelList{{DeviceName: "device", Direction: domain.ChannelTx, Number: 1, Name: "Left"}},
+	})
+	router.GET("/devicelist", uh.DeviceListPage)
+	request := httptest.NewRequest(http.MethodGet, "/devicelist", nil)
+
+	router.ServeHTTP(recorder, request)
+	res := recorder.Result()
+	defer res.Body.Close()
+	data, err := io.ReadAll(res.Body)
+	containsChannels := strings.Contains(string(data), "Channels (1 TX / 0 RX)")
+	containsChannelName := strings.Contains(string(data), "<td>Left</td>")
+
+	assert.EqualValues(t, http.StatusOK, res.StatusCode)
+	assert.Nil(t, err)
+	assert.True(t, containsChannels)
+	assert.True(t, containsChannelName)
+}
+
+func TestDeviceListPageShowsIdentifyWhenChangesEnabled(t *testing.T) {
+	teardown := setupUiTest()
+	defer teardown()
+	cfg.Server.AdminPassword = "secret"
+	defer func() {
+		cfg.Server.AdminPassword = ""
+	}()
+	repo.Store(domain.DeviceInfo{Name: "device"})
+	router.GET("/devicelist", uh.DeviceListPage)
+	request := httptest.NewRequest(http.MethodGet, "/devicelist", nil)
+
+	router.ServeHTTP(recorder, request)
+	res := recorder.Result()
+	defer res.Body.Close()
+	data, err := io.ReadAll(res.Body)
+	containsIdentify := strings.Contains(string(data), "onclick=\"identify(this)\"")
+
+	assert.EqualValues(t, http.StatusOK, res.StatusCode)
+	assert.Nil(t, err)
+	assert.True(t, containsIdentify)
+}
+
+func TestDeviceListPageKeysRowsByIdentity(t *testing.T) {
+	teardown := setupUiTest()
+	defer teardown()
+	repo.Store(domain.DeviceInfo{Name: "stagebox", Id: "001dc1fffe000001"})
+	repo.Store(domain.DeviceInfo{Name: "stagebox", Id: "001dc1fffe000002"})
+	router.GET("/devicelist", uh.DeviceListPage)
+	request := httptest.NewRequest(http.MethodGet, "/devicelist", nil)
+
+	router.ServeHTTP(recorder, request)
+	res := recorder.Result()
+	defer res.Body.Close()
+	data, err := io.ReadAll(res.Body)
+
+	assert.EqualValues(t, http.StatusOK, res.StatusCode)
+	assert.Nil(t, err)
+	assert.Contains(t, string(data), "data-device=\"001dc1fffe000001\" data-name=\"stagebox\"")
+	assert.Contains(t, string(data), "data-device=\"001dc1fffe000002\" data-name=\"stagebox\"")
+}
+
+func TestSubscriptionsPageReturnsSubscriptions(t *testing.T) {
+	teardown := setupUiTest()
+	defer teardown()
+	repo.Store(domain.DeviceInfo{
+		Name:       "stagebox",
+		RxChannels: domain.ChannelList{{DeviceName: "stagebox", Direction: domain.ChannelRx, Number: 1, Name: "In 1", TxChannelName: "Out 1", TxDeviceName: "mixer"}},
+	})
+	router.GET("/subscriptions", uh.SubscriptionsPage)
+	request := httptest.NewRequest(http.MethodGet, "/subscriptions", nil)
+
+	router.ServeHTTP(recorder, request)
+	res := recorder.Result()
+	defer res.Body.Close()
+	data, err := io.ReadAll(res.Body)
+	containsTitle := strings.Contains(string(data), "<title>Subscriptions</title>")
+	containsChannel := strings.Contains(string(data), "<td>In 1</td>")
+	containsDisabled := strings.Contains(string(data), "Changes are disabled")
+
+	assert.EqualValues(t, http.StatusOK, res.StatusCode)
+	assert.Nil(t, err)
+	assert.True(t, containsTitle)
+	assert.True(t, containsChannel)
+	assert.True(t, containsDisabled)
+}
+
+func TestRoutingPageReturnsMatrix(t *testing.T) {
+	teardown := setupUiTest()
+	defer teardown()
+	cfg.Server.AdminPassword = "secret"
+	defer func() {
+		cfg.Server.AdminPassword = ""
+	}()
+	repo.Store(domain.DeviceInfo{
+		Name:       "stagebox",
+		RxChannels: domain.ChannelList{{DeviceName: "stagebox", Direction: domain.ChannelRx, Number: 1, Name: "In 1", TxChannelName: "Out 1", TxDeviceName: "mixer"}},
+	})
+	repo.Store(domain.DeviceInfo{
+		Name:       "mixer",
+		TxChannels: domain.ChannelList{{DeviceName: "mixer", Direction: domain.ChannelTx, Number: 1, Name: "Out 1"}},
+	})
+	router.GET("/routing", uh.RoutingPage)
+	request := httptest.NewRequest(http.MethodGet, "/routing", nil)
+
+	router.ServeHTTP(recorder, request)
+	res := recorder.Result()
+	defer res.Body.Close()
+	data, err := io.ReadAll(res.Body)
+	containsTitle := strings.Contains(string(data), "<title>Routing</title>")
+	containsCrosspoint := strings.Contains(string(data), "crosspoint table-success")
+	containsPatch := strings.Contains(string(data), "onclick=\"patch(this)\"")
+
+	assert.EqualValues(t, http.StatusOK, res.StatusCode)
+	assert.Nil(t, err)
+	assert.True(t, containsTitle)
+	assert.True(t, containsCrosspoint)
+	assert.True(t, containsPatch)
+}
+
+func TestEventsPageReturnsEvents(t *testing.T) {
+	teardown := setupUiTest()
+	defer teardown()
+	cfg.Events.EventFile = filepath.Join(t.TempDir(), "events.json")
+	eventRepo := repositories.NewEventRepository(&cfg)
+	defer eventRepo.Close()
+	eventRepo.Add(domain.Event{Type: domain.EventNewDevice, Device: "stagebox"})
+	eh := NewEventHandler(&cfg, &eventRepo)
+	router.GET("/events", eh.EventsPage)
+	request := httptest.NewRequest(http.MethodGet, "/events?type=offline", nil)
+
+	router.ServeHTTP(recorder, request)
+	res := recorder.Result()
+	defer res.Body.Close()
+	data, _ := io.ReadAll(res.Body)
+
+	assert.EqualValues(t, http.StatusOK, res.StatusCode)
+	assert.True(t, strings.Contains(string(data), "<title>Events</title>"))
+	assert.True(t, strings.Contains(string(data), "No events recorded"))
+}
+
+func TestWebhooksPageReturnsTargetsAndDeliveries(t *testing.T) {
+	teardown := setupUiTest()
+	defer teardown()
+	cfg.Webhooks.Targets = config.WebhookTargets{{Name: "oncall", Url: "http://chat/hook"}}
+	defer func() { cfg.Webhooks.Targets = nil }()
+	wh := NewWebhookHandler(&cfg, service.NewWebhookService(&cfg))
+	router.GET("/webhooks", wh.WebhooksPage)
+	request := httptest.NewRequest(http.MethodGet, "/webhooks", nil)
+
+	router.ServeHTTP(recorder, request)
+	res := recorder.Result()
+	defer res.Body.Close()
+	data, _ := io.ReadAll(res.Body)
+
+	assert.EqualValues(t, http.StatusOK, res.StatusCode)
+	assert.True(t, strings.Contains(string(data), "<title>Webhooks</title>"))
+	assert.True(t, strings.Contains(string(data), "http://chat/hook"))
+	assert.True(t, strings.Contains(string(data), "No deliveries yet"))
+}
+
+func TestRedundancyPageWithOneInterfaceExplainsSetup(t *testing.T) {
+	teardown := setupUiTest()
+	defer teardown()
+	rh := NewRedundancyHandler(&cfg, service.NewRedundancyService(&cfg, &repo))
+	router.GET("/redundancy", rh.RedundancyPage)
+	request := httptest.NewRequest(http.MethodGet, "/redundancy", nil)
+
+	router.ServeHTTP(recorder, request)
+	res := recorder.Result()
+	defer res.Body.Close()
+	data, _ := io.ReadAll(res.Body)
+
+	assert.EqualValues(t, http.StatusOK, res.StatusCode)
+	assert.True(t, strings.Contains(string(data), "<title>Redundancy</title>"))
+	assert.True(t, strings.Contains(string(data), "need a primary and a secondary network"))
+}
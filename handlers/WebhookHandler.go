@@ -0,0 +1,33 @@
+// package handlers sets up the handlers for the Web UI
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/johannes-kuhfuss/alighieri/config"
+	"github.com/johannes-kuhfuss/alighieri/dto"
+	"github.com/johannes-kuhfuss/alighieri/service"
+)
+
+type WebhookHandler struct {
+	Cfg *config.AppConfig
+	Svc service.WebhookService
+}
+
+// NewWebhookHandler creates a new webhook handler and injects its dependencies
+func NewWebhookHandler(cfg *config.AppConfig, svc service.WebhookService) WebhookHandler {
+	return WebhookHandler{
+		Cfg: cfg,
+		Svc: svc,
+	}
+}
+
+// WebhooksPage is the handler for the page listing the webhook targets and the delivery log
+func (wh *WebhookHandler) WebhooksPage(c *gin.Context) {
+	c.HTML(http.StatusOK, "webhooks.page.tmpl", gin.H{
+		"title":      "Webhooks",
+		"targets":    dto.GetWebhookTargets(wh.Cfg),
+		"deliveries": dto.GetWebhookDeliveries(wh.Svc.Deliveries()),
+	})
+}
@@ -4,43 +4,81 @@ package repositories
 import (
 	"errors"
 	"fmt"
+	"net"
+	"strings"
+	"sync"
 
 	"github.com/johannes-kuhfuss/alighieri/config"
 	"github.com/johannes-kuhfuss/alighieri/domain"
+	"github.com/johannes-kuhfuss/services_utils/logger"
 )
 
 type DeviceRepository interface {
 	Exists(string) bool
 	Size() int
 	GetByName(string) *domain.DeviceInfo
+	GetByKey(string) *domain.DeviceInfo
+	Find(string) *domain.DeviceInfo
+	GetByAddress(string) *domain.DeviceInfo
 	GetAll() *domain.DeviceList
 	Store(domain.DeviceInfo) error
 	Delete(string) error
 	DeleteAllData()
+	Close() error
+	Watch(DeviceWatcher)
 }
 
 type DefaultDeviceRepository struct {
 	Cfg *config.AppConfig
 }
 
+// DeviceWatcher is called after a device has been stored or removed. A renamed device is reported as removed under its old and added under its new name
+type DeviceWatcher func(key string, name string, added bool, removed bool)
+
 var (
-	deviceList domain.SafeDeviceList
+	deviceList  domain.SafeDeviceList
+	deviceStore DeviceStore = memoryDeviceStore{}
+	// storeLock keeps the writes to the device store in the order of the changes to the list, so the list lock is not held during disk I/O.
+	// It is taken while holding the list lock and released after the write
+	storeLock      sync.Mutex
+	deviceWatchers []DeviceWatcher
 )
 
-// NewDeviceRepository creates a new device repository. You need to pass in the configuration
+// NewDeviceRepository creates a new device repository and loads the devices from the configured store. You need to pass in the configuration
 func NewDeviceRepository(cfg *config.AppConfig) DefaultDeviceRepository {
+	deviceList.Lock()
+	defer deviceList.Unlock()
+	storeLock.Lock()
+	defer storeLock.Unlock()
 	deviceList.Devices = make(map[string]domain.DeviceInfo)
+	deviceWatchers = nil
+	if err := deviceStore.Close(); err != nil {
+		logger.Error("Could not close device store", err)
+	}
+	store, err := newDeviceStore(cfg)
+	if err != nil {
+		logger.Error("Could not open device store. Devices will not be persisted", err)
+		store = memoryDeviceStore{}
+	}
+	deviceStore = store
+	devices, err := deviceStore.Load()
+	if err != nil {
+		logger.Error("Could not load devices from device store", err)
+	}
+	for _, device := range devices {
+		deviceList.Devices[device.Key()] = device
+	}
+	if len(devices) > 0 {
+		logger.Infof("Loaded %v device(s) from device store", len(devices))
+	}
 	return DefaultDeviceRepository{
 		Cfg: cfg,
 	}
 }
 
-// Exists checks whether a device identified by its name exists in the repository
+// Exists checks whether a device with the given name exists in the repository
 func (dr DefaultDeviceRepository) Exists(name string) bool {
-	deviceList.RLock()
-	defer deviceList.RUnlock()
-	_, ok := deviceList.Devices[name]
-	return ok
+	return dr.GetByName(name) != nil
 }
 
 // Size returns the number of devices stored in the repository
@@ -50,18 +88,71 @@ func (dr DefaultDeviceRepository) Size() int {
 	return len(deviceList.Devices)
 }
 
-// GetByName returns a device's information where the device is identified by its name. If no device matches, the method returns nil
+// GetByName returns the information of the device with the given name. If devices on different networks share a name, the most recently seen one is returned.
+// If no device matches, the method returns nil
 func (dr DefaultDeviceRepository) GetByName(name string) *domain.DeviceInfo {
-	var di domain.DeviceInfo
-	if !dr.Exists(name) {
-		return nil
+	var found *domain.DeviceInfo
+	deviceList.RLock()
+	defer deviceList.RUnlock()
+	for _, device := range deviceList.Devices {
+		if device.Name == name && (found == nil || device.LastSeen.After(found.LastSeen)) {
+			found = &device
+		}
 	}
+	return found
+}
+
+// GetByKey returns the information of the device stored under the given identity, see domain.DeviceInfo.Key. If no device matches, the method returns nil
+func (dr DefaultDeviceRepository) GetByKey(key string) *domain.DeviceInfo {
 	deviceList.RLock()
 	defer deviceList.RUnlock()
-	di = deviceList.Devices[name]
+	di, ok := deviceList.Devices[key]
+	if !ok {
+		return nil
+	}
 	return &di
 }
 
+// Find returns the information of the device stored under the given identity or, if there is none, of the only device with the given name.
+// If several devices share the name or no device matches, the method returns nil
+func (dr DefaultDeviceRepository) Find(ref string) *domain.DeviceInfo {
+	var found *domain.DeviceInfo
+	deviceList.RLock()
+	defer deviceList.RUnlock()
+	if di, ok := deviceList.Devices[ref]; ok {
+		return &di
+	}
+	for _, device := range deviceList.Devices {
+		if device.Name != ref {
+			continue
+		}
+		if found != nil {
+			return nil
+		}
+		found = &device
+	}
+	return found
+}
+
+// GetByAddress returns the information of the device using an IPv4 or IPv6 address, e.g. "192.168.1.10" or "fe80::1%eth0".
+// The zone of a link-local address is optional. If no device matches or the address is invalid, the method returns nil
+func (dr DefaultDeviceRepository) GetByAddress(address string) *domain.DeviceInfo {
+	host, zone, _ := strings.Cut(address, "%")
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return nil
+	}
+	addr := net.IPAddr{IP: ip, Zone: zone}
+	deviceList.RLock()
+	defer deviceList.RUnlock()
+	for _, device := range deviceList.Devices {
+		if device.HasAddress(addr) {
+			return &device
+		}
+	}
+	return nil
+}
+
 // GetAll returns all device data from the repository. Returns nil if repository is empty
 func (dr DefaultDeviceRepository) GetAll() *domain.DeviceList {
 	var list domain.DeviceList
@@ -76,31 +167,87 @@ func (dr DefaultDeviceRepository) GetAll() *domain.DeviceList {
 	return &list
 }
 
-// Store stores a new device information entry into the repository
+// Store stores a device information entry into the repository under its identity, replacing the entry with the same identity
 func (dr DefaultDeviceRepository) Store(di domain.DeviceInfo) error {
 	if di.Name == "" {
 		return errors.New("cannot add item with empty name to list")
 	}
 	deviceList.Lock()
-	defer deviceList.Unlock()
-	deviceList.Devices[di.Name] = di
-	return nil
+	old, exists := deviceList.Devices[di.Key()]
+	deviceList.Devices[di.Key()] = di
+	store := deviceStore
+	storeLock.Lock()
+	deviceList.Unlock()
+	err := store.Save(di)
+	storeLock.Unlock()
+	if exists && old.Name != di.Name {
+		notifyWatchers(di.Key(), old.Name, false, true)
+		notifyWatchers(di.Key(), di.Name, true, false)
+	} else {
+		notifyWatchers(di.Key(), di.Name, !exists, false)
+	}
+	return err
 }
 
-// Delete a device information entry from the repository, if it exists
-func (dr DefaultDeviceRepository) Delete(name string) error {
-	if !dr.Exists(name) {
-		return fmt.Errorf("item with name %v does not exist", name)
-	}
+// Delete a device information entry identified by its identity from the repository, if it exists
+func (dr DefaultDeviceRepository) Delete(key string) error {
 	deviceList.Lock()
-	defer deviceList.Unlock()
-	delete(deviceList.Devices, name)
-	return nil
+	old, exists := deviceList.Devices[key]
+	if !exists {
+		deviceList.Unlock()
+		return fmt.Errorf("item with key %v does not exist", key)
+	}
+	delete(deviceList.Devices, key)
+	store := deviceStore
+	storeLock.Lock()
+	deviceList.Unlock()
+	err := store.Delete(key)
+	storeLock.Unlock()
+	notifyWatchers(key, old.Name, false, true)
+	return err
 }
 
 // DeleteAllData removes all entries from the repository
 func (dr DefaultDeviceRepository) DeleteAllData() {
 	deviceList.Lock()
-	defer deviceList.Unlock()
+	removed := deviceList.Devices
 	deviceList.Devices = make(map[string]domain.DeviceInfo)
+	store := deviceStore
+	storeLock.Lock()
+	deviceList.Unlock()
+	if err := store.DeleteAll(); err != nil {
+		logger.Error("Could not delete devices from device store", err)
+	}
+	storeLock.Unlock()
+	for key, device := range removed {
+		notifyWatchers(key, device.Name, false, true)
+	}
+}
+
+// Close closes the device store. Changes after closing are kept in memory only
+func (dr DefaultDeviceRepository) Close() error {
+	deviceList.Lock()
+	defer deviceList.Unlock()
+	storeLock.Lock()
+	defer storeLock.Unlock()
+	err := deviceStore.Close()
+	deviceStore = memoryDeviceStore{}
+	return err
+}
+
+// Watch registers a function called after every change of a device
+func (dr DefaultDeviceRepository) Watch(watcher DeviceWatcher) {
+	deviceList.Lock()
+	defer deviceList.Unlock()
+	deviceWatchers = append(deviceWatchers, watcher)
+}
+
+// notifyWatchers calls all registered watchers. Needs to be called without the lock held, so watchers can read the repository
+func notifyWatchers(key string, name string, added bool, removed bool) {
+	deviceList.RLock()
+	watchers := deviceWatchers
+	deviceList.RUnlock()
+	for _, watcher := range watchers {
+		watcher(key, name, added, removed)
+	}
 }
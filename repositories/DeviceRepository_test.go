@@ -1,7 +1,10 @@
 package repositories
 
 import (
+	"fmt"
+	"net"
 	"testing"
+	"time"
 
 	"github.com/johannes-kuhfuss/alighieri/config"
 	"github.com/johannes-kuhfuss/alighieri/domain"
@@ -75,7 +78,7 @@ func TestDeleteNonExistingElementReturnsError(t *testing.T) {
 	setupTest()
 	err := repo.Delete("A")
 	assert.NotNil(t, err)
-	assert.EqualValues(t, "item with name A does not exist", err.Error())
+	assert.EqualValues(t, "item with key A does not exist", err.Error())
 }
 
 func TestDeleteExistingElementDeletesElement(t *testing.T) {
@@ -108,3 +111,113 @@ func TestDeleteAllDeletesAllElements(t *testing.T) {
 	assert.EqualValues(t, 2, sizeBefore)
 	assert.EqualValues(t, 0, sizeAfter)
 }
+
+func TestWatchNotifiesAboutChanges(t *testing.T) {
+	setupTest()
+	var changes []string
+	repo.Watch(func(key string, name string, added bool, removed bool) {
+		changes = append(changes, fmt.Sprintf("%v %v %v %v", key, name, added, removed))
+	})
+	repo.Store(domain.DeviceInfo{Name: "stagebox"})
+	repo.Store(domain.DeviceInfo{Name: "stagebox"})
+	repo.Delete("stagebox")
+
+	assert.EqualValues(t, []string{"stagebox stagebox true false", "stagebox stagebox false false", "stagebox stagebox false true"}, changes)
+}
+
+func TestGetByAddressFindsIPv4AndIPv6Addresses(t *testing.T) {
+	setupTest()
+	defer repo.DeleteAllData()
+	repo.Store(domain.DeviceInfo{
+		Name: "A",
+		IPv4: net.ParseIP("192.168.1.10"),
+		Addresses: []net.IPAddr{
+			{IP: net.ParseIP("192.168.1.10")},
+			{IP: net.ParseIP("fe80::1"), Zone: "eth0"},
+			{IP: net.ParseIP("2001:db8::10")},
+		},
+	})
+
+	assert.EqualValues(t, "A", repo.GetByAddress("192.168.1.10").Name)
+	assert.EqualValues(t, "A", repo.GetByAddress("2001:db8::10").Name)
+	assert.EqualValues(t, "A", repo.GetByAddress("fe80::1%eth0").Name)
+	assert.EqualValues(t, "A", repo.GetByAddress("fe80::1").Name)
+	assert.Nil(t, repo.GetByAddress("fe80::1%eth1"))
+	assert.Nil(t, repo.GetByAddress("192.168.1.11"))
+	assert.Nil(t, repo.GetByAddress("no address"))
+}
+
+func TestStoreDeviceWithIdReplacesEntryOnRename(t *testing.T) {
+	setupTest()
+	defer repo.DeleteAllData()
+	var changes []string
+	repo.Watch(func(key string, name string, added bool, removed bool) {
+		changes = append(changes, fmt.Sprintf("%v %v %v %v", key, name, added, removed))
+	})
+	repo.Store(domain.DeviceInfo{Name: "stagebox", Id: "001dc1fffe000001"})
+
+	repo.Store(domain.DeviceInfo{Name: "stagebox-foh", Id: "001dc1fffe000001"})
+
+	assert.EqualValues(t, 1, repo.Size())
+	assert.Nil(t, repo.GetByName("stagebox"))
+	assert.EqualValues(t, "stagebox-foh", repo.GetByKey("001dc1fffe000001").Name)
+	assert.EqualValues(t, []string{"001dc1fffe000001 stagebox true false", "001dc1fffe000001 stagebox false true", "001dc1fffe000001 stagebox-foh true false"}, changes)
+}
+
+func TestStoreDevicesWithSameNameKeepsBoth(t *testing.T) {
+	setupTest()
+	defer repo.DeleteAllData()
+	now := time.Now()
+	repo.Store(domain.DeviceInfo{Name: "stagebox", Id: "001dc1fffe000001", LastSeen: now.Add(-time.Minute)})
+	repo.Store(domain.DeviceInfo{Name: "stagebox", Id: "001dc1fffe000002", LastSeen: now})
+
+	assert.EqualValues(t, 2, repo.Size())
+	assert.EqualValues(t, "001dc1fffe000002", repo.GetByName("stagebox").Id)
+	assert.Nil(t, repo.Delete("001dc1fffe000002"))
+	assert.EqualValues(t, "001dc1fffe000001", repo.GetByName("stagebox").Id)
+}
+
+func TestFindPrefersKeyAndOnlyUsesUniqueNames(t *testing.T) {
+	setupTest()
+	defer repo.DeleteAllData()
+	repo.Store(domain.DeviceInfo{Name: "stagebox", Id: "001dc1fffe000001"})
+	repo.Store(domain.DeviceInfo{Name: "stagebox", Id: "001dc1fffe000002"})
+	repo.Store(domain.DeviceInfo{Name: "mixer", Id: "001dc1fffe000003"})
+
+	assert.EqualValues(t, "001dc1fffe000002", repo.Find("001dc1fffe000002").Id)
+	assert.EqualValues(t, "001dc1fffe000003", repo.Find("mixer").Id)
+	assert.Nil(t, repo.Find("stagebox"))
+	assert.Nil(t, repo.Find("unknown"))
+}
+
+// blockingDeviceStore holds every save until it is released
+type blockingDeviceStore struct {
+	memoryDeviceStore
+	saving  chan bool
+	release chan bool
+}
+
+func (s blockingDeviceStore) Save(domain.DeviceInfo) error {
+	s.saving <- true
+	<-s.release
+	return nil
+}
+
+func TestStoreDoesNotBlockReadsWhileSaving(t *testing.T) {
+	setupTest()
+	defer repo.DeleteAllData()
+	store := blockingDeviceStore{saving: make(chan bool), release: make(chan bool)}
+	deviceStore = store
+	defer func() { deviceStore = memoryDeviceStore{} }()
+	stored := make(chan error)
+	go func() {
+		stored <- repo.Store(domain.DeviceInfo{Name: "stagebox", Id: "001dc1fffe000001"})
+	}()
+	<-store.saving
+
+	device := repo.GetByKey("001dc1fffe000001")
+	close(store.release)
+
+	assert.NotNil(t, device)
+	assert.Nil(t, <-stored)
+}
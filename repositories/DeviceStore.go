@@ -0,0 +1,161 @@
+// Package repositories implements an in-memory store for representing the data of the files scanned
+package repositories
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/johannes-kuhfuss/alighieri/config"
+	"github.com/johannes-kuhfuss/alighieri/domain"
+	bolt "go.etcd.io/bbolt"
+)
+
+// Device store backends selectable via configuration
+const (
+	DeviceStoreMemory = "memory"
+	DeviceStoreBolt   = "bolt"
+)
+
+// DeviceStore persists the devices held by the device repository. The repository keeps all devices in memory and writes every change through to the store
+type DeviceStore interface {
+	Load() (domain.DeviceList, error)
+	Save(domain.DeviceInfo) error
+	Delete(string) error // removes the device stored under the given identity
+	DeleteAll() error
+	Close() error
+}
+
+// newDeviceStore opens the store backend selected in the configuration
+func newDeviceStore(cfg *config.AppConfig) (DeviceStore, error) {
+	switch cfg.Storage.DeviceBackend {
+	case "", DeviceStoreMemory:
+		return memoryDeviceStore{}, nil
+	case DeviceStoreBolt:
+		return newBoltDeviceStore(cfg.Storage.DeviceFile)
+	}
+	return nil, fmt.Errorf("unknown device store backend %v", cfg.Storage.DeviceBackend)
+}
+
+// memoryDeviceStore keeps nothing, so the devices are lost on restart
+type memoryDeviceStore struct{}
+
+func (memoryDeviceStore) Load() (domain.DeviceList, error) { return nil, nil }
+func (memoryDeviceStore) Save(domain.DeviceInfo) error     { return nil }
+func (memoryDeviceStore) Delete(string) error              { return nil }
+func (memoryDeviceStore) DeleteAll() error                 { return nil }
+func (memoryDeviceStore) Close() error                     { return nil }
+
+var (
+	deviceBucket = []byte("devices")
+)
+
+// boltDeviceStore keeps one JSON-encoded record per device in a bbolt database file
+type boltDeviceStore struct {
+	db *bolt.DB
+}
+
+func newBoltDeviceStore(fileName string) (*boltDeviceStore, error) {
+	db, err := bolt.Open(fileName, 0600, &bolt.Options{Timeout: 1 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("could not open device store %v: %w", fileName, err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(deviceBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &boltDeviceStore{db: db}, nil
+}
+
+// Load reads all devices from the database. Records stored under another key than the device's identity, e.g. by name before devices were identified by their id,
+// are moved to their identity. If several records share an identity, the most recently seen one is kept and the names of the others become previous names
+func (bs *boltDeviceStore) Load() (list domain.DeviceList, err error) {
+	err = bs.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(deviceBucket)
+		devices := make(map[string]domain.DeviceInfo)
+		var keys []string
+		var moved [][]byte
+		err := bucket.ForEach(func(k, v []byte) error {
+			var di domain.DeviceInfo
+			if err := json.Unmarshal(v, &di); err != nil {
+				return fmt.Errorf("could not read device %s: %w", k, err)
+			}
+			if string(k) != di.Key() {
+				moved = append(moved, append([]byte(nil), k...))
+			}
+			known, ok := devices[di.Key()]
+			switch {
+			case !ok:
+				keys = append(keys, di.Key())
+			case known.LastSeen.After(di.LastSeen):
+				di = known.WithPreviousName(di.Name)
+			default:
+				di = di.WithPreviousName(known.Name)
+			}
+			devices[di.Key()] = di
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for _, k := range moved {
+			if err := bucket.Delete(k); err != nil {
+				return err
+			}
+		}
+		for _, key := range keys {
+			di := devices[key]
+			if len(moved) > 0 {
+				if err := putDevice(bucket, di); err != nil {
+					return err
+				}
+			}
+			list = append(list, di)
+		}
+		return nil
+	})
+	return list, err
+}
+
+// Save writes a device to the database, replacing an existing record with the same identity
+func (bs *boltDeviceStore) Save(di domain.DeviceInfo) error {
+	return bs.db.Update(func(tx *bolt.Tx) error {
+		return putDevice(tx.Bucket(deviceBucket), di)
+	})
+}
+
+// putDevice writes a device as JSON under its identity
+func putDevice(bucket *bolt.Bucket, di domain.DeviceInfo) error {
+	data, err := json.Marshal(di)
+	if err != nil {
+		return err
+	}
+	return bucket.Put([]byte(di.Key()), data)
+}
+
+// Delete removes a device from the database
+func (bs *boltDeviceStore) Delete(key string) error {
+	return bs.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(deviceBucket).Delete([]byte(key))
+	})
+}
+
+// DeleteAll removes all devices from the database
+func (bs *boltDeviceStore) DeleteAll() error {
+	return bs.db.Update(func(tx *bolt.Tx) error {
+		if err := tx.DeleteBucket(deviceBucket); err != nil {
+			return err
+		}
+		_, err := tx.CreateBucket(deviceBucket)
+		return err
+	})
+}
+
+// Close closes the database file
+func (bs *boltDeviceStore) Close() error {
+	return bs.db.Close()
+}
@@ -0,0 +1,101 @@
+package repositories
+
+import (
+	"encoding/json"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/johannes-kuhfuss/alighieri/config"
+	"github.com/johannes-kuhfuss/alighieri/domain"
+	"github.com/stretchr/testify/assert"
+	bolt "go.etcd.io/bbolt"
+)
+
+func setupBoltTest(t *testing.T) *config.AppConfig {
+	var boltCfg config.AppConfig
+	boltCfg.Storage.DeviceBackend = DeviceStoreBolt
+	boltCfg.Storage.DeviceFile = filepath.Join(t.TempDir(), "devices.db")
+	t.Cleanup(func() {
+		repo = NewDeviceRepository(&cfg)
+	})
+	return &boltCfg
+}
+
+func TestNewDeviceStoreUnknownBackendReturnsError(t *testing.T) {
+	var unknownCfg config.AppConfig
+	unknownCfg.Storage.DeviceBackend = "tape"
+	_, err := newDeviceStore(&unknownCfg)
+
+	assert.NotNil(t, err)
+	assert.EqualValues(t, "unknown device store backend tape", err.Error())
+}
+
+func TestBoltStoreKeepsDevicesAcrossRestart(t *testing.T) {
+	boltCfg := setupBoltTest(t)
+	boltRepo := NewDeviceRepository(boltCfg)
+	firstSeen := time.Date(2024, 9, 17, 11, 12, 13, 0, time.UTC)
+	boltRepo.Store(domain.DeviceInfo{Name: "stagebox", IPv4: net.ParseIP("192.168.1.10"), FirstSeen: firstSeen})
+	boltRepo.Store(domain.DeviceInfo{Name: "mixer"})
+	boltRepo.Delete("mixer")
+	boltRepo.Close()
+
+	boltRepo = NewDeviceRepository(boltCfg)
+	device := boltRepo.GetByName("stagebox")
+
+	assert.EqualValues(t, 1, boltRepo.Size())
+	assert.NotNil(t, device)
+	assert.True(t, firstSeen.Equal(device.FirstSeen))
+	assert.EqualValues(t, "192.168.1.10", device.IPv4.String())
+}
+
+func TestBoltStoreDeleteAllDataEmptiesStore(t *testing.T) {
+	boltCfg := setupBoltTest(t)
+	boltRepo := NewDeviceRepository(boltCfg)
+	boltRepo.Store(domain.DeviceInfo{Name: "stagebox"})
+	boltRepo.DeleteAllData()
+	boltRepo.Close()
+
+	boltRepo = NewDeviceRepository(boltCfg)
+
+	assert.EqualValues(t, 0, boltRepo.Size())
+}
+
+func TestBoltStoreMovesDevicesStoredByNameToTheirId(t *testing.T) {
+	boltCfg := setupBoltTest(t)
+	store, _ := newBoltDeviceStore(boltCfg.Storage.DeviceFile)
+	lastSeen := time.Date(2024, 9, 17, 11, 12, 13, 0, time.UTC)
+	store.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(deviceBucket)
+		for key, di := range map[string]domain.DeviceInfo{
+			"stagebox":   {Name: "stagebox", Id: "001dc1fffe000001", LastSeen: lastSeen},
+			"stagebox-2": {Name: "stagebox-2", Id: "001dc1fffe000001", LastSeen: lastSeen.Add(time.Hour)},
+			"mixer":      {Name: "mixer", LastSeen: lastSeen},
+		} {
+			data, _ := json.Marshal(di)
+			bucket.Put([]byte(key), data)
+		}
+		return nil
+	})
+	store.Close()
+
+	boltRepo := NewDeviceRepository(boltCfg)
+	device := boltRepo.GetByKey("001dc1fffe000001")
+	boltRepo.Close()
+	store, _ = newBoltDeviceStore(boltCfg.Storage.DeviceFile)
+	var keys []string
+	store.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(deviceBucket).ForEach(func(k, v []byte) error {
+			keys = append(keys, string(k))
+			return nil
+		})
+	})
+	store.Close()
+
+	assert.EqualValues(t, 2, boltRepo.Size())
+	assert.NotNil(t, device)
+	assert.EqualValues(t, "stagebox-2", device.Name)
+	assert.EqualValues(t, []string{"stagebox"}, device.PreviousNames)
+	assert.EqualValues(t, []string{"001dc1fffe000001", "mixer"}, keys)
+}
@@ -0,0 +1,176 @@
+// Package repositories implements an in-memory store for representing the data of the files scanned
+package repositories
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/johannes-kuhfuss/alighieri/config"
+	"github.com/johannes-kuhfuss/alighieri/domain"
+	"github.com/johannes-kuhfuss/services_utils/logger"
+)
+
+type EventRepository interface {
+	Size() int
+	Add(domain.Event) error
+	Find(string, domain.EventType) *domain.EventList
+	DeleteAllData()
+	Close() error
+}
+
+type DefaultEventRepository struct {
+	Cfg *config.AppConfig
+}
+
+var (
+	eventList domain.SafeEventList
+	// eventSave batches writes of the event file, so a scan producing many events writes the file once
+	eventSave struct {
+		sync.Mutex
+		pending *time.Timer
+	}
+	eventSaveDelay = time.Second
+)
+
+// NewEventRepository creates a new event repository and loads the events from the event file. You need to pass in the configuration
+func NewEventRepository(cfg *config.AppConfig) DefaultEventRepository {
+	eventList.Events = nil
+	eventSave.Lock()
+	if eventSave.pending != nil {
+		eventSave.pending.Stop()
+		eventSave.pending = nil
+	}
+	eventSave.Unlock()
+	er := DefaultEventRepository{
+		Cfg: cfg,
+	}
+	if err := er.load(); err != nil {
+		logger.Error("Could not load events", err)
+	}
+	return er
+}
+
+// Size returns the number of events stored in the repository
+func (er DefaultEventRepository) Size() int {
+	eventList.RLock()
+	defer eventList.RUnlock()
+	return len(eventList.Events)
+}
+
+// Add appends an event and drops the oldest events beyond the configured maximum. The event file is written shortly after, together with further events added in the meantime
+func (er DefaultEventRepository) Add(e domain.Event) error {
+	if e.Device == "" {
+		return errors.New("cannot add event without device")
+	}
+	eventList.Lock()
+	eventList.Events = append(eventList.Events, e)
+	er.trim()
+	eventList.Unlock()
+	er.scheduleSave()
+	return nil
+}
+
+// Close writes events not yet written to the event file
+func (er DefaultEventRepository) Close() error {
+	eventSave.Lock()
+	if eventSave.pending != nil {
+		eventSave.pending.Stop()
+		eventSave.pending = nil
+	}
+	eventSave.Unlock()
+	return er.save()
+}
+
+// Find returns the events matching the device and event type, newest first. Empty values match all. Returns nil if no event matches
+func (er DefaultEventRepository) Find(device string, eventType domain.EventType) *domain.EventList {
+	var list domain.EventList
+	eventList.RLock()
+	defer eventList.RUnlock()
+	for i := len(eventList.Events) - 1; i >= 0; i-- {
+		e := eventList.Events[i]
+		if (device == "" || e.Device == device) && (eventType == "" || e.Type == eventType) {
+			list = append(list, e)
+		}
+	}
+	if len(list) == 0 {
+		return nil
+	}
+	return &list
+}
+
+// DeleteAllData removes all events from the repository and the event file
+func (er DefaultEventRepository) DeleteAllData() {
+	eventList.Lock()
+	eventList.Events = nil
+	eventList.Unlock()
+	if err := er.Close(); err != nil {
+		logger.Error("Could not write event file", err)
+	}
+}
+
+// trim drops the oldest events beyond the configured maximum. Needs to be called with the lock held
+func (er DefaultEventRepository) trim() {
+	if limit := er.Cfg.Events.MaxEvents; limit > 0 && len(eventList.Events) > limit {
+		eventList.Events = append(domain.EventList(nil), eventList.Events[len(eventList.Events)-limit:]...)
+	}
+}
+
+// load reads the events from the event file. A missing file is not an error
+func (er DefaultEventRepository) load() error {
+	var events domain.EventList
+	if er.Cfg.Events.EventFile == "" {
+		return nil
+	}
+	data, err := os.ReadFile(er.Cfg.Events.EventFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, &events); err != nil {
+		return err
+	}
+	eventList.Lock()
+	defer eventList.Unlock()
+	eventList.Events = events
+	er.trim()
+	return nil
+}
+
+// scheduleSave writes the event file after a short delay unless a write is already scheduled
+func (er DefaultEventRepository) scheduleSave() {
+	eventSave.Lock()
+	defer eventSave.Unlock()
+	if eventSave.pending != nil {
+		return
+	}
+	eventSave.pending = time.AfterFunc(eventSaveDelay, func() {
+		eventSave.Lock()
+		eventSave.pending = nil
+		eventSave.Unlock()
+		if err := er.save(); err != nil {
+			logger.Error("Could not write event file", err)
+		}
+	})
+}
+
+// save writes all events to the event file
+func (er DefaultEventRepository) save() error {
+	if er.Cfg.Events.EventFile == "" {
+		return nil
+	}
+	// holding the save lock while taking the snapshot keeps concurrent writes in order
+	eventSave.Lock()
+	defer eventSave.Unlock()
+	eventList.RLock()
+	data, err := json.Marshal(eventList.Events)
+	eventList.RUnlock()
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(er.Cfg.Events.EventFile, data)
+}
@@ -0,0 +1,82 @@
+package repositories
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/johannes-kuhfuss/alighieri/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	eventRepo DefaultEventRepository
+)
+
+func setupEventTest(t *testing.T) {
+	cfg.Events.EventFile = filepath.Join(t.TempDir(), "events.json")
+	cfg.Events.MaxEvents = 3
+	eventRepo = NewEventRepository(&cfg)
+}
+
+func TestAddEventWithoutDeviceReturnsError(t *testing.T) {
+	setupEventTest(t)
+	err := eventRepo.Add(domain.Event{Type: domain.EventNewDevice})
+
+	assert.NotNil(t, err)
+	assert.EqualValues(t, "cannot add event without device", err.Error())
+}
+
+func TestAddEventDropsOldestEvents(t *testing.T) {
+	setupEventTest(t)
+	for i := 1; i <= 5; i++ {
+		eventRepo.Add(domain.Event{Date: time.Unix(int64(i), 0), Type: domain.EventNewDevice, Device: "device"})
+	}
+	events := eventRepo.Find("", "")
+
+	assert.EqualValues(t, 3, eventRepo.Size())
+	assert.EqualValues(t, int64(5), (*events)[0].Date.Unix())
+	assert.EqualValues(t, int64(3), (*events)[2].Date.Unix())
+}
+
+func TestAddEventWritesFileAndReloads(t *testing.T) {
+	setupEventTest(t)
+	eventRepo.Add(domain.Event{Type: domain.EventIpChanged, Device: "device", OldValue: "192.168.1.10", NewValue: "192.168.1.11"})
+	eventRepo.Close()
+	eventRepo = NewEventRepository(&cfg)
+	events := eventRepo.Find("device", domain.EventIpChanged)
+
+	assert.NotNil(t, events)
+	assert.EqualValues(t, "192.168.1.11", (*events)[0].NewValue)
+}
+
+func TestFindEventsFiltersByDeviceAndType(t *testing.T) {
+	setupEventTest(t)
+	eventRepo.Add(domain.Event{Type: domain.EventNewDevice, Device: "mixer"})
+	eventRepo.Add(domain.Event{Type: domain.EventNewDevice, Device: "stagebox"})
+	eventRepo.Add(domain.Event{Type: domain.EventOffline, Device: "stagebox"})
+
+	assert.EqualValues(t, 2, len(*eventRepo.Find("stagebox", "")))
+	assert.EqualValues(t, 2, len(*eventRepo.Find("", domain.EventNewDevice)))
+	assert.EqualValues(t, 1, len(*eventRepo.Find("stagebox", domain.EventOffline)))
+	assert.Nil(t, eventRepo.Find("mixer", domain.EventOffline))
+}
+
+func TestAddEventBatchesWrites(t *testing.T) {
+	setupEventTest(t)
+	eventSaveDelay = 50 * time.Millisecond
+	defer func() {
+		eventSaveDelay = time.Second
+	}()
+	eventRepo.Add(domain.Event{Type: domain.EventNewDevice, Device: "mixer"})
+	eventRepo.Add(domain.Event{Type: domain.EventNewDevice, Device: "stagebox"})
+	_, errBefore := os.Stat(cfg.Events.EventFile)
+
+	assert.True(t, os.IsNotExist(errBefore))
+	assert.Eventually(t, func() bool {
+		data, err := os.ReadFile(cfg.Events.EventFile)
+		return err == nil && strings.Contains(string(data), "stagebox")
+	}, time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,160 @@
+// Package repositories implements an in-memory store for representing the data of the files scanned
+package repositories
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/johannes-kuhfuss/alighieri/config"
+	"github.com/johannes-kuhfuss/alighieri/domain"
+	"github.com/johannes-kuhfuss/services_utils/logger"
+)
+
+type PresetRepository interface {
+	Exists(string) bool
+	GetByName(string) *domain.Preset
+	GetAll() *domain.PresetList
+	Store(domain.Preset) error
+	Delete(string) error
+}
+
+type DefaultPresetRepository struct {
+	Cfg *config.AppConfig
+}
+
+var (
+	presetList domain.SafePresetList
+)
+
+// NewPresetRepository creates a new preset repository and loads the presets from the preset file. You need to pass in the configuration
+func NewPresetRepository(cfg *config.AppConfig) DefaultPresetRepository {
+	presetList.Presets = make(map[string]domain.Preset)
+	pr := DefaultPresetRepository{
+		Cfg: cfg,
+	}
+	if err := pr.load(); err != nil {
+		logger.Error("Could not load presets", err)
+	}
+	return pr
+}
+
+// Exists checks whether a preset identified by its name exists in the repository
+func (pr DefaultPresetRepository) Exists(name string) bool {
+	presetList.RLock()
+	defer presetList.RUnlock()
+	_, ok := presetList.Presets[name]
+	return ok
+}
+
+// GetByName returns a preset identified by its name. If no preset matches, the method returns nil
+func (pr DefaultPresetRepository) GetByName(name string) *domain.Preset {
+	presetList.RLock()
+	defer presetList.RUnlock()
+	if p, ok := presetList.Presets[name]; ok {
+		return &p
+	}
+	return nil
+}
+
+// GetAll returns all presets sorted by name. Returns nil if repository is empty
+func (pr DefaultPresetRepository) GetAll() *domain.PresetList {
+	var list domain.PresetList
+	presetList.RLock()
+	defer presetList.RUnlock()
+	if len(presetList.Presets) == 0 {
+		return nil
+	}
+	for _, preset := range presetList.Presets {
+		list = append(list, preset)
+	}
+	sort.SliceStable(list, func(i, j int) bool {
+		return list[i].Name < list[j].Name
+	})
+	return &list
+}
+
+// Store stores a preset into the repository, replacing a preset with the same name, and writes all presets to the preset file
+func (pr DefaultPresetRepository) Store(p domain.Preset) error {
+	if p.Name == "" {
+		return errors.New("cannot add preset with empty name to list")
+	}
+	presetList.Lock()
+	defer presetList.Unlock()
+	presetList.Presets[p.Name] = p
+	return pr.save()
+}
+
+// Delete removes a preset from the repository, if it exists, and writes all presets to the preset file
+func (pr DefaultPresetRepository) Delete(name string) error {
+	presetList.Lock()
+	defer presetList.Unlock()
+	if _, ok := presetList.Presets[name]; !ok {
+		return fmt.Errorf("preset with name %v does not exist", name)
+	}
+	delete(presetList.Presets, name)
+	return pr.save()
+}
+
+// load reads the presets from the preset file. A missing file is not an error
+func (pr DefaultPresetRepository) load() error {
+	var presets domain.PresetList
+	if pr.Cfg.Routing.PresetFile == "" {
+		return nil
+	}
+	data, err := os.ReadFile(pr.Cfg.Routing.PresetFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, &presets); err != nil {
+		return err
+	}
+	presetList.Lock()
+	defer presetList.Unlock()
+	for _, preset := range presets {
+		presetList.Presets[preset.Name] = preset
+	}
+	return nil
+}
+
+// save writes all presets to the preset file. Needs to be called with the lock held
+func (pr DefaultPresetRepository) save() error {
+	var presets domain.PresetList
+	if pr.Cfg.Routing.PresetFile == "" {
+		return nil
+	}
+	for _, preset := range presetList.Presets {
+		presets = append(presets, preset)
+	}
+	sort.SliceStable(presets, func(i, j int) bool {
+		return presets[i].Name < presets[j].Name
+	})
+	data, err := json.MarshalIndent(presets, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(pr.Cfg.Routing.PresetFile, data)
+}
+
+// writeFileAtomic writes data to a temporary file next to the target and renames it, so readers never see a partially written file
+func writeFileAtomic(fileName string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(fileName), filepath.Base(fileName)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), fileName)
+}
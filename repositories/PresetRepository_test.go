@@ -0,0 +1,68 @@
+package repositories
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/johannes-kuhfuss/alighieri/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	presetRepo DefaultPresetRepository
+)
+
+func setupPresetTest(t *testing.T) {
+	cfg.Routing.PresetFile = filepath.Join(t.TempDir(), "presets.json")
+	presetRepo = NewPresetRepository(&cfg)
+}
+
+func TestNewPresetRepositoryNoFileCreatesEmptyList(t *testing.T) {
+	setupPresetTest(t)
+	assert.Nil(t, presetRepo.GetAll())
+}
+
+func TestStorePresetWithEmptyNameReturnsError(t *testing.T) {
+	setupPresetTest(t)
+	err := presetRepo.Store(domain.Preset{})
+
+	assert.NotNil(t, err)
+	assert.EqualValues(t, "cannot add preset with empty name to list", err.Error())
+}
+
+func TestStorePresetWritesFileAndReloads(t *testing.T) {
+	setupPresetTest(t)
+	err := presetRepo.Store(domain.Preset{
+		Name:          "evening",
+		Created:       time.Date(2024, 9, 17, 18, 0, 0, 0, time.UTC),
+		Subscriptions: []domain.PresetSubscription{{RxDevice: "stagebox", RxChannel: 1, TxDevice: "mixer", TxChannel: "Out 1"}},
+	})
+	_, statErr := os.Stat(cfg.Routing.PresetFile)
+	presetRepo = NewPresetRepository(&cfg)
+	res := presetRepo.GetByName("evening")
+
+	assert.Nil(t, err)
+	assert.Nil(t, statErr)
+	assert.NotNil(t, res)
+	assert.EqualValues(t, "Out 1", res.Subscriptions[0].TxChannel)
+}
+
+func TestDeletePresetRemovesPreset(t *testing.T) {
+	setupPresetTest(t)
+	presetRepo.Store(domain.Preset{Name: "evening"})
+	err := presetRepo.Delete("evening")
+	presetRepo = NewPresetRepository(&cfg)
+
+	assert.Nil(t, err)
+	assert.False(t, presetRepo.Exists("evening"))
+}
+
+func TestDeleteNonExistingPresetReturnsError(t *testing.T) {
+	setupPresetTest(t)
+	err := presetRepo.Delete("evening")
+
+	assert.NotNil(t, err)
+	assert.EqualValues(t, "preset with name evening does not exist", err.Error())
+}
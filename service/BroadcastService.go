@@ -0,0 +1,81 @@
+// package service implements the services and their business logic that provide the main part of the program
+package service
+
+import (
+	"sync"
+
+	"github.com/johannes-kuhfuss/alighieri/config"
+	"github.com/johannes-kuhfuss/alighieri/domain"
+	"github.com/johannes-kuhfuss/services_utils/logger"
+)
+
+type BroadcastService interface {
+	Publish(domain.Notification)
+	Subscribe() (<-chan domain.Notification, func())
+	DeviceChanged(string, string, bool, bool)
+}
+
+// The BroadcastService distributes notifications about changed devices and scan status to all subscribers, e.g. live pages
+type DefaultBroadcastService struct {
+	Cfg *config.AppConfig
+	hub *broadcastHub
+}
+
+type broadcastHub struct {
+	sync.Mutex
+	subscribers map[chan domain.Notification]bool
+}
+
+// number of notifications buffered per subscriber before notifications to a slow subscriber are dropped
+const subscriberBuffer = 64
+
+// NewBroadcastService creates a new broadcast service and injects its dependencies
+func NewBroadcastService(cfg *config.AppConfig) DefaultBroadcastService {
+	return DefaultBroadcastService{
+		Cfg: cfg,
+		hub: &broadcastHub{
+			subscribers: make(map[chan domain.Notification]bool),
+		},
+	}
+}
+
+// Publish sends a notification to all subscribers without blocking. Subscribers not keeping up miss notifications
+func (s DefaultBroadcastService) Publish(n domain.Notification) {
+	s.hub.Lock()
+	defer s.hub.Unlock()
+	for ch := range s.hub.subscribers {
+		select {
+		case ch <- n:
+		default:
+			logger.Warnf("Subscriber too slow, dropping notification %v for device %v", n.Type, n.Device)
+		}
+	}
+}
+
+// Subscribe registers a new subscriber. The returned function unsubscribes and closes the channel
+func (s DefaultBroadcastService) Subscribe() (<-chan domain.Notification, func()) {
+	ch := make(chan domain.Notification, subscriberBuffer)
+	s.hub.Lock()
+	defer s.hub.Unlock()
+	s.hub.subscribers[ch] = true
+	return ch, func() {
+		s.hub.Lock()
+		defer s.hub.Unlock()
+		if s.hub.subscribers[ch] {
+			delete(s.hub.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+// DeviceChanged is called by the device repository whenever a device has been stored or removed
+func (s DefaultBroadcastService) DeviceChanged(key string, name string, added bool, removed bool) {
+	n := domain.Notification{Type: domain.NotificationDeviceChanged, Device: name, Key: key}
+	switch {
+	case removed:
+		n.Type = domain.NotificationDeviceRemoved
+	case added:
+		n.Type = domain.NotificationDeviceAdded
+	}
+	s.Publish(n)
+}
@@ -0,0 +1,52 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/johannes-kuhfuss/alighieri/config"
+	"github.com/johannes-kuhfuss/alighieri/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPublishSendsToAllSubscribers(t *testing.T) {
+	var cfg config.AppConfig
+	bs := NewBroadcastService(&cfg)
+	first, unsubscribeFirst := bs.Subscribe()
+	defer unsubscribeFirst()
+	second, unsubscribeSecond := bs.Subscribe()
+	bs.Publish(domain.Notification{Type: domain.NotificationScanStatus})
+
+	assert.EqualValues(t, domain.NotificationScanStatus, (<-first).Type)
+	assert.EqualValues(t, domain.NotificationScanStatus, (<-second).Type)
+
+	unsubscribeSecond()
+	_, ok := <-second
+	assert.False(t, ok)
+	unsubscribeSecond()
+}
+
+func TestPublishSlowSubscriberDoesNotBlock(t *testing.T) {
+	var cfg config.AppConfig
+	bs := NewBroadcastService(&cfg)
+	ch, unsubscribe := bs.Subscribe()
+	defer unsubscribe()
+	for i := 0; i < subscriberBuffer+10; i++ {
+		bs.Publish(domain.Notification{Type: domain.NotificationScanStatus})
+	}
+
+	assert.EqualValues(t, subscriberBuffer, len(ch))
+}
+
+func TestDeviceChangedPublishesType(t *testing.T) {
+	var cfg config.AppConfig
+	bs := NewBroadcastService(&cfg)
+	ch, unsubscribe := bs.Subscribe()
+	defer unsubscribe()
+	bs.DeviceChanged("001dc1fffe000001", "stagebox", true, false)
+	bs.DeviceChanged("001dc1fffe000001", "stagebox", false, false)
+	bs.DeviceChanged("001dc1fffe000001", "stagebox", false, true)
+
+	assert.EqualValues(t, domain.Notification{Type: domain.NotificationDeviceAdded, Device: "stagebox", Key: "001dc1fffe000001"}, <-ch)
+	assert.EqualValues(t, domain.NotificationDeviceChanged, (<-ch).Type)
+	assert.EqualValues(t, domain.NotificationDeviceRemoved, (<-ch).Type)
+}
@@ -0,0 +1,172 @@
+// package service implements the services and their business logic that provide the main part of the program
+package service
+
+import (
+	"fmt"
+	"net"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/johannes-kuhfuss/alighieri/config"
+	"github.com/johannes-kuhfuss/alighieri/domain"
+	"github.com/johannes-kuhfuss/alighieri/repositories"
+	"github.com/johannes-kuhfuss/services_utils/logger"
+)
+
+type ConflictService interface {
+	Analyze() domain.ConflictReport
+	Report() domain.ConflictReport
+}
+
+// The ConflictService looks for devices getting in each other's way after every scan: shared names and addresses, link-local addresses and addresses outside the scanned subnets
+type DefaultConflictService struct {
+	Cfg  *config.AppConfig
+	Repo *repositories.DefaultDeviceRepository
+	last *domain.SafeConflictReport
+}
+
+// NewConflictService creates a new conflict service and injects its dependencies
+func NewConflictService(cfg *config.AppConfig, repo *repositories.DefaultDeviceRepository) DefaultConflictService {
+	return DefaultConflictService{
+		Cfg:  cfg,
+		Repo: repo,
+		last: &domain.SafeConflictReport{},
+	}
+}
+
+// Analyze checks all devices not offline for conflicts and keeps the result as the last report
+func (s DefaultConflictService) Analyze() domain.ConflictReport {
+	now := time.Now().UTC()
+	staleAfter, offlineAfter := config.DeviceStateThresholds(s.Cfg)
+	var devices []domain.DeviceInfo
+	if all := s.Repo.GetAll(); all != nil {
+		for _, device := range *all {
+			if device.State(now, staleAfter, offlineAfter) != domain.DeviceOffline {
+				devices = append(devices, device)
+			}
+		}
+	}
+	s.Cfg.RunTime.Mu.Lock()
+	subnets := make(map[string]*net.IPNet)
+	for _, iface := range s.Cfg.RunTime.DeviceScanInterfaces {
+		subnets[iface.Name] = interfaceSubnet(iface)
+	}
+	s.Cfg.RunTime.Mu.Unlock()
+	report := findConflicts(devices, subnets)
+	report.Date = now
+	if len(report.Conflicts) > 0 {
+		logger.Warnf("Found %v conflict(s) between %v device(s)", len(report.Conflicts), report.Checked)
+	}
+	s.last.Lock()
+	s.last.Report = report
+	s.last.Unlock()
+	return report
+}
+
+// Report returns the result of the last analysis
+func (s DefaultConflictService) Report() domain.ConflictReport {
+	s.last.RLock()
+	defer s.last.RUnlock()
+	return s.last.Report
+}
+
+// findConflicts compares the devices with each other and with the subnets of the interfaces they answered on
+func findConflicts(devices []domain.DeviceInfo, subnets map[string]*net.IPNet) (report domain.ConflictReport) {
+	sort.SliceStable(devices, func(i, j int) bool {
+		if devices[i].Name != devices[j].Name {
+			return devices[i].Name < devices[j].Name
+		}
+		return devices[i].Key() < devices[j].Key()
+	})
+	report.Checked = len(devices)
+	add := func(conflictType domain.ConflictType, names []string, format string, a ...any) {
+		report.Conflicts = append(report.Conflicts, domain.Conflict{Type: conflictType, Devices: names, Detail: fmt.Sprintf(format, a...)})
+	}
+
+	byName := make(map[string][]domain.DeviceInfo)
+	var names []string
+	byIp := make(map[string][]string)
+	var ips []string
+	routable := 0
+	for _, device := range devices {
+		if len(byName[device.Name]) == 0 {
+			names = append(names, device.Name)
+		}
+		byName[device.Name] = append(byName[device.Name], device)
+		addrs := deviceIPv4s(device)
+		for _, ip := range addrs {
+			if len(byIp[ip.String()]) == 0 {
+				ips = append(ips, ip.String())
+			}
+			byIp[ip.String()] = append(byIp[ip.String()], device.Name)
+		}
+		if slices.ContainsFunc(addrs, func(ip net.IP) bool { return !ip.IsLinkLocalUnicast() }) {
+			routable++
+		}
+	}
+
+	for _, name := range names {
+		if same := byName[name]; len(same) > 1 {
+			var ids []string
+			for _, device := range same {
+				ids = append(ids, describeIdentity(device))
+			}
+			add(domain.ConflictDuplicateName, []string{name}, "%v devices are named %v (%v)", len(same), name, strings.Join(ids, ", "))
+		}
+	}
+	slices.SortFunc(ips, func(a, b string) int {
+		return slices.Compare(net.ParseIP(a).To4(), net.ParseIP(b).To4())
+	})
+	for _, ip := range ips {
+		if users := byIp[ip]; len(users) > 1 {
+			add(domain.ConflictDuplicateIp, users, "%v is used by %v", ip, strings.Join(users, ", "))
+		}
+	}
+	for _, device := range devices {
+		addrs := deviceIPv4s(device)
+		others := routable
+		if slices.ContainsFunc(addrs, func(ip net.IP) bool { return !ip.IsLinkLocalUnicast() }) {
+			others--
+		}
+		for _, ip := range addrs {
+			if ip.IsLinkLocalUnicast() && others > 0 {
+				add(domain.ConflictLinkLocal, []string{device.Name}, "%v uses the link-local address %v while %v other device(s) use DHCP or static addresses", device.Name, ip, others)
+			}
+		}
+		for _, sighting := range device.Sightings {
+			subnet := subnets[sighting.Interface]
+			if subnet == nil || sighting.IPv4 == nil || sighting.IPv4.IsLinkLocalUnicast() || subnet.Contains(sighting.IPv4) {
+				continue
+			}
+			add(domain.ConflictOutsideSubnet, []string{device.Name}, "%v answers on %v with %v, outside of %v", device.Name, sighting.Interface, sighting.IPv4, subnet)
+		}
+	}
+	return
+}
+
+// deviceIPv4s returns the distinct IPv4 addresses of a device, the address on the primary network first
+func deviceIPv4s(device domain.DeviceInfo) (addrs []net.IP) {
+	add := func(ip net.IP) {
+		if ip = ip.To4(); ip != nil && !slices.ContainsFunc(addrs, ip.Equal) {
+			addrs = append(addrs, ip)
+		}
+	}
+	add(device.IPv4)
+	for _, sighting := range device.Sightings {
+		add(sighting.IPv4)
+	}
+	return
+}
+
+// describeIdentity names what tells devices with the same name apart: their id, or their address if they announce no id
+func describeIdentity(device domain.DeviceInfo) string {
+	if device.Id != "" {
+		return "id " + device.Id
+	}
+	if device.IPv4 != nil {
+		return "address " + device.IPv4.String()
+	}
+	return "no id"
+}
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/johannes-kuhfuss/alighieri/config"
+	"github.com/johannes-kuhfuss/alighieri/domain"
+	"github.com/johannes-kuhfuss/alighieri/repositories"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindConflictsWithoutConflictsReturnsEmptyReport(t *testing.T) {
+	devices := []domain.DeviceInfo{
+		{Name: "stagebox", IPv4: net.ParseIP("192.168.1.10")},
+		{Name: "amp", IPv4: net.ParseIP("192.168.1.11")},
+	}
+
+	report := findConflicts(devices, nil)
+
+	assert.EqualValues(t, 2, report.Checked)
+	assert.Empty(t, report.Conflicts)
+}
+
+func TestFindConflictsReportsDuplicateNames(t *testing.T) {
+	devices := []domain.DeviceInfo{
+		{Name: "stagebox", Id: "001dc1fffe000002", IPv4: net.ParseIP("192.168.1.11")},
+		{Name: "stagebox", Id: "001dc1fffe000001", IPv4: net.ParseIP("192.168.1.10")},
+	}
+
+	report := findConflicts(devices, nil)
+
+	assert.EqualValues(t, 1, len(report.Conflicts))
+	assert.EqualValues(t, domain.ConflictDuplicateName, report.Conflicts[0].Type)
+	assert.EqualValues(t, "2 devices are named stagebox (id 001dc1fffe000001, id 001dc1fffe000002)", report.Conflicts[0].Detail)
+}
+
+func TestFindConflictsReportsDuplicateIpAddresses(t *testing.T) {
+	devices := []domain.DeviceInfo{
+		{Name: "stagebox", IPv4: net.ParseIP("192.168.1.10")},
+		{Name: "amp", IPv4: net.ParseIP("192.168.1.20"), Sightings: []domain.Sighting{{Interface: "eth1", IPv4: net.ParseIP("192.168.1.10")}}},
+		{Name: "mixer", IPv4: net.ParseIP("192.168.1.30")},
+	}
+
+	report := findConflicts(devices, nil)
+
+	assert.EqualValues(t, 1, len(report.Conflicts))
+	assert.EqualValues(t, domain.ConflictDuplicateIp, report.Conflicts[0].Type)
+	assert.EqualValues(t, []string{"amp", "stagebox"}, report.Conflicts[0].Devices)
+	assert.EqualValues(t, "192.168.1.10 is used by amp, stagebox", report.Conflicts[0].Detail)
+}
+
+func TestFindConflictsReportsLinkLocalAmongRoutableDevices(t *testing.T) {
+	devices := []domain.DeviceInfo{
+		{Name: "stagebox", IPv4: net.ParseIP("169.254.10.20")},
+		{Name: "amp", IPv4: net.ParseIP("192.168.1.11")},
+	}
+
+	report := findConflicts(devices, nil)
+
+	assert.EqualValues(t, 1, len(report.Conflicts))
+	assert.EqualValues(t, domain.ConflictLinkLocal, report.Conflicts[0].Type)
+	assert.EqualValues(t, "stagebox uses the link-local address 169.254.10.20 while 1 other device(s) use DHCP or static addresses", report.Conflicts[0].Detail)
+}
+
+func TestFindConflictsAllowsNetworkOfLinkLocalDevices(t *testing.T) {
+	devices := []domain.DeviceInfo{
+		{Name: "stagebox", IPv4: net.ParseIP("169.254.10.20")},
+		{Name: "amp", IPv4: net.ParseIP("169.254.10.21")},
+	}
+
+	report := findConflicts(devices, nil)
+
+	assert.Empty(t, report.Conflicts)
+}
+
+func TestFindConflictsReportsAddressOutsideSubnet(t *testing.T) {
+	devices := []domain.DeviceInfo{
+		{Name: "stagebox", IPv4: net.ParseIP("10.0.0.5"), Sightings: []domain.Sighting{{Interface: "eth0", IPv4: net.ParseIP("10.0.0.5")}}},
+		{Name: "amp", IPv4: net.ParseIP("192.168.1.11"), Sightings: []domain.Sighting{{Interface: "eth0", IPv4: net.ParseIP("192.168.1.11")}}},
+	}
+
+	report := findConflicts(devices, map[string]*net.IPNet{"eth0": mustParseSubnet("192.168.1.0/24")})
+
+	assert.EqualValues(t, 1, len(report.Conflicts))
+	assert.EqualValues(t, domain.ConflictOutsideSubnet, report.Conflicts[0].Type)
+	assert.EqualValues(t, "stagebox answers on eth0 with 10.0.0.5, outside of 192.168.1.0/24", report.Conflicts[0].Detail)
+}
+
+func TestAnalyzeKeepsReportAndSkipsOfflineDevices(t *testing.T) {
+	var testCfg config.AppConfig
+	config.InitConfig("", &testCfg)
+	repo := repositories.NewDeviceRepository(&testCfg)
+	svc := NewConflictService(&testCfg, &repo)
+	repo.Store(domain.DeviceInfo{Name: "stagebox", IPv4: net.ParseIP("192.168.1.10"), LastSeen: time.Now()})
+	repo.Store(domain.DeviceInfo{Name: "amp", IPv4: net.ParseIP("192.168.1.10"), LastSeen: time.Now().Add(-24 * time.Hour)})
+
+	report := svc.Analyze()
+
+	assert.EqualValues(t, 1, report.Checked)
+	assert.Empty(t, report.Conflicts)
+	assert.False(t, svc.Report().Date.IsZero())
+}
@@ -2,136 +2,430 @@
 package service
 
 import (
+	"fmt"
+	"maps"
 	"net"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/johannes-kuhfuss/alighieri/arc"
 	"github.com/johannes-kuhfuss/alighieri/config"
 	"github.com/johannes-kuhfuss/alighieri/domain"
 	"github.com/johannes-kuhfuss/alighieri/repositories"
 	"github.com/johannes-kuhfuss/mdns"
 	"github.com/johannes-kuhfuss/services_utils/logger"
+	"github.com/miekg/dns"
 	defaultroute "github.com/nixigaj/go-default-route"
+	"golang.org/x/net/ipv4"
 )
 
+var (
+	mdnsGroup = &net.UDPAddr{IP: net.IPv4(224, 0, 0, 251), Port: 5353}
+)
+
+// number of scan durations kept until the metrics pick them up
+const maxPendingDurations = 100
+
 type DeviceScanService interface {
 	Scan()
 	ScanRun() error
+	TriggerScan() bool
 }
 
 // The DeviceScan service scans for available audio devices
 type DefaultDeviceScanService struct {
-	Cfg  *config.AppConfig
-	Repo *repositories.DefaultDeviceRepository
+	Cfg       *config.AppConfig
+	Repo      *repositories.DefaultDeviceRepository
+	Events    EventService
+	Broadcast BroadcastService
+	Conflicts ConflictService
+	trigger   chan bool
+}
+
+// interfaceEntries holds the entries answered on one network interface
+type interfaceEntries struct {
+	iface   *net.Interface
+	entries []*mdns.ServiceEntry
+	err     error
 }
 
-func selectNetworkInterface(cfg *config.AppConfig) {
-	logger.Info("Determining network interface...")
-	if cfg.DeviceScan.InterfaceName != "" {
-		logger.Infof("Trying to find interface with name %v", cfg.DeviceScan.InterfaceName)
-		iface, err := net.InterfaceByName(cfg.DeviceScan.InterfaceName)
+// selectNetworkInterfaces looks up the configured network interfaces, keeping their order. Without a usable configured interface the default route's interface is used
+func selectNetworkInterfaces(cfg *config.AppConfig) {
+	logger.Info("Determining network interfaces...")
+	cfg.RunTime.DeviceScanInterfaces = nil
+	for _, name := range cfg.DeviceScan.InterfaceNames {
+		logger.Infof("Trying to find interface with name %v", name)
+		iface, err := net.InterfaceByName(name)
 		if err != nil {
-			logger.Errorf("Could not find interface with name %v. Using default interface.", cfg.DeviceScan.InterfaceName)
-		} else {
-			logger.Infof("Found interface with name %v", cfg.DeviceScan.InterfaceName)
-			cfg.RunTime.DeviceScanInterface = iface
+			logger.Errorf("Could not find interface with name %v.", name)
+			continue
+		}
+		logger.Infof("Found interface with name %v", name)
+		cfg.RunTime.DeviceScanInterfaces = append(cfg.RunTime.DeviceScanInterfaces, iface)
+	}
+	if len(cfg.RunTime.DeviceScanInterfaces) == 0 {
+		if len(cfg.DeviceScan.InterfaceNames) > 0 {
+			logger.Warn("None of the configured interfaces found. Using default interface.")
+		}
+		defIface, err := defaultroute.DefaultRouteInterface()
+		if err != nil {
+			logger.Error("Could not find default interface. Giving up...", err)
 			return
 		}
+		cfg.RunTime.DeviceScanInterfaces = []*net.Interface{defIface}
 	}
-	defIface, err := defaultroute.DefaultRouteInterface()
-	if err != nil {
-		logger.Error("Could not find default interface. Giving up...", err)
-	} else {
-		cfg.RunTime.DeviceScanInterface = defIface
+	logger.Infof("Using network interface(s) %v", interfaceNames(cfg.RunTime.DeviceScanInterfaces))
+}
+
+// interfaceNames returns the names of network interfaces separated by commas
+func interfaceNames(ifaces []*net.Interface) string {
+	var names []string
+	for _, iface := range ifaces {
+		names = append(names, iface.Name)
 	}
-	logger.Infof("Using network interface %v", cfg.RunTime.DeviceScanInterface.Name)
+	return strings.Join(names, ", ")
 }
 
 // NewDeviceScanService creates a new device scan service and injects its dependencies
-func NewDeviceScanService(cfg *config.AppConfig, repo *repositories.DefaultDeviceRepository) DefaultDeviceScanService {
-	selectNetworkInterface(cfg)
+func NewDeviceScanService(cfg *config.AppConfig, repo *repositories.DefaultDeviceRepository, events EventService, broadcast BroadcastService, conflicts ConflictService) DefaultDeviceScanService {
+	selectNetworkInterfaces(cfg)
 	return DefaultDeviceScanService{
-		Cfg:  cfg,
-		Repo: repo,
+		Cfg:       cfg,
+		Repo:      repo,
+		Events:    events,
+		Broadcast: broadcast,
+		Conflicts: conflicts,
+		trigger:   make(chan bool, 1),
 	}
 }
 
 func (s DefaultDeviceScanService) Scan() {
 	for s.Cfg.DeviceScan.DeviceScanRun {
 		s.ScanRun()
-		time.Sleep(time.Duration(s.Cfg.DeviceScan.ScanCycleSec) * time.Second)
+		pause := time.Duration(s.Cfg.DeviceScan.ScanCycleSec) * time.Second
+		if s.Cfg.DeviceScan.Goodbyes {
+			s.listenForGoodbyes(pause)
+		} else {
+			s.waitForTrigger(pause)
+		}
+	}
+}
+
+// TriggerScan ends the pause between two scans, so the next scan starts right away. Returns false if a scan has already been requested
+func (s DefaultDeviceScanService) TriggerScan() bool {
+	select {
+	case s.trigger <- true:
+		return true
+	default:
+		return false
 	}
 }
 
 // Scan orchestrates the process of querying audio devices and adding the retrieved information to the device repository
 func (s DefaultDeviceScanService) ScanRun() error {
+	s.Cfg.RunTime.Mu.Lock()
 	s.Cfg.RunTime.DeviceScanNumber++
 	s.Cfg.RunTime.LastDeviceScanDate = time.Now()
 	s.Cfg.RunTime.DeviceScanRunning = true
-	logger.Infof("Starting device scan run #%v on network interface %v.", s.Cfg.RunTime.DeviceScanNumber, s.Cfg.RunTime.DeviceScanInterface.Name)
+	s.Cfg.RunTime.Mu.Unlock()
+	s.publishStatus()
+	logger.Infof("Starting device scan run #%v on network interface(s) %v.", s.Cfg.RunTime.DeviceScanNumber, interfaceNames(s.Cfg.RunTime.DeviceScanInterfaces))
 	start := time.Now().UTC()
 	deviceCount, err := s.scanDevices()
 	if err != nil {
 		logger.Errorf("Error while scanning for audio devices: %v", err)
 	}
+	s.markOffline(time.Now())
+	if purged := s.purgeDevices(time.Now()); purged > 0 {
+		logger.Infof("Removed %v device(s) not seen for %v hour(s)", purged, s.Cfg.DeviceScan.RetentionHours)
+	}
+	if s.Conflicts != nil {
+		s.Conflicts.Analyze()
+	}
 	deviceListCount := s.Repo.Size()
 	end := time.Now().UTC()
 	dur := end.Sub(start)
 	logger.Infof("Finished device scan run #%v. Found %v devices. %v device(s) in list total. (%v)", s.Cfg.RunTime.DeviceScanNumber, deviceCount, deviceListCount, dur.String())
 	s.Cfg.RunTime.Mu.Lock()
-	defer s.Cfg.RunTime.Mu.Unlock()
 	s.Cfg.RunTime.DevicesInList = deviceListCount
 	s.Cfg.RunTime.DeviceScanRunning = false
+	if len(s.Cfg.RunTime.ScanDurations) < maxPendingDurations {
+		s.Cfg.RunTime.ScanDurations = append(s.Cfg.RunTime.ScanDurations, dur)
+	}
+	s.Cfg.RunTime.Mu.Unlock()
+	s.publishStatus()
 	return nil
 }
 
+// countEntries adds the number of mDNS entries received for a service type to the runtime statistics
+func (s DefaultDeviceScanService) countEntries(service string, count int) {
+	s.Cfg.RunTime.Mu.Lock()
+	defer s.Cfg.RunTime.Mu.Unlock()
+	if s.Cfg.RunTime.MdnsEntries == nil {
+		s.Cfg.RunTime.MdnsEntries = make(map[string]int)
+	}
+	s.Cfg.RunTime.MdnsEntries[service] += count
+}
+
+// countConversionError adds an mDNS entry that could not be converted to the runtime statistics
+func (s DefaultDeviceScanService) countConversionError() {
+	s.Cfg.RunTime.Mu.Lock()
+	defer s.Cfg.RunTime.Mu.Unlock()
+	s.Cfg.RunTime.ConversionErrors++
+}
+
+// publishStatus notifies live clients that the scan status changed
+func (s DefaultDeviceScanService) publishStatus() {
+	if s.Broadcast != nil {
+		s.Broadcast.Publish(domain.Notification{Type: domain.NotificationScanStatus})
+	}
+}
+
+// scanDevices queries all configured service types one after the other and merges the entries into one record per device.
+// Each device records the interfaces it answered on
 func (s DefaultDeviceScanService) scanDevices() (deviceCount int, err error) {
 	var (
-		numEntries int
+		queryErr error
+		queried  int
 	)
+	var records []domain.DeviceInfo
+	results := s.queryServices(s.Cfg.DeviceScan.ServiceNames)
+	for i, service := range s.Cfg.DeviceScan.ServiceNames {
+		answered := false
+		for _, result := range results[i] {
+			if result.err != nil {
+				logger.Errorf("Error while querying service %v on interface %v: %v", service, result.iface.Name, result.err)
+				queryErr = result.err
+				continue
+			}
+			answered = true
+			s.countEntries(service, len(result.entries))
+			for _, entry := range result.entries {
+				logger.Infof("Found device %v on interface %v\r\n", entry.Name, result.iface.Name)
+				device, err := convertEntry(*entry, service)
+				if err != nil {
+					logger.Error("Could not convert entry to device", err)
+					s.countConversionError()
+					continue
+				}
+				device.Sightings = []domain.Sighting{{Interface: result.iface.Name, IPv4: device.IPv4, Addresses: entryAddresses(*entry, result.iface.Name), LastSeen: device.LastSeen}}
+				if service == domain.ServiceChan {
+					channel, err := convertChannel(*entry)
+					if err != nil {
+						logger.Error("Could not convert entry to channel", err)
+						s.countConversionError()
+					} else {
+						device.TxChannels = domain.ChannelList{channel}
+					}
+				}
+				records = append(records, device)
+			}
+		}
+		if answered {
+			queried++
+		}
+	}
+	if queried == 0 && queryErr != nil {
+		return 0, queryErr
+	}
+	found := groupDevices(records)
+	if s.Cfg.DeviceScan.ArcQuery {
+		s.enrichDevices(found)
+	}
+	if s.Cfg.DeviceScan.SettingsQuery {
+		s.readSettings(found)
+	}
+	for _, device := range found {
+		s.storeDevice(device)
+	}
+	return len(found), nil
+}
+
+// enrichDevices queries all devices advertising an ARC port concurrently for their details
+func (s DefaultDeviceScanService) enrichDevices(found map[string]domain.DeviceInfo) {
+	updateConcurrently(found, func(device domain.DeviceInfo) bool {
+		return device.ArcPort != 0 && device.IPv4 != nil
+	}, func(name string, device *domain.DeviceInfo) bool {
+		client := newArcClient(device.IPv4, device.ArcPort, time.Duration(s.Cfg.DeviceScan.ArcTimeOutMs)*time.Millisecond)
+		if err := enrichDevice(client, device); err != nil {
+			logger.Warnf("Could not query details of device %v via ARC: %v", name, err)
+			return false
+		}
+		return true
+	})
+}
+
+// updateConcurrently runs update for all selected devices concurrently. The devices are copied before the updates start and
+// written back to found after all updates finished, keeping the map free of concurrent access. Devices whose update fails stay unchanged
+func updateConcurrently(found map[string]domain.DeviceInfo, selected func(domain.DeviceInfo) bool, update func(string, *domain.DeviceInfo) bool) {
+	type job struct {
+		name    string
+		device  domain.DeviceInfo
+		updated bool
+	}
+	var jobs []job
+	for name, device := range found {
+		if selected(device) {
+			jobs = append(jobs, job{name: name, device: device})
+		}
+	}
+	var wg sync.WaitGroup
+	for i := range jobs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			jobs[i].updated = update(jobs[i].name, &jobs[i].device)
+		}()
+	}
+	wg.Wait()
+	for _, j := range jobs {
+		if j.updated {
+			found[j.name] = j.device
+		}
+	}
+}
+
+// readSettings reads the settings of all devices with a known address concurrently
+func (s DefaultDeviceScanService) readSettings(found map[string]domain.DeviceInfo) {
+	updateConcurrently(found, func(device domain.DeviceInfo) bool {
+		return device.IPv4 != nil
+	}, func(name string, device *domain.DeviceInfo) bool {
+		settings, err := settingsClient(s.Cfg, device.IPv4).GetSettings()
+		if err != nil {
+			logger.Warnf("Could not read settings of device %v: %v", name, err)
+			return false
+		}
+		device.Settings = settings
+		return true
+	})
+}
+
+// enrichDevice adds the device name, channel counts, channel names and subscriptions retrieved via ARC to the device
+func enrichDevice(client arc.ArcClient, dev *domain.DeviceInfo) (err error) {
+	danteName, err := client.GetDeviceName()
+	if err != nil {
+		return err
+	}
+	txCount, rxCount, err := client.GetChannelCount()
+	if err != nil {
+		return err
+	}
+	txChannels, err := client.GetTxChannels(txCount)
+	if err != nil {
+		return err
+	}
+	rxChannels, err := client.GetRxChannels(rxCount)
+	if err != nil {
+		return err
+	}
+	dev.DanteName = danteName
+	dev.TxChannelCount = txCount
+	dev.RxChannelCount = rxCount
+	for _, channel := range txChannels {
+		channel.DeviceName = dev.Name
+		if existing := dev.TxChannels.GetByNumber(channel.Number); existing != nil {
+			// names from ARC are authoritative, format details from mDNS are more complete
+			existing.Name = channel.Name
+			if existing.SampleRate == 0 {
+				existing.SampleRate = channel.SampleRate
+			}
+		} else {
+			dev.TxChannels = mergeChannels(dev.TxChannels, domain.ChannelList{channel})
+		}
+	}
+	dev.RxChannels = nil
+	for _, channel := range rxChannels {
+		channel.DeviceName = dev.Name
+		dev.RxChannels = append(dev.RxChannels, channel)
+	}
+	return nil
+}
+
+// queryServices queries all service types on all network interfaces at the same time, so a scan takes a single time out.
+// Only a single service type on a single interface is queried with the mDNS client. Otherwise each query gets its own socket, as the mDNS client
+// needs port 5353 exclusively, always sends via the default route and does not tell the answers for different service types apart.
+// Returns the results in the order of the service types, each in the order of the interfaces
+func (s DefaultDeviceScanService) queryServices(services []string) [][]interfaceEntries {
+	timeout := time.Duration(s.Cfg.DeviceScan.ScanTimeOutSec) * time.Second
+	ifaces := s.Cfg.RunTime.DeviceScanInterfaces
+	results := make([][]interfaceEntries, len(services))
+	if len(services) == 1 && len(ifaces) == 1 {
+		entries, err := queryClient(ifaces[0], services[0], timeout)
+		results[0] = []interfaceEntries{{iface: ifaces[0], entries: entries, err: err}}
+		return results
+	}
+	var wg sync.WaitGroup
+	for i, service := range services {
+		results[i] = make([]interfaceEntries, len(ifaces))
+		for j, iface := range ifaces {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				entries, err := queryInterface(iface, mdnsGroup, service, timeout)
+				results[i][j] = interfaceEntries{iface: iface, entries: entries, err: err}
+			}()
+		}
+	}
+	wg.Wait()
+	return results
+}
+
+// queryClient runs a single mDNS query for the given service type with the mDNS client and collects all entries received until the time out
+func queryClient(iface *net.Interface, service string, timeout time.Duration) (entries []*mdns.ServiceEntry, err error) {
 	entriesCh := make(chan *mdns.ServiceEntry, 32)
+	done := make(chan bool)
 	go func() {
 		for entry := range entriesCh {
-			numEntries++
-			logger.Infof("Found device %v\r\n", entry.Name)
-			device, err := convertEntry(*entry)
-			if err != nil {
-				logger.Error("Could not convert entry to device", err)
-			} else {
-				s.storeDevice(device)
-			}
+			entries = append(entries, entry)
 		}
+		done <- true
 	}()
 
 	queryParams := &mdns.QueryParam{
-		Service:             s.Cfg.DeviceScan.ServiceName,
+		Service:             service,
 		Domain:              "local",
-		Timeout:             time.Duration(s.Cfg.DeviceScan.ScanTimeOutSec) * time.Second,
-		Interface:           s.Cfg.RunTime.DeviceScanInterface,
+		Timeout:             timeout,
+		Interface:           iface,
 		Entries:             entriesCh,
 		WantUnicastResponse: false,
 		DisableIPv4:         false,
 		DisableIPv6:         false,
 	}
 	err = mdns.Query(queryParams)
+	close(entriesCh)
+	<-done
 	if err != nil {
-		logger.Errorf("Error while querying audio devices: %v", err)
-		return 0, err
+		return nil, err
 	}
-	close(entriesCh)
-	return numEntries, nil
+	return entries, nil
 }
 
-func convertEntry(e mdns.ServiceEntry) (dev domain.DeviceInfo, err error) {
+// convertEntry converts an mDNS entry received for the given service type to a device
+func convertEntry(e mdns.ServiceEntry, service string) (dev domain.DeviceInfo, err error) {
 	d := domain.DeviceInfo{
 		IPv4:      e.AddrV4,
-		Port:      e.Port,
+		Services:  []string{service},
 		FirstSeen: time.Now(),
 		LastSeen:  time.Now(),
 	}
-	d.FullName = strings.TrimSuffix(e.Name, ".")
 	d.HostName = strings.TrimSuffix(e.Host, ".")
 	d.Name = shorten(e.Host)
+	switch service {
+	case domain.ServiceArc:
+		d.ArcPort = e.Port
+	case domain.ServiceCmc:
+		d.CmcPort = e.Port
+	case domain.ServiceDbc:
+		d.DbcPort = e.Port
+	case domain.ServiceChan:
+		// channel entries describe a single channel, not the device itself
+		return d, nil
+	}
+	d.FullName = strings.TrimSuffix(e.Name, ".")
 	for _, info := range e.InfoFields {
 		if info != "" {
 			kvp := strings.Split(info, "=")
@@ -162,6 +456,239 @@ func convertEntry(e mdns.ServiceEntry) (dev domain.DeviceInfo, err error) {
 	return d, nil
 }
 
+// convertChannel converts an entry of the channel service type to a transmit channel of the device advertising it
+func convertChannel(e mdns.ServiceEntry) (ch domain.ChannelInfo, err error) {
+	c := domain.ChannelInfo{
+		DeviceName: shorten(e.Host),
+		Direction:  domain.ChannelTx,
+	}
+	instance := strings.TrimSuffix(strings.TrimSuffix(e.Name, "."), "."+domain.ServiceChan+".local")
+	name, _, found := strings.Cut(instance, "@")
+	if !found || name == "" {
+		return c, fmt.Errorf("entry %v is not a channel entry", e.Name)
+	}
+	c.Name = name
+	for _, info := range e.InfoFields {
+		key, value, found := strings.Cut(info, "=")
+		if !found {
+			continue
+		}
+		switch strings.ToLower(key) {
+		case "id":
+			c.Number, err = strconv.Atoi(value)
+			if err != nil {
+				return c, fmt.Errorf("could not convert channel id %v of entry %v", value, e.Name)
+			}
+		case "rate":
+			c.SampleRate, _ = strconv.Atoi(value)
+		case "en", "enc":
+			c.Encoding, _ = strconv.Atoi(value)
+		case "latency_ns":
+			c.LatencyNs, _ = strconv.Atoi(value)
+		}
+	}
+	if c.Number == 0 {
+		return c, fmt.Errorf("entry %v carries no channel id", e.Name)
+	}
+	return c, nil
+}
+
+// groupDevices merges the records of one scan into one record per device. Records carrying the device id are grouped by it, so devices sharing a name
+// on different networks stay apart. Records without id, e.g. of the ARC and channel services, join the device they match by name, see matchByName
+func groupDevices(records []domain.DeviceInfo) map[string]domain.DeviceInfo {
+	found := make(map[string]domain.DeviceInfo)
+	join := func(key string, device domain.DeviceInfo) {
+		if known, ok := found[key]; ok {
+			device = mergeDevice(known, device)
+		}
+		found[key] = device
+	}
+	for _, record := range records {
+		if record.Id != "" {
+			join(record.Id, record)
+		}
+	}
+	for _, record := range records {
+		if record.Id != "" {
+			continue
+		}
+		candidates := slices.Collect(maps.Values(found))
+		if i := matchByName(candidates, record); i != -1 {
+			join(candidates[i].Key(), record)
+		} else {
+			join(record.Name, record)
+		}
+	}
+	return found
+}
+
+// matchByName returns the index of the device with an id a record without id belongs to. Among devices with the record's name,
+// the one answering on the same interface is preferred. Returns -1 if no device matches
+func matchByName(devices []domain.DeviceInfo, record domain.DeviceInfo) int {
+	match, matchOnIface := -1, false
+	for i, device := range devices {
+		if device.Id == "" || device.Name != record.Name {
+			continue
+		}
+		onIface := slices.ContainsFunc(record.Sightings, func(sighting domain.Sighting) bool {
+			_, ok := findSighting(device.Sightings, sighting.Interface)
+			return ok
+		})
+		// ties are decided by the id, as the order of the devices is random
+		if match == -1 || (onIface && !matchOnIface) || (onIface == matchOnIface && device.Id < devices[match].Id) {
+			match, matchOnIface = i, onIface
+		}
+	}
+	return match
+}
+
+// mergeDevice combines two records of the same device received via different service types
+func mergeDevice(dev domain.DeviceInfo, other domain.DeviceInfo) domain.DeviceInfo {
+	mergeString(&dev.FullName, other.FullName)
+	mergeString(&dev.HostName, other.HostName)
+	mergeString(&dev.Id, other.Id)
+	mergeString(&dev.Process, other.Process)
+	mergeString(&dev.CmcpVersion, other.CmcpVersion)
+	mergeString(&dev.CmcpMin, other.CmcpMin)
+	mergeString(&dev.ServerVersion, other.ServerVersion)
+	mergeString(&dev.Channels, other.Channels)
+	mergeString(&dev.Manufacturer, other.Manufacturer)
+	mergeString(&dev.Model, other.Model)
+	if dev.IPv4 == nil {
+		dev.IPv4 = other.IPv4
+	}
+	if other.ArcPort != 0 {
+		dev.ArcPort = other.ArcPort
+	}
+	if other.CmcPort != 0 {
+		dev.CmcPort = other.CmcPort
+	}
+	if other.DbcPort != 0 {
+		dev.DbcPort = other.DbcPort
+	}
+	for _, service := range other.Services {
+		if !dev.HasService(service) {
+			dev.Services = append(dev.Services, service)
+		}
+	}
+	dev.TxChannels = mergeChannels(dev.TxChannels, other.TxChannels)
+	dev.Sightings = combineSightings(dev.Sightings, other.Sightings)
+	if other.FirstSeen.Before(dev.FirstSeen) {
+		dev.FirstSeen = other.FirstSeen
+	}
+	if other.LastSeen.After(dev.LastSeen) {
+		dev.LastSeen = other.LastSeen
+	}
+	return dev
+}
+
+// mergeChannels adds all channels not yet contained in the list and keeps the list sorted by channel number
+func mergeChannels(channels domain.ChannelList, other domain.ChannelList) domain.ChannelList {
+	for _, channel := range other {
+		if existing := channels.GetByNumber(channel.Number); existing != nil {
+			*existing = channel
+		} else {
+			channels = append(channels, channel)
+		}
+	}
+	sort.SliceStable(channels, func(i, j int) bool {
+		return channels[i].Number < channels[j].Number
+	})
+	return channels
+}
+
+// mergeSightings adds the sightings on interfaces not yet contained in the list and updates the others with newer sightings
+func mergeSightings(sightings []domain.Sighting, other []domain.Sighting) []domain.Sighting {
+	merged := append([]domain.Sighting(nil), sightings...)
+	for _, sighting := range other {
+		i := slices.IndexFunc(merged, func(known domain.Sighting) bool { return known.Interface == sighting.Interface })
+		switch {
+		case i == -1:
+			merged = append(merged, sighting)
+		case sighting.LastSeen.After(merged[i].LastSeen):
+			if sighting.IPv4 == nil {
+				sighting.IPv4 = merged[i].IPv4
+			}
+			if len(sighting.Addresses) == 0 {
+				sighting.Addresses = merged[i].Addresses
+			}
+			merged[i] = sighting
+		}
+	}
+	return merged
+}
+
+// combineSightings joins the sightings of one scan, where a device answers once per service type on each interface. Other than mergeSightings,
+// the addresses of sightings on the same interface are combined, as not every answer carries all of them
+func combineSightings(sightings []domain.Sighting, other []domain.Sighting) []domain.Sighting {
+	combined := append([]domain.Sighting(nil), sightings...)
+	for _, sighting := range other {
+		i := slices.IndexFunc(combined, func(known domain.Sighting) bool { return known.Interface == sighting.Interface })
+		if i == -1 {
+			combined = append(combined, sighting)
+			continue
+		}
+		known := combined[i]
+		if known.IPv4 == nil {
+			known.IPv4 = sighting.IPv4
+		}
+		known.Addresses = mergeAddresses(known.Addresses, sighting.Addresses)
+		if sighting.LastSeen.After(known.LastSeen) {
+			known.LastSeen = sighting.LastSeen
+		}
+		combined[i] = known
+	}
+	return combined
+}
+
+// entryAddresses returns the IPv4 and IPv6 address of an mDNS entry. Link-local IPv6 addresses without zone get the interface the entry was received on
+func entryAddresses(e mdns.ServiceEntry, iface string) (addrs []net.IPAddr) {
+	if e.AddrV4 != nil {
+		addrs = append(addrs, net.IPAddr{IP: e.AddrV4})
+	}
+	if e.AddrV6IPAddr != nil && e.AddrV6IPAddr.IP != nil {
+		addr := *e.AddrV6IPAddr
+		if addr.Zone == "" && (addr.IP.IsLinkLocalUnicast() || addr.IP.IsLinkLocalMulticast()) {
+			addr.Zone = iface
+		}
+		addrs = append(addrs, addr)
+	}
+	return
+}
+
+// mergeAddresses adds the addresses not yet contained in the list
+func mergeAddresses(addrs []net.IPAddr, other []net.IPAddr) []net.IPAddr {
+	merged := append([]net.IPAddr(nil), addrs...)
+	for _, addr := range other {
+		if !slices.ContainsFunc(merged, func(known net.IPAddr) bool { return known.IP.Equal(addr.IP) && known.Zone == addr.Zone }) {
+			merged = append(merged, addr)
+		}
+	}
+	return merged
+}
+
+// sortSightings orders the sightings like the configured interfaces, so the primary network comes first. Interfaces no longer scanned come last
+func (s DefaultDeviceScanService) sortSightings(sightings []domain.Sighting) {
+	rank := func(name string) int {
+		for i, iface := range s.Cfg.RunTime.DeviceScanInterfaces {
+			if iface.Name == name {
+				return i
+			}
+		}
+		return len(s.Cfg.RunTime.DeviceScanInterfaces)
+	}
+	sort.SliceStable(sightings, func(i, j int) bool {
+		return rank(sightings[i].Interface) < rank(sightings[j].Interface)
+	})
+}
+
+// mergeString sets the target to the value if the target is still empty
+func mergeString(target *string, value string) {
+	if *target == "" {
+		*target = value
+	}
+}
+
 func shorten(fqdn string) string {
 	i := strings.Index(fqdn, ".")
 	if i == -1 {
@@ -170,11 +697,224 @@ func shorten(fqdn string) string {
 	return fqdn[0:i]
 }
 
+// storeDevice stores a scanned device under its identity and reports the changes against the stored record
 func (s DefaultDeviceScanService) storeDevice(dev domain.DeviceInfo) (err error) {
-	oldDev := s.Repo.GetByName(dev.Name)
-	if oldDev != nil {
+	if dev.Id == "" {
+		// not every service announces the id, so records without it belong to the known device with the same name
+		if devices := s.Repo.GetAll(); devices != nil {
+			if i := matchByName(*devices, dev); i != -1 {
+				dev.Id = (*devices)[i].Id
+			}
+		}
+	}
+	oldDev := s.Repo.GetByKey(dev.Key())
+	if oldDev == nil && dev.Id != "" {
+		// devices seen before their id was known are stored under their name
+		if legacy := s.Repo.GetByKey(dev.Name); legacy != nil && legacy.Id == "" {
+			oldDev = legacy
+			if err := s.Repo.Delete(dev.Name); err != nil {
+				logger.Error("Could not remove device stored by name", err)
+			}
+		}
+	}
+	if oldDev == nil {
+		s.Events.Publish(domain.Event{Type: domain.EventNewDevice, Device: dev.Name})
+	} else {
+		dev.PreviousNames = slices.DeleteFunc(slices.Clone(oldDev.PreviousNames), func(name string) bool { return name == dev.Name })
+		if oldDev.Name != dev.Name {
+			logger.Infof("Device %v was renamed to %v", oldDev.Name, dev.Name)
+			dev = dev.WithPreviousName(oldDev.Name)
+		}
 		dev.FirstSeen = oldDev.FirstSeen
+		// the channel service and ARC do not answer in every cycle, keep the last known details
+		if len(dev.TxChannels) == 0 {
+			dev.TxChannels = oldDev.TxChannels
+		}
+		if dev.DanteName == "" {
+			dev.DanteName = oldDev.DanteName
+			dev.TxChannelCount = oldDev.TxChannelCount
+			dev.RxChannelCount = oldDev.RxChannelCount
+			dev.RxChannels = oldDev.RxChannels
+		}
+		if !dev.Settings.Known() {
+			dev.Settings = oldDev.Settings
+		}
+		dev.Sightings = mergeSightings(oldDev.Sightings, dev.Sightings)
+	}
+	s.sortSightings(dev.Sightings)
+	// the address on the first interface stays the device's address, even if the device only answered on another interface
+	for _, sighting := range dev.Sightings {
+		if sighting.IPv4 != nil {
+			dev.IPv4 = sighting.IPv4
+			break
+		}
+	}
+	dev.Addresses = nil
+	for _, sighting := range dev.Sightings {
+		dev.Addresses = mergeAddresses(dev.Addresses, sighting.Addresses)
+	}
+	if oldDev != nil {
+		for _, e := range compareDevices(*oldDev, dev, time.Now()) {
+			s.Events.Publish(e)
+		}
 	}
 	err = s.Repo.Store(dev)
 	return err
 }
+
+// markOffline records the time devices passed the offline threshold and reports them once
+func (s DefaultDeviceScanService) markOffline(now time.Time) (marked int) {
+	staleAfter, offlineAfter := config.DeviceStateThresholds(s.Cfg)
+	if devices := s.Repo.GetAll(); devices != nil {
+		for _, dev := range *devices {
+			if !dev.OfflineSince.IsZero() || dev.State(now, staleAfter, offlineAfter) != domain.DeviceOffline {
+				continue
+			}
+			dev.OfflineSince = now
+			if err := s.Repo.Store(dev); err == nil {
+				s.Events.Publish(domain.Event{Date: now, Type: domain.EventOffline, Device: dev.Name, Property: "last seen", OldValue: dev.LastSeen.Format("2006-01-02 15:04:05"), NewValue: string(domain.DeviceOffline)})
+				marked++
+			}
+		}
+	}
+	return marked
+}
+
+// purgeDevices removes all devices not seen within the configured retention period
+func (s DefaultDeviceScanService) purgeDevices(now time.Time) (purged int) {
+	if s.Cfg.DeviceScan.RetentionHours <= 0 {
+		return 0
+	}
+	retention := time.Duration(s.Cfg.DeviceScan.RetentionHours) * time.Hour
+	if devices := s.Repo.GetAll(); devices != nil {
+		for _, dev := range *devices {
+			if now.Sub(dev.LastSeen) < retention {
+				continue
+			}
+			if err := s.Repo.Delete(dev.Key()); err == nil {
+				logger.Infof("Removed device %v, last seen %v", dev.Name, dev.LastSeen.Format("2006-01-02 15:04:05"))
+				purged++
+			}
+		}
+	}
+	return purged
+}
+
+// listenForGoodbyes listens on the mDNS multicast group during the pause between scans and marks devices sending a goodbye as offline.
+// The socket is closed before the next scan, as the mDNS client needs the port for itself while querying
+func (s DefaultDeviceScanService) listenForGoodbyes(pause time.Duration) {
+	deadline := time.Now().Add(pause)
+	ifaces := s.Cfg.RunTime.DeviceScanInterfaces
+	var first *net.Interface
+	if len(ifaces) > 0 {
+		first = ifaces[0]
+	}
+	conn, err := net.ListenMulticastUDP("udp4", first, mdnsGroup)
+	if err != nil {
+		logger.Warnf("Could not listen for mDNS goodbye packets: %v", err)
+		s.waitForTrigger(time.Until(deadline))
+		return
+	}
+	defer conn.Close()
+	if len(ifaces) > 1 {
+		p := ipv4.NewPacketConn(conn)
+		for _, iface := range ifaces[1:] {
+			if err := p.JoinGroup(iface, mdnsGroup); err != nil {
+				logger.Warnf("Could not listen for mDNS goodbye packets on interface %v: %v", iface.Name, err)
+			}
+		}
+	}
+	buf := make([]byte, 9000)
+	for time.Now().Before(deadline) {
+		select {
+		case <-s.trigger:
+			logger.Info("Immediate device scan requested")
+			return
+		default:
+		}
+		// wake up regularly to check for a requested scan
+		readDeadline := time.Now().Add(time.Second)
+		if readDeadline.After(deadline) {
+			readDeadline = deadline
+		}
+		conn.SetReadDeadline(readDeadline)
+		n, _, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			if ne, ok := err.(net.Error); ok && ne.Timeout() {
+				continue
+			}
+			logger.Warnf("Error while listening for mDNS goodbye packets: %v", err)
+			s.waitForTrigger(time.Until(deadline))
+			return
+		}
+		var msg dns.Msg
+		if err := msg.Unpack(buf[:n]); err != nil {
+			continue
+		}
+		if names := goodbyeNames(&msg, s.Cfg.DeviceScan.ServiceNames); len(names) > 0 {
+			s.applyGoodbyes(names, time.Now())
+		}
+	}
+}
+
+// waitForTrigger pauses until the time is up or an immediate scan is requested
+func (s DefaultDeviceScanService) waitForTrigger(pause time.Duration) {
+	select {
+	case <-s.trigger:
+		logger.Info("Immediate device scan requested")
+	case <-time.After(pause):
+	}
+}
+
+// goodbyeNames returns the names of all devices announcing with a TTL of 0 that they leave the network. Channel goodbyes are ignored
+func goodbyeNames(msg *dns.Msg, services []string) (names []string) {
+	for _, rr := range append(msg.Answer, msg.Extra...) {
+		if rr.Header().Ttl != 0 {
+			continue
+		}
+		switch r := rr.(type) {
+		case *dns.PTR:
+			service := strings.TrimSuffix(r.Hdr.Name, ".local.")
+			if service == domain.ServiceChan || !slices.Contains(services, service) {
+				continue
+			}
+			names = append(names, strings.TrimSuffix(r.Ptr, "."+r.Hdr.Name))
+		case *dns.SRV:
+			if strings.Contains(r.Hdr.Name, domain.ServiceChan) {
+				continue
+			}
+			names = append(names, shorten(r.Target))
+		case *dns.A:
+			names = append(names, shorten(r.Hdr.Name))
+		}
+	}
+	return names
+}
+
+// applyGoodbyes marks all devices whose name or mDNS instance name matches as offline
+func (s DefaultDeviceScanService) applyGoodbyes(names []string, now time.Time) (marked int) {
+	if devices := s.Repo.GetAll(); devices != nil {
+		for _, dev := range *devices {
+			instance, _, _ := strings.Cut(dev.FullName, "._")
+			if !slices.Contains(names, dev.Name) && !slices.Contains(names, instance) {
+				continue
+			}
+			if dev.GoodbyeAt.After(dev.LastSeen) {
+				continue
+			}
+			dev.GoodbyeAt = now
+			wasOffline := !dev.OfflineSince.IsZero()
+			if !wasOffline {
+				dev.OfflineSince = now
+			}
+			if err := s.Repo.Store(dev); err == nil {
+				logger.Infof("Device %v sent an mDNS goodbye. Marking it as offline", dev.Name)
+				if !wasOffline {
+					s.Events.Publish(domain.Event{Date: now, Type: domain.EventOffline, Device: dev.Name, Property: "state", OldValue: "goodbye received", NewValue: string(domain.DeviceOffline)})
+				}
+				marked++
+			}
+		}
+	}
+	return marked
+}
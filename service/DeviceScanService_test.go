@@ -0,0 +1,514 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/johannes-kuhfuss/alighieri/config"
+	"github.com/johannes-kuhfuss/alighieri/domain"
+	"github.com/johannes-kuhfuss/alighieri/repositories"
+	"github.com/johannes-kuhfuss/mdns"
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShortenNoDotReturnsInput(t *testing.T) {
+	assert.EqualValues(t, "device", shorten("device"))
+}
+
+func TestShortenFqdnReturnsHost(t *testing.T) {
+	assert.EqualValues(t, "device", shorten("device.local."))
+}
+
+func TestConvertEntryArcSetsArcPortAndInfo(t *testing.T) {
+	e := mdns.ServiceEntry{
+		Name:       "device._netaudio-arc._udp.local.",
+		Host:       "device.local.",
+		AddrV4:     net.ParseIP("192.168.1.10"),
+		Port:       4440,
+		InfoFields: []string{"id=001dc1fffe000001", "mf=Audinate", "model=DAI2"},
+	}
+	dev, err := convertEntry(e, domain.ServiceArc)
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, "device", dev.Name)
+	assert.EqualValues(t, 4440, dev.ArcPort)
+	assert.EqualValues(t, 0, dev.CmcPort)
+	assert.EqualValues(t, "001dc1fffe000001", dev.Id)
+	assert.EqualValues(t, "Audinate", dev.Manufacturer)
+	assert.EqualValues(t, []string{domain.ServiceArc}, dev.Services)
+}
+
+func TestConvertEntryChanIgnoresChannelInfo(t *testing.T) {
+	e := mdns.ServiceEntry{
+		Name:       "01@device._netaudio-chan._udp.local.",
+		Host:       "device.local.",
+		AddrV4:     net.ParseIP("192.168.1.10"),
+		Port:       4455,
+		InfoFields: []string{"id=1", "rate=48000"},
+	}
+	dev, err := convertEntry(e, domain.ServiceChan)
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, "device", dev.Name)
+	assert.EqualValues(t, "", dev.Id)
+	assert.EqualValues(t, "", dev.FullName)
+	assert.True(t, dev.HasService(domain.ServiceChan))
+}
+
+func TestMergeDeviceCombinesPortsAndServices(t *testing.T) {
+	now := time.Now()
+	arc := domain.DeviceInfo{
+		Name:      "device",
+		ArcPort:   4440,
+		Services:  []string{domain.ServiceArc},
+		FirstSeen: now,
+		LastSeen:  now,
+	}
+	cmc := domain.DeviceInfo{
+		Name:         "device",
+		CmcPort:      8800,
+		Manufacturer: "Audinate",
+		Services:     []string{domain.ServiceCmc},
+		FirstSeen:    now.Add(-time.Minute),
+		LastSeen:     now.Add(time.Second),
+	}
+	dev := mergeDevice(arc, cmc)
+
+	assert.EqualValues(t, 4440, dev.ArcPort)
+	assert.EqualValues(t, 8800, dev.CmcPort)
+	assert.EqualValues(t, "Audinate", dev.Manufacturer)
+	assert.EqualValues(t, []string{domain.ServiceArc, domain.ServiceCmc}, dev.Services)
+	assert.EqualValues(t, now.Add(-time.Minute), dev.FirstSeen)
+	assert.EqualValues(t, now.Add(time.Second), dev.LastSeen)
+}
+
+func TestMergeDeviceDoesNotDuplicateServices(t *testing.T) {
+	a := domain.DeviceInfo{Name: "device", Services: []string{domain.ServiceArc}}
+	b := domain.DeviceInfo{Name: "device", Services: []string{domain.ServiceArc}}
+	dev := mergeDevice(a, b)
+
+	assert.EqualValues(t, 1, len(dev.Services))
+}
+
+func TestConvertChannelReturnsChannel(t *testing.T) {
+	e := mdns.ServiceEntry{
+		Name:       "Left@device._netaudio-chan._udp.local.",
+		Host:       "device.local.",
+		InfoFields: []string{"txtvers=2", "id=1", "rate=48000", "en=24", "latency_ns=1000000"},
+	}
+	ch, err := convertChannel(e)
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, "device", ch.DeviceName)
+	assert.EqualValues(t, domain.ChannelTx, ch.Direction)
+	assert.EqualValues(t, 1, ch.Number)
+	assert.EqualValues(t, "Left", ch.Name)
+	assert.EqualValues(t, 48000, ch.SampleRate)
+	assert.EqualValues(t, 24, ch.Encoding)
+	assert.EqualValues(t, 1000000, ch.LatencyNs)
+}
+
+func TestConvertChannelNoChannelNameReturnsError(t *testing.T) {
+	e := mdns.ServiceEntry{
+		Name:       "device._netaudio-chan._udp.local.",
+		Host:       "device.local.",
+		InfoFields: []string{"id=1"},
+	}
+	_, err := convertChannel(e)
+
+	assert.NotNil(t, err)
+	assert.EqualValues(t, "entry device._netaudio-chan._udp.local. is not a channel entry", err.Error())
+}
+
+func TestConvertChannelNoIdReturnsError(t *testing.T) {
+	e := mdns.ServiceEntry{
+		Name: "Left@device._netaudio-chan._udp.local.",
+		Host: "device.local.",
+	}
+	_, err := convertChannel(e)
+
+	assert.NotNil(t, err)
+}
+
+func TestMergeChannelsSortsByNumber(t *testing.T) {
+	a := domain.ChannelList{{Number: 2, Name: "Right"}}
+	b := domain.ChannelList{{Number: 1, Name: "Left"}, {Number: 2, Name: "Right 2"}}
+	res := mergeChannels(a, b)
+
+	assert.EqualValues(t, 2, len(res))
+	assert.EqualValues(t, "Left", res[0].Name)
+	assert.EqualValues(t, "Right 2", res[1].Name)
+}
+
+type arcClientMock struct {
+	err error
+}
+
+var (
+	lastArcCall string
+)
+
+func (m arcClientMock) GetDeviceName() (string, error) {
+	return "stagebox-1", m.err
+}
+
+func (m arcClientMock) GetChannelCount() (int, int, error) {
+	return 2, 1, m.err
+}
+
+func (m arcClientMock) GetTxChannels(txCount int) (domain.ChannelList, error) {
+	return domain.ChannelList{
+		{Direction: domain.ChannelTx, Number: 1, Name: "Vocal L", SampleRate: 48000},
+		{Direction: domain.ChannelTx, Number: 2, Name: "Vocal R", SampleRate: 48000},
+	}, m.err
+}
+
+func (m arcClientMock) GetRxChannels(rxCount int) (domain.ChannelList, error) {
+	return domain.ChannelList{
+		{Direction: domain.ChannelRx, Number: 1, Name: "In 1", TxChannelName: "Out 1", TxDeviceName: "mixer", SubscriptionStatus: domain.SubscriptionUnicast},
+	}, m.err
+}
+
+func (m arcClientMock) Subscribe(rxChannel int, txChannelName string, txDeviceName string) error {
+	lastArcCall = fmt.Sprintf("subscribe %v %v@%v", rxChannel, txChannelName, txDeviceName)
+	return m.err
+}
+
+func (m arcClientMock) Unsubscribe(rxChannel int) error {
+	lastArcCall = fmt.Sprintf("unsubscribe %v", rxChannel)
+	return m.err
+}
+
+func (m arcClientMock) SetDeviceName(name string) error {
+	lastArcCall = fmt.Sprintf("rename device %v", name)
+	return m.err
+}
+
+func (m arcClientMock) SetChannelName(direction domain.ChannelDirection, channel int, name string) error {
+	lastArcCall = fmt.Sprintf("rename %v %v %v", direction, channel, name)
+	return m.err
+}
+
+func TestEnrichDeviceAddsArcDetails(t *testing.T) {
+	dev := domain.DeviceInfo{
+		Name:       "device",
+		TxChannels: domain.ChannelList{{DeviceName: "device", Direction: domain.ChannelTx, Number: 1, Name: "01", LatencyNs: 1000000}},
+	}
+	err := enrichDevice(arcClientMock{}, &dev)
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, "stagebox-1", dev.DanteName)
+	assert.EqualValues(t, 2, dev.TxChannelCount)
+	assert.EqualValues(t, 1, dev.RxChannelCount)
+	assert.EqualValues(t, 2, len(dev.TxChannels))
+	assert.EqualValues(t, "Vocal L", dev.TxChannels[0].Name)
+	assert.EqualValues(t, 1000000, dev.TxChannels[0].LatencyNs)
+	assert.EqualValues(t, "device", dev.TxChannels[1].DeviceName)
+	assert.EqualValues(t, 1, len(dev.RxChannels))
+	assert.EqualValues(t, "mixer", dev.RxChannels[0].TxDeviceName)
+}
+
+func TestEnrichDeviceErrorLeavesDeviceUnchanged(t *testing.T) {
+	dev := domain.DeviceInfo{Name: "device"}
+	err := enrichDevice(arcClientMock{err: errors.New("timeout")}, &dev)
+
+	assert.NotNil(t, err)
+	assert.EqualValues(t, "", dev.DanteName)
+	assert.Nil(t, dev.RxChannels)
+}
+
+type eventServiceMock struct {
+	events []domain.Event
+}
+
+func (m *eventServiceMock) Publish(e domain.Event) {
+	m.events = append(m.events, e)
+}
+
+func setupDeviceScanTest() (DefaultDeviceScanService, *repositories.DefaultDeviceRepository, *eventServiceMock) {
+	var cfg config.AppConfig
+	config.InitConfig("", &cfg)
+	repo := repositories.NewDeviceRepository(&cfg)
+	events := eventServiceMock{}
+	return DefaultDeviceScanService{Cfg: &cfg, Repo: &repo, Events: &events}, &repo, &events
+}
+
+func TestGoodbyeNamesReturnsDevicesWithTtlZero(t *testing.T) {
+	var msg dns.Msg
+	msg.Answer = []dns.RR{
+		&dns.PTR{Hdr: dns.RR_Header{Name: "_netaudio-arc._udp.local.", Rrtype: dns.TypePTR, Ttl: 0}, Ptr: "leaving._netaudio-arc._udp.local."},
+		&dns.PTR{Hdr: dns.RR_Header{Name: "_netaudio-arc._udp.local.", Rrtype: dns.TypePTR, Ttl: 120}, Ptr: "staying._netaudio-arc._udp.local."},
+		&dns.PTR{Hdr: dns.RR_Header{Name: "_netaudio-chan._udp.local.", Rrtype: dns.TypePTR, Ttl: 0}, Ptr: "01@channel._netaudio-chan._udp.local."},
+	}
+	msg.Extra = []dns.RR{
+		&dns.A{Hdr: dns.RR_Header{Name: "host.local.", Rrtype: dns.TypeA, Ttl: 0}, A: net.ParseIP("192.168.1.10")},
+	}
+	names := goodbyeNames(&msg, []string{domain.ServiceArc, domain.ServiceChan})
+
+	assert.EqualValues(t, []string{"leaving", "host"}, names)
+}
+
+func TestApplyGoodbyesMarksDeviceOffline(t *testing.T) {
+	s, repo, events := setupDeviceScanTest()
+	now := time.Now()
+	repo.Store(domain.DeviceInfo{Name: "leaving", FullName: "leaving-arc._netaudio-arc._udp.local", LastSeen: now.Add(-time.Second)})
+	repo.Store(domain.DeviceInfo{Name: "staying", LastSeen: now.Add(-time.Second)})
+	marked := s.applyGoodbyes([]string{"leaving-arc"}, now)
+
+	assert.EqualValues(t, 1, marked)
+	assert.EqualValues(t, 1, len(events.events))
+	assert.EqualValues(t, domain.EventOffline, events.events[0].Type)
+	assert.EqualValues(t, domain.DeviceOffline, repo.GetByName("leaving").State(now, time.Minute, time.Hour))
+	assert.EqualValues(t, domain.DeviceOnline, repo.GetByName("staying").State(now, time.Minute, time.Hour))
+}
+
+func TestDeviceStateDependsOnLastSeen(t *testing.T) {
+	now := time.Now()
+	dev := domain.DeviceInfo{LastSeen: now.Add(-2 * time.Minute)}
+
+	assert.EqualValues(t, domain.DeviceStale, dev.State(now, time.Minute, time.Hour))
+	assert.EqualValues(t, domain.DeviceOffline, dev.State(now, time.Second, time.Minute))
+
+	dev.GoodbyeAt = now.Add(-3 * time.Minute)
+	assert.EqualValues(t, domain.DeviceOnline, dev.State(now, time.Hour, 2*time.Hour))
+}
+
+func TestPurgeDevicesRemovesDevicesPastRetention(t *testing.T) {
+	s, repo, _ := setupDeviceScanTest()
+	now := time.Now()
+	repo.Store(domain.DeviceInfo{Name: "old", LastSeen: now.Add(-25 * time.Hour)})
+	repo.Store(domain.DeviceInfo{Name: "new", LastSeen: now})
+
+	assert.EqualValues(t, 0, s.purgeDevices(now))
+
+	s.Cfg.DeviceScan.RetentionHours = 24
+	assert.EqualValues(t, 1, s.purgeDevices(now))
+	assert.False(t, repo.Exists("old"))
+	assert.True(t, repo.Exists("new"))
+}
+
+func TestStoreDeviceNewDevicePublishesEvent(t *testing.T) {
+	s, _, events := setupDeviceScanTest()
+	s.storeDevice(domain.DeviceInfo{Name: "device", LastSeen: time.Now()})
+	s.storeDevice(domain.DeviceInfo{Name: "device", LastSeen: time.Now()})
+
+	assert.EqualValues(t, 1, len(events.events))
+	assert.EqualValues(t, domain.EventNewDevice, events.events[0].Type)
+}
+
+func TestStoreDeviceChangedIpPublishesEvent(t *testing.T) {
+	s, _, events := setupDeviceScanTest()
+	s.storeDevice(domain.DeviceInfo{Name: "device", IPv4: net.ParseIP("192.168.1.10")})
+	s.storeDevice(domain.DeviceInfo{Name: "device", IPv4: net.ParseIP("192.168.1.11")})
+
+	assert.EqualValues(t, 2, len(events.events))
+	assert.EqualValues(t, domain.EventIpChanged, events.events[1].Type)
+	assert.EqualValues(t, "192.168.1.10", events.events[1].OldValue)
+	assert.EqualValues(t, "192.168.1.11", events.events[1].NewValue)
+}
+
+func TestMarkOfflineReportsDeviceOnce(t *testing.T) {
+	s, repo, events := setupDeviceScanTest()
+	now := time.Now()
+	repo.Store(domain.DeviceInfo{Name: "device", LastSeen: now.Add(-24 * time.Hour)})
+
+	assert.EqualValues(t, 1, s.markOffline(now))
+	assert.EqualValues(t, 0, s.markOffline(now))
+	assert.EqualValues(t, domain.EventOffline, events.events[0].Type)
+
+	s.storeDevice(domain.DeviceInfo{Name: "device", LastSeen: now})
+	assert.EqualValues(t, domain.EventBackOnline, events.events[1].Type)
+	assert.True(t, repo.GetByName("device").OfflineSince.IsZero())
+}
+
+func TestCompareDevicesIgnoresMissingValues(t *testing.T) {
+	old := domain.DeviceInfo{Name: "device", ArcPort: 4440, CmcPort: 8800, DanteName: "stagebox", ServerVersion: "4.2.1"}
+	dev := domain.DeviceInfo{Name: "device", ArcPort: 4441, DanteName: "stagebox-1", ServerVersion: "4.2.1"}
+	events := compareDevices(old, dev, time.Now())
+
+	assert.EqualValues(t, 2, len(events))
+	assert.EqualValues(t, domain.EventPortChanged, events[0].Type)
+	assert.EqualValues(t, "ARC port", events[0].Property)
+	assert.EqualValues(t, domain.EventNameChanged, events[1].Type)
+}
+
+func TestTriggerScanSecondRequestReturnsFalse(t *testing.T) {
+	s, _, _ := setupDeviceScanTest()
+	s.trigger = make(chan bool, 1)
+
+	assert.True(t, s.TriggerScan())
+	assert.False(t, s.TriggerScan())
+
+	s.waitForTrigger(time.Hour)
+	assert.True(t, s.TriggerScan())
+}
+
+func TestCountEntriesAddsPerService(t *testing.T) {
+	svc, _, _ := setupDeviceScanTest()
+	svc.Cfg.RunTime.MdnsEntries = nil
+
+	svc.countEntries(domain.ServiceArc, 3)
+	svc.countEntries(domain.ServiceArc, 2)
+	svc.countConversionError()
+
+	assert.EqualValues(t, 5, svc.Cfg.RunTime.MdnsEntries[domain.ServiceArc])
+	assert.EqualValues(t, 1, svc.Cfg.RunTime.ConversionErrors)
+}
+
+func TestMergeSightingsKeepsNewestPerInterface(t *testing.T) {
+	now := time.Now()
+	sightings := []domain.Sighting{
+		{Interface: "eth0", IPv4: net.ParseIP("192.168.1.10"), LastSeen: now.Add(-time.Minute)},
+		{Interface: "eth1", IPv4: net.ParseIP("192.168.2.10"), LastSeen: now.Add(-time.Hour)},
+	}
+
+	merged := mergeSightings(sightings, []domain.Sighting{{Interface: "eth0", IPv4: net.ParseIP("192.168.1.11"), LastSeen: now}})
+
+	assert.EqualValues(t, 2, len(merged))
+	assert.EqualValues(t, "192.168.1.11", merged[0].IPv4.String())
+	assert.EqualValues(t, now, merged[0].LastSeen)
+	assert.EqualValues(t, "192.168.2.10", merged[1].IPv4.String())
+	assert.EqualValues(t, "192.168.1.10", sightings[0].IPv4.String())
+}
+
+func TestStoreDeviceOnlySecondaryAnsweringKeepsPrimaryAddress(t *testing.T) {
+	s, repo, events := setupDeviceScanTest()
+	s.Cfg.RunTime.DeviceScanInterfaces = []*net.Interface{{Name: "eth0"}, {Name: "eth1"}}
+	now := time.Now()
+	repo.Store(domain.DeviceInfo{
+		Name: "stagebox",
+		IPv4: net.ParseIP("192.168.1.10"),
+		Sightings: []domain.Sighting{
+			{Interface: "eth0", IPv4: net.ParseIP("192.168.1.10"), LastSeen: now.Add(-time.Hour)},
+			{Interface: "eth1", IPv4: net.ParseIP("192.168.2.10"), LastSeen: now.Add(-time.Hour)},
+		},
+		LastSeen: now.Add(-time.Hour),
+	})
+
+	s.storeDevice(domain.DeviceInfo{
+		Name:      "stagebox",
+		IPv4:      net.ParseIP("192.168.2.10"),
+		Sightings: []domain.Sighting{{Interface: "eth1", IPv4: net.ParseIP("192.168.2.10"), LastSeen: now}},
+		LastSeen:  now,
+	})
+	dev := repo.GetByName("stagebox")
+	missing := dev.MissingOn(time.Minute)
+
+	assert.EqualValues(t, "192.168.1.10", dev.IPv4.String())
+	assert.EqualValues(t, "eth0", dev.Sightings[0].Interface)
+	assert.EqualValues(t, 1, len(missing))
+	assert.EqualValues(t, "eth0", missing[0].Interface)
+	assert.EqualValues(t, 0, len(events.events))
+}
+
+func TestEntryAddressesAddsZoneToLinkLocalAddress(t *testing.T) {
+	entry := mdns.ServiceEntry{
+		AddrV4:       net.ParseIP("192.168.1.10"),
+		AddrV6IPAddr: &net.IPAddr{IP: net.ParseIP("fe80::1")},
+	}
+
+	addrs := entryAddresses(entry, "eth0")
+
+	assert.EqualValues(t, 2, len(addrs))
+	assert.EqualValues(t, "192.168.1.10", addrs[0].String())
+	assert.EqualValues(t, "fe80::1%eth0", addrs[1].String())
+}
+
+func TestCombineSightingsJoinsAddressesPerInterface(t *testing.T) {
+	now := time.Now()
+	sightings := []domain.Sighting{{Interface: "eth0", IPv4: net.ParseIP("192.168.1.10"), Addresses: []net.IPAddr{{IP: net.ParseIP("192.168.1.10")}}, LastSeen: now}}
+
+	combined := combineSightings(sightings, []domain.Sighting{{Interface: "eth0", IPv4: net.ParseIP("192.168.1.10"), Addresses: []net.IPAddr{{IP: net.ParseIP("192.168.1.10")}, {IP: net.ParseIP("2001:db8::10")}}, LastSeen: now.Add(time.Second)}})
+
+	assert.EqualValues(t, 1, len(combined))
+	assert.EqualValues(t, 2, len(combined[0].Addresses))
+	assert.EqualValues(t, "2001:db8::10", combined[0].Addresses[1].String())
+	assert.EqualValues(t, now.Add(time.Second), combined[0].LastSeen)
+	assert.EqualValues(t, 1, len(sightings[0].Addresses))
+}
+
+func TestStoreDeviceCollectsAddressesOfAllInterfaces(t *testing.T) {
+	s, repo, _ := setupDeviceScanTest()
+	s.Cfg.RunTime.DeviceScanInterfaces = []*net.Interface{{Name: "eth0"}, {Name: "eth1"}}
+	now := time.Now()
+
+	s.storeDevice(domain.DeviceInfo{
+		Name: "stagebox",
+		Sightings: []domain.Sighting{
+			{Interface: "eth1", IPv4: net.ParseIP("192.168.2.10"), Addresses: []net.IPAddr{{IP: net.ParseIP("192.168.2.10")}, {IP: net.ParseIP("fe80::2"), Zone: "eth1"}}, LastSeen: now},
+			{Interface: "eth0", IPv4: net.ParseIP("192.168.1.10"), Addresses: []net.IPAddr{{IP: net.ParseIP("192.168.1.10")}, {IP: net.ParseIP("fe80::1"), Zone: "eth0"}}, LastSeen: now},
+		},
+		LastSeen: now,
+	})
+	dev := repo.GetByName("stagebox")
+
+	assert.EqualValues(t, "192.168.1.10", dev.IPv4.String())
+	assert.EqualValues(t, []net.IPAddr{
+		{IP: net.ParseIP("192.168.1.10")}, {IP: net.ParseIP("fe80::1"), Zone: "eth0"},
+		{IP: net.ParseIP("192.168.2.10")}, {IP: net.ParseIP("fe80::2"), Zone: "eth1"},
+	}, dev.Addresses)
+}
+
+func TestGroupDevicesKeepsDevicesWithSameNameApart(t *testing.T) {
+	now := time.Now()
+	sighting := func(iface string) []domain.Sighting {
+		return []domain.Sighting{{Interface: iface, LastSeen: now}}
+	}
+	records := []domain.DeviceInfo{
+		{Name: "stagebox", ArcPort: 4440, Sightings: sighting("eth1")},
+		{Name: "stagebox", ArcPort: 4441, Sightings: sighting("eth0")},
+		{Name: "stagebox", Id: "001dc1fffe000001", CmcPort: 8800, Sightings: sighting("eth0")},
+		{Name: "stagebox", Id: "001dc1fffe000002", CmcPort: 8801, Sightings: sighting("eth1")},
+		{Name: "amp", ArcPort: 4440, Sightings: sighting("eth0")},
+	}
+
+	found := groupDevices(records)
+
+	assert.EqualValues(t, 3, len(found))
+	assert.EqualValues(t, 4441, found["001dc1fffe000001"].ArcPort)
+	assert.EqualValues(t, 4440, found["001dc1fffe000002"].ArcPort)
+	assert.EqualValues(t, 4440, found["amp"].ArcPort)
+}
+
+func TestStoreDeviceRenamedDeviceKeepsIdentity(t *testing.T) {
+	s, repo, events := setupDeviceScanTest()
+	s.storeDevice(domain.DeviceInfo{Name: "stagebox", Id: "001dc1fffe000001", LastSeen: time.Now()})
+
+	s.storeDevice(domain.DeviceInfo{Name: "stagebox-foh", Id: "001dc1fffe000001", LastSeen: time.Now()})
+	dev := repo.GetByKey("001dc1fffe000001")
+
+	assert.EqualValues(t, 1, repo.Size())
+	assert.EqualValues(t, "stagebox-foh", dev.Name)
+	assert.EqualValues(t, []string{"stagebox"}, dev.PreviousNames)
+	assert.EqualValues(t, 2, len(events.events))
+	assert.EqualValues(t, domain.EventNameChanged, events.events[1].Type)
+	assert.EqualValues(t, "stagebox", events.events[1].OldValue)
+	assert.EqualValues(t, "stagebox-foh", events.events[1].NewValue)
+}
+
+func TestStoreDeviceMovesDeviceStoredByNameToItsId(t *testing.T) {
+	s, repo, events := setupDeviceScanTest()
+	firstSeen := time.Now().Add(-time.Hour)
+	repo.Store(domain.DeviceInfo{Name: "stagebox", FirstSeen: firstSeen})
+
+	s.storeDevice(domain.DeviceInfo{Name: "stagebox", Id: "001dc1fffe000001", LastSeen: time.Now()})
+
+	assert.EqualValues(t, 1, repo.Size())
+	assert.Nil(t, repo.GetByKey("stagebox"))
+	assert.True(t, firstSeen.Equal(repo.GetByKey("001dc1fffe000001").FirstSeen))
+	assert.Empty(t, events.events)
+}
+
+func TestStoreDeviceWithoutIdJoinsKnownDevice(t *testing.T) {
+	s, repo, _ := setupDeviceScanTest()
+	repo.Store(domain.DeviceInfo{Name: "stagebox", Id: "001dc1fffe000001", CmcPort: 8800})
+
+	s.storeDevice(domain.DeviceInfo{Name: "stagebox", ArcPort: 4440, LastSeen: time.Now()})
+
+	assert.EqualValues(t, 1, repo.Size())
+	assert.EqualValues(t, 4440, repo.GetByKey("001dc1fffe000001").ArcPort)
+}
@@ -0,0 +1,94 @@
+// package service implements the services and their business logic that provide the main part of the program
+package service
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/johannes-kuhfuss/alighieri/config"
+	"github.com/johannes-kuhfuss/alighieri/domain"
+	"github.com/johannes-kuhfuss/alighieri/repositories"
+	"github.com/johannes-kuhfuss/services_utils/logger"
+)
+
+type EventService interface {
+	Publish(domain.Event)
+}
+
+// The EventService records the changes detected on devices in the event history and passes them on to the notifiers, e.g. webhooks
+type DefaultEventService struct {
+	Cfg       *config.AppConfig
+	Repo      *repositories.DefaultEventRepository
+	Notifiers []EventNotifier
+}
+
+// NewEventService creates a new event service and injects its dependencies
+func NewEventService(cfg *config.AppConfig, repo *repositories.DefaultEventRepository, notifiers ...EventNotifier) DefaultEventService {
+	return DefaultEventService{
+		Cfg:       cfg,
+		Repo:      repo,
+		Notifiers: notifiers,
+	}
+}
+
+// Publish adds an event to the event history and informs the notifiers
+func (s DefaultEventService) Publish(e domain.Event) {
+	if e.Date.IsZero() {
+		e.Date = time.Now()
+	}
+	logger.Infof("Device %v: %v %v", e.Device, e.Type, describeChange(e))
+	if err := s.Repo.Add(e); err != nil {
+		logger.Error("Could not store event", err)
+	}
+	for _, n := range s.Notifiers {
+		n.Notify(e)
+	}
+}
+
+// compareDevices returns the events describing the changes between the stored and the newly scanned record of a device.
+// Values the scan did not deliver are not reported as changes
+func compareDevices(old domain.DeviceInfo, dev domain.DeviceInfo, now time.Time) (events []domain.Event) {
+	add := func(eventType domain.EventType, property string, oldValue string, newValue string) {
+		events = append(events, domain.Event{
+			Date:     now,
+			Type:     eventType,
+			Device:   dev.Name,
+			Property: property,
+			OldValue: oldValue,
+			NewValue: newValue,
+		})
+	}
+	if !old.OfflineSince.IsZero() {
+		add(domain.EventBackOnline, "state", string(domain.DeviceOffline), string(domain.DeviceOnline))
+	}
+	if old.IPv4 != nil && dev.IPv4 != nil && !old.IPv4.Equal(dev.IPv4) {
+		add(domain.EventIpChanged, "IPv4", old.IPv4.String(), dev.IPv4.String())
+	}
+	comparePort := func(property string, oldPort int, newPort int) {
+		if oldPort != 0 && newPort != 0 && oldPort != newPort {
+			add(domain.EventPortChanged, property, strconv.Itoa(oldPort), strconv.Itoa(newPort))
+		}
+	}
+	comparePort("ARC port", old.ArcPort, dev.ArcPort)
+	comparePort("CMC port", old.CmcPort, dev.CmcPort)
+	comparePort("DBC port", old.DbcPort, dev.DbcPort)
+	compareString := func(eventType domain.EventType, property string, oldValue string, newValue string) {
+		if oldValue != "" && newValue != "" && oldValue != newValue {
+			add(eventType, property, oldValue, newValue)
+		}
+	}
+	compareString(domain.EventNameChanged, "name", old.Name, dev.Name)
+	compareString(domain.EventNameChanged, "Dante name", old.DanteName, dev.DanteName)
+	compareString(domain.EventNameChanged, "full name", old.FullName, dev.FullName)
+	compareString(domain.EventVersionChanged, "server version", old.ServerVersion, dev.ServerVersion)
+	compareString(domain.EventVersionChanged, "CMCP version", old.CmcpVersion, dev.CmcpVersion)
+	return events
+}
+
+// describeChange formats an event's change for the log
+func describeChange(e domain.Event) string {
+	if e.Property == "" {
+		return ""
+	}
+	return e.Property + ": " + e.OldValue + " -> " + e.NewValue
+}
@@ -0,0 +1,136 @@
+// package service implements the services and their business logic that provide the main part of the program
+package service
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/johannes-kuhfuss/alighieri/config"
+	"github.com/johannes-kuhfuss/alighieri/domain"
+	"github.com/johannes-kuhfuss/alighieri/repositories"
+)
+
+type FormatService interface {
+	Report() domain.FormatReport
+}
+
+// The FormatService compares the sample rate and encoding of the devices with each other and with the house standard
+type DefaultFormatService struct {
+	Cfg  *config.AppConfig
+	Repo *repositories.DefaultDeviceRepository
+}
+
+// NewFormatService creates a new format service and injects its dependencies
+func NewFormatService(cfg *config.AppConfig, repo *repositories.DefaultDeviceRepository) DefaultFormatService {
+	return DefaultFormatService{
+		Cfg:  cfg,
+		Repo: repo,
+	}
+}
+
+// Report checks all devices not offline whose settings were read
+func (s DefaultFormatService) Report() domain.FormatReport {
+	standard := domain.DeviceSettings{
+		SampleRate: s.Cfg.HouseStandard.SampleRate,
+		Encoding:   s.Cfg.HouseStandard.Encoding,
+		LatencyNs:  s.Cfg.HouseStandard.LatencyUs * int(time.Microsecond),
+	}
+	staleAfter, offlineAfter := config.DeviceStateThresholds(s.Cfg)
+	now := time.Now().UTC()
+	var devices []domain.DeviceInfo
+	if all := s.Repo.GetAll(); all != nil {
+		for _, device := range *all {
+			if device.State(now, staleAfter, offlineAfter) != domain.DeviceOffline {
+				devices = append(devices, device)
+			}
+		}
+	}
+	return analyzeFormats(devices, standard)
+}
+
+// analyzeFormats groups the devices by format, flags devices deviating from the standard and subscriptions between devices running different formats
+func analyzeFormats(devices []domain.DeviceInfo, standard domain.DeviceSettings) (report domain.FormatReport) {
+	sort.SliceStable(devices, func(i, j int) bool {
+		return devices[i].Name < devices[j].Name
+	})
+	report.Standard = standard
+	for _, device := range devices {
+		if !device.Settings.Known() {
+			report.Unknown++
+			continue
+		}
+		report.Checked++
+		format := device.Settings.Format()
+		i := slices.IndexFunc(report.Groups, func(group domain.FormatGroup) bool { return group.Format == format })
+		if i == -1 {
+			report.Groups = append(report.Groups, domain.FormatGroup{Format: format, Standard: len(deviations(format, 0, standard)) == 0})
+			i = len(report.Groups) - 1
+		}
+		report.Groups[i].Devices = append(report.Groups[i].Devices, device.Name)
+		if deviated := deviations(format, device.Settings.LatencyNs, standard); len(deviated) > 0 {
+			report.Findings = append(report.Findings, domain.FormatFinding{
+				Issue:   domain.FormatOffStandard,
+				Devices: []string{device.Name},
+				Detail:  fmt.Sprintf("%v runs %v", device.Name, strings.Join(deviated, ", ")),
+			})
+		}
+	}
+	sort.SliceStable(report.Groups, func(i, j int) bool {
+		return len(report.Groups[i].Devices) > len(report.Groups[j].Devices)
+	})
+	for _, rx := range devices {
+		if !rx.Settings.Known() {
+			continue
+		}
+		for _, channel := range rx.RxChannels {
+			if !channel.IsSubscribed() {
+				continue
+			}
+			tx := findTransmitter(devices, channel.TxDeviceName)
+			if tx == nil || tx.Key() == rx.Key() || !tx.Settings.Known() || tx.Settings.Format() == rx.Settings.Format() {
+				continue
+			}
+			report.Findings = append(report.Findings, domain.FormatFinding{
+				Issue:   domain.FormatMismatch,
+				Devices: []string{rx.Name, tx.Name},
+				Detail:  fmt.Sprintf("%v channel %v (%v) runs %v, its source %v@%v runs %v", rx.Name, channel.Number, channel.Name, rx.Settings.Format(), channel.TxChannelName, tx.Name, tx.Settings.Format()),
+			})
+		}
+	}
+	return
+}
+
+// deviations lists how a format and latency differ from the standard. Settings without a standard value and a latency of 0 are not checked
+func deviations(format domain.Format, latencyNs int, standard domain.DeviceSettings) (deviated []string) {
+	if standard.SampleRate != 0 && format.SampleRate != standard.SampleRate {
+		deviated = append(deviated, fmt.Sprintf("%v Hz instead of %v Hz", format.SampleRate, standard.SampleRate))
+	}
+	if standard.SampleRate != 0 && format.PullUp != domain.PullUpNone {
+		deviated = append(deviated, fmt.Sprintf("pull-up/down %v instead of none", format.PullUp))
+	}
+	if standard.Encoding != 0 && format.Encoding != standard.Encoding {
+		deviated = append(deviated, fmt.Sprintf("%v bit instead of %v bit", format.Encoding, standard.Encoding))
+	}
+	if standard.LatencyNs != 0 && latencyNs != 0 && latencyNs != standard.LatencyNs {
+		deviated = append(deviated, fmt.Sprintf("%v latency instead of %v", time.Duration(latencyNs), time.Duration(standard.LatencyNs)))
+	}
+	return
+}
+
+// findTransmitter looks up the device a subscription refers to by its Dante name or, if the device did not tell its Dante name, by its name
+func findTransmitter(devices []domain.DeviceInfo, name string) *domain.DeviceInfo {
+	for i, device := range devices {
+		if device.DanteName == name {
+			return &devices[i]
+		}
+	}
+	for i, device := range devices {
+		if device.DanteName == "" && device.Name == name {
+			return &devices[i]
+		}
+	}
+	return nil
+}
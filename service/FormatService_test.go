@@ -0,0 +1,95 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/johannes-kuhfuss/alighieri/config"
+	"github.com/johannes-kuhfuss/alighieri/domain"
+	"github.com/johannes-kuhfuss/alighieri/repositories"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	houseStandard = domain.DeviceSettings{SampleRate: 48000, Encoding: 24, LatencyNs: 1000000}
+)
+
+func knownSettings(sampleRate int, encoding int, latencyNs int) domain.DeviceSettings {
+	return domain.DeviceSettings{SampleRate: sampleRate, Encoding: encoding, LatencyNs: latencyNs, ReadAt: time.Now()}
+}
+
+func TestAnalyzeFormatsGroupsDevicesByFormat(t *testing.T) {
+	devices := []domain.DeviceInfo{
+		{Name: "stagebox", Settings: knownSettings(48000, 24, 1000000)},
+		{Name: "amp", Settings: knownSettings(48000, 24, 2000000)},
+		{Name: "recorder", Settings: knownSettings(96000, 24, 1000000)},
+		{Name: "mixer"},
+	}
+
+	report := analyzeFormats(devices, houseStandard)
+
+	assert.EqualValues(t, 3, report.Checked)
+	assert.EqualValues(t, 1, report.Unknown)
+	assert.EqualValues(t, 2, len(report.Groups))
+	assert.EqualValues(t, []string{"amp", "stagebox"}, report.Groups[0].Devices)
+	assert.True(t, report.Groups[0].Standard)
+	assert.EqualValues(t, []string{"recorder"}, report.Groups[1].Devices)
+	assert.False(t, report.Groups[1].Standard)
+}
+
+func TestAnalyzeFormatsFlagsDevicesOffStandard(t *testing.T) {
+	devices := []domain.DeviceInfo{
+		{Name: "stagebox", Settings: knownSettings(48000, 24, 1000000)},
+		{Name: "amp", Settings: knownSettings(48000, 32, 2000000)},
+	}
+
+	report := analyzeFormats(devices, houseStandard)
+
+	assert.EqualValues(t, 1, report.Count(domain.FormatOffStandard))
+	assert.EqualValues(t, []string{"amp"}, report.Findings[0].Devices)
+	assert.EqualValues(t, "amp runs 32 bit instead of 24 bit, 2ms latency instead of 1ms", report.Findings[0].Detail)
+}
+
+func TestAnalyzeFormatsSkipsSettingsWithoutStandard(t *testing.T) {
+	devices := []domain.DeviceInfo{
+		{Name: "amp", Settings: knownSettings(96000, 32, 2000000)},
+	}
+
+	report := analyzeFormats(devices, domain.DeviceSettings{SampleRate: 96000})
+
+	assert.Empty(t, report.Findings)
+	assert.True(t, report.Groups[0].Standard)
+}
+
+func TestAnalyzeFormatsFlagsSubscriptionsBetweenFormats(t *testing.T) {
+	devices := []domain.DeviceInfo{
+		{Name: "stagebox", Settings: knownSettings(48000, 24, 1000000), RxChannels: domain.ChannelList{
+			{Direction: domain.ChannelRx, Number: 1, Name: "In 1", TxChannelName: "Out 1", TxDeviceName: "recorder-dante"},
+			{Direction: domain.ChannelRx, Number: 2, Name: "In 2", TxChannelName: "Out 1", TxDeviceName: "amp"},
+		}},
+		{Name: "recorder", DanteName: "recorder-dante", Settings: knownSettings(96000, 24, 1000000)},
+		{Name: "amp", Settings: knownSettings(48000, 24, 1000000)},
+	}
+
+	report := analyzeFormats(devices, domain.DeviceSettings{})
+
+	assert.EqualValues(t, 1, len(report.Findings))
+	assert.EqualValues(t, domain.FormatMismatch, report.Findings[0].Issue)
+	assert.EqualValues(t, []string{"stagebox", "recorder"}, report.Findings[0].Devices)
+	assert.EqualValues(t, "stagebox channel 1 (In 1) runs 48000 Hz, 24 bit, its source Out 1@recorder runs 96000 Hz, 24 bit", report.Findings[0].Detail)
+}
+
+func TestFormatReportSkipsOfflineDevices(t *testing.T) {
+	var testCfg config.AppConfig
+	config.InitConfig("", &testCfg)
+	repo := repositories.NewDeviceRepository(&testCfg)
+	svc := NewFormatService(&testCfg, &repo)
+	repo.Store(domain.DeviceInfo{Name: "stagebox", LastSeen: time.Now(), Settings: knownSettings(48000, 24, 1000000)})
+	repo.Store(domain.DeviceInfo{Name: "amp", LastSeen: time.Now().Add(-24 * time.Hour), Settings: knownSettings(96000, 24, 1000000)})
+
+	report := svc.Report()
+
+	assert.EqualValues(t, 1, report.Checked)
+	assert.EqualValues(t, houseStandard, report.Standard)
+	assert.Empty(t, report.Findings)
+}
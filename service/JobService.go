@@ -0,0 +1,173 @@
+// package service implements the services and their business logic that provide the main part of the program
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/johannes-kuhfuss/alighieri/config"
+	"github.com/johannes-kuhfuss/services_utils/logger"
+)
+
+// Actions a scheduled job can run
+const (
+	JobActionRecall      = "recall"      // argument: preset name
+	JobActionSubscribe   = "subscribe"   // argument: rxDevice/rxChannel=txChannel@txDevice
+	JobActionUnsubscribe = "unsubscribe" // argument: rxDevice/rxChannel
+)
+
+type JobService interface {
+	Schedule()
+	RunJob(int)
+}
+
+// The JobService schedules the jobs defined in the configuration on the background scheduler and records their results
+type DefaultJobService struct {
+	Cfg     *config.AppConfig
+	Presets PresetService
+	Routing RoutingService
+}
+
+// NewJobService creates a new job service and injects its dependencies
+func NewJobService(cfg *config.AppConfig, presets PresetService, routing RoutingService) DefaultJobService {
+	return DefaultJobService{
+		Cfg:     cfg,
+		Presets: presets,
+		Routing: routing,
+	}
+}
+
+// Schedule adds all configured jobs to the background scheduler. Invalid jobs are listed with their error but not scheduled
+func (s DefaultJobService) Schedule() {
+	var jobs []config.JobStatus
+	for i, def := range s.Cfg.Jobs.ScheduledJobs {
+		status := config.JobStatus{
+			Definition: def,
+		}
+		if err := validateJob(def); err != nil {
+			logger.Errorf("Could not schedule job %v: %v", def.Name, err)
+			status.LastResult = err.Error()
+		} else if id, err := s.Cfg.RunTime.BgJobs.AddFunc(def.Schedule, func() { s.RunJob(i) }); err != nil {
+			logger.Errorf("Could not schedule job %v with schedule %v: %v", def.Name, def.Schedule, err)
+			status.LastResult = fmt.Sprintf("invalid schedule: %v", err)
+		} else {
+			logger.Infof("Scheduled job %v (%v) with schedule %v", def.Name, def.Action, def.Schedule)
+			status.EntryId = int(id)
+		}
+		jobs = append(jobs, status)
+	}
+	s.Cfg.RunTime.Mu.Lock()
+	defer s.Cfg.RunTime.Mu.Unlock()
+	s.Cfg.RunTime.ScheduledJobs = jobs
+}
+
+// RunJob runs the job with the given index and records its result
+func (s DefaultJobService) RunJob(index int) {
+	if index < 0 || index >= len(s.Cfg.Jobs.ScheduledJobs) {
+		return
+	}
+	def := s.Cfg.Jobs.ScheduledJobs[index]
+	logger.Infof("Running job %v (%v %v)", def.Name, def.Action, def.Argument)
+	result, err := s.execute(def)
+	if err != nil {
+		logger.Errorf("Job %v failed: %v", def.Name, err)
+		result = err.Error()
+	} else {
+		logger.Infof("Job %v finished: %v", def.Name, result)
+	}
+	s.Cfg.RunTime.Mu.Lock()
+	defer s.Cfg.RunTime.Mu.Unlock()
+	if index < len(s.Cfg.RunTime.ScheduledJobs) {
+		s.Cfg.RunTime.ScheduledJobs[index].LastRun = time.Now()
+		s.Cfg.RunTime.ScheduledJobs[index].LastResult = result
+		s.Cfg.RunTime.ScheduledJobs[index].LastSuccess = err == nil
+	}
+}
+
+// execute runs the job's action
+func (s DefaultJobService) execute(def config.JobDefinition) (string, error) {
+	switch def.Action {
+	case JobActionRecall:
+		result, apiErr := s.Presets.Recall(def.Argument)
+		if apiErr != nil {
+			return "", errors.New(apiErr.Message())
+		}
+		summary := fmt.Sprintf("%v change(s) applied, %v failed", len(result.Applied), result.FailureCount())
+		if result.FailureCount() > 0 {
+			return "", errors.New(summary)
+		}
+		return summary, nil
+	case JobActionSubscribe:
+		rxDevice, rxChannel, txChannel, txDevice, err := parseSubscription(def.Argument)
+		if err != nil {
+			return "", err
+		}
+		if apiErr := s.Routing.Subscribe(rxDevice, rxChannel, txDevice, txChannel); apiErr != nil {
+			return "", errors.New(apiErr.Message())
+		}
+		return fmt.Sprintf("subscribed %v channel %v to %v@%v", rxDevice, rxChannel, txChannel, txDevice), nil
+	case JobActionUnsubscribe:
+		rxDevice, rxChannel, err := parseChannelRef(def.Argument)
+		if err != nil {
+			return "", err
+		}
+		if apiErr := s.Routing.Unsubscribe(rxDevice, rxChannel); apiErr != nil {
+			return "", errors.New(apiErr.Message())
+		}
+		return fmt.Sprintf("unsubscribed %v channel %v", rxDevice, rxChannel), nil
+	}
+	return "", fmt.Errorf("unknown action %v", def.Action)
+}
+
+// validateJob checks a job definition's action and argument before scheduling it
+func validateJob(def config.JobDefinition) (err error) {
+	if def.Name == "" {
+		return errors.New("job has no name")
+	}
+	switch def.Action {
+	case JobActionRecall:
+		if def.Argument == "" {
+			return errors.New("no preset name given")
+		}
+	case JobActionSubscribe:
+		_, _, _, _, err = parseSubscription(def.Argument)
+	case JobActionUnsubscribe:
+		_, _, err = parseChannelRef(def.Argument)
+	default:
+		err = fmt.Errorf("unknown action %v", def.Action)
+	}
+	return err
+}
+
+// parseSubscription parses a subscription in the format "rxDevice/rxChannel=txChannel@txDevice"
+func parseSubscription(arg string) (rxDevice string, rxChannel int, txChannel string, txDevice string, err error) {
+	rx, tx, found := strings.Cut(arg, "=")
+	if !found {
+		return "", 0, "", "", fmt.Errorf("subscription %q is not in the format rxDevice/rxChannel=txChannel@txDevice", arg)
+	}
+	rxDevice, rxChannel, err = parseChannelRef(rx)
+	if err != nil {
+		return "", 0, "", "", err
+	}
+	at := strings.LastIndex(tx, "@")
+	if at < 1 || at == len(tx)-1 {
+		return "", 0, "", "", fmt.Errorf("transmit channel %q is not in the format txChannel@txDevice", tx)
+	}
+	return rxDevice, rxChannel, tx[:at], tx[at+1:], nil
+}
+
+// parseChannelRef parses a receive channel reference in the format "device/channel"
+func parseChannelRef(ref string) (device string, channel int, err error) {
+	device, number, found := strings.Cut(ref, "/")
+	if !found || device == "" {
+		return "", 0, fmt.Errorf("channel %q is not in the format device/channel", ref)
+	}
+	channel, err = strconv.Atoi(number)
+	if err != nil || channel < 1 {
+		return "", 0, fmt.Errorf("invalid channel number %q", number)
+	}
+	return device, channel, nil
+}
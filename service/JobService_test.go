@@ -0,0 +1,92 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/johannes-kuhfuss/alighieri/config"
+	"github.com/johannes-kuhfuss/alighieri/domain"
+	"github.com/robfig/cron/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	jobSvc DefaultJobService
+)
+
+func setupJobTest(t *testing.T, jobs ...config.JobDefinition) {
+	setupPresetTest(t)
+	presetCfg.Jobs.ScheduledJobs = jobs
+	presetCfg.RunTime.BgJobs = cron.New()
+	jobSvc = NewJobService(&presetCfg, presetSvc, &presetRouting)
+	jobSvc.Schedule()
+}
+
+func TestScheduleInvalidJobsAreNotScheduled(t *testing.T) {
+	setupJobTest(t,
+		config.JobDefinition{Name: "bad schedule", Schedule: "never", Action: JobActionRecall, Argument: "day"},
+		config.JobDefinition{Name: "bad action", Schedule: "@daily", Action: "reboot"},
+		config.JobDefinition{Name: "bad argument", Schedule: "@daily", Action: JobActionSubscribe, Argument: "stagebox/1"},
+		config.JobDefinition{Name: "good", Schedule: "@daily", Action: JobActionUnsubscribe, Argument: "stagebox/1"},
+	)
+
+	assert.EqualValues(t, 4, len(presetCfg.RunTime.ScheduledJobs))
+	assert.EqualValues(t, 1, len(presetCfg.RunTime.BgJobs.Entries()))
+	assert.EqualValues(t, 0, presetCfg.RunTime.ScheduledJobs[0].EntryId)
+	assert.Contains(t, presetCfg.RunTime.ScheduledJobs[1].LastResult, "unknown action")
+	assert.NotEqualValues(t, 0, presetCfg.RunTime.ScheduledJobs[3].EntryId)
+}
+
+func TestRunJobRecallRecordsSuccess(t *testing.T) {
+	setupJobTest(t, config.JobDefinition{Name: "evening", Schedule: "@daily", Action: JobActionRecall, Argument: "evening"})
+	presetRepo.Store(domain.Preset{
+		Name: "evening",
+		Subscriptions: []domain.PresetSubscription{
+			{RxDevice: "stagebox", RxChannel: 2, TxDevice: "mixer", TxChannel: "Out 2"},
+		},
+	})
+	jobSvc.RunJob(0)
+
+	status := presetCfg.RunTime.ScheduledJobs[0]
+	assert.True(t, status.LastSuccess)
+	assert.False(t, status.LastRun.IsZero())
+	assert.EqualValues(t, "1 change(s) applied, 0 failed", status.LastResult)
+}
+
+func TestRunJobRecallWithFailuresRecordsFailure(t *testing.T) {
+	setupJobTest(t, config.JobDefinition{Name: "evening", Schedule: "@daily", Action: JobActionRecall, Argument: "evening"})
+	presetRouting.failDevice = "stagebox"
+	presetRepo.Store(domain.Preset{
+		Name: "evening",
+		Subscriptions: []domain.PresetSubscription{
+			{RxDevice: "stagebox", RxChannel: 2, TxDevice: "mixer", TxChannel: "Out 2"},
+		},
+	})
+	jobSvc.RunJob(0)
+
+	assert.False(t, presetCfg.RunTime.ScheduledJobs[0].LastSuccess)
+	assert.EqualValues(t, "0 change(s) applied, 1 failed", presetCfg.RunTime.ScheduledJobs[0].LastResult)
+}
+
+func TestRunJobSubscribeCallsRouting(t *testing.T) {
+	setupJobTest(t, config.JobDefinition{Name: "patch", Schedule: "@daily", Action: JobActionSubscribe, Argument: "stagebox/2=Out 2@mixer"})
+	jobSvc.RunJob(0)
+
+	assert.True(t, presetCfg.RunTime.ScheduledJobs[0].LastSuccess)
+	assert.EqualValues(t, []string{"subscribe stagebox Out 2@mixer"}, presetRouting.calls)
+}
+
+func TestParseSubscriptionReturnsParts(t *testing.T) {
+	rxDevice, rxChannel, txChannel, txDevice, err := parseSubscription("stagebox/12=Out@1@mixer")
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, "stagebox", rxDevice)
+	assert.EqualValues(t, 12, rxChannel)
+	assert.EqualValues(t, "Out@1", txChannel)
+	assert.EqualValues(t, "mixer", txDevice)
+}
+
+func TestParseChannelRefInvalidNumberReturnsError(t *testing.T) {
+	_, _, err := parseChannelRef("stagebox/zero")
+
+	assert.NotNil(t, err)
+}
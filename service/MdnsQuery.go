@@ -0,0 +1,197 @@
+// package service implements the services and their business logic that provide the main part of the program
+package service
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/johannes-kuhfuss/mdns"
+	"github.com/miekg/dns"
+	"golang.org/x/net/ipv4"
+)
+
+// queryInterface sends an mDNS query for a service type out of one network interface and collects the entries answered until the time out.
+// The query is sent from an ephemeral port, so responders answer by unicast directly to this socket (RFC 6762, section 6.7).
+// Other than the mDNS client, it does not need port 5353 and can run on several interfaces at the same time
+func queryInterface(iface *net.Interface, group *net.UDPAddr, service string, timeout time.Duration) (entries []*mdns.ServiceEntry, err error) {
+	ip, err := interfaceIPv4(iface)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: ip})
+	if err != nil {
+		return nil, fmt.Errorf("could not open socket on interface %v: %w", iface.Name, err)
+	}
+	defer conn.Close()
+	p := ipv4.NewPacketConn(conn)
+	if err := p.SetMulticastInterface(iface); err != nil {
+		return nil, fmt.Errorf("could not send multicast on interface %v: %w", iface.Name, err)
+	}
+	p.SetMulticastTTL(255)
+
+	send := func(name string) error {
+		m := new(dns.Msg)
+		m.SetQuestion(name, dns.TypePTR)
+		m.RecursionDesired = false
+		buf, err := m.Pack()
+		if err != nil {
+			return err
+		}
+		_, err = conn.WriteToUDP(buf, group)
+		return err
+	}
+	serviceName := strings.Trim(service, ".") + ".local."
+	if err := send(serviceName); err != nil {
+		return nil, fmt.Errorf("could not send query on interface %v: %w", iface.Name, err)
+	}
+
+	records := newMdnsRecords(serviceName)
+	asked := make(map[string]bool)
+	deadline := time.Now().Add(timeout)
+	conn.SetReadDeadline(deadline)
+	buf := make([]byte, 65536)
+	for {
+		n, _, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			var ne net.Error
+			if errors.As(err, &ne) && ne.Timeout() {
+				break
+			}
+			return nil, fmt.Errorf("could not read answers on interface %v: %w", iface.Name, err)
+		}
+		var msg dns.Msg
+		if err := msg.Unpack(buf[:n]); err != nil {
+			continue
+		}
+		records.add(append(msg.Answer, msg.Extra...))
+		// ask devices answering with an incomplete record for their details, like the mDNS client does
+		for _, name := range records.incomplete() {
+			if !asked[name] {
+				asked[name] = true
+				send(name)
+			}
+		}
+	}
+	return records.entries(), nil
+}
+
+// interfaceIPv4 returns the first IPv4 address of a network interface
+func interfaceIPv4(iface *net.Interface) (net.IP, error) {
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return nil, fmt.Errorf("could not read addresses of interface %v: %w", iface.Name, err)
+	}
+	for _, addr := range addrs {
+		if ipNet, ok := addr.(*net.IPNet); ok && ipNet.IP.To4() != nil {
+			return ipNet.IP.To4(), nil
+		}
+	}
+	return nil, fmt.Errorf("interface %v has no IPv4 address", iface.Name)
+}
+
+// mdnsRecords collects the records of all answers to a query, as a device's PTR, SRV, TXT, A and AAAA records may arrive in separate answers
+type mdnsRecords struct {
+	service   string
+	instances []string
+	srv       map[string]*dns.SRV
+	txt       map[string][]string
+	a         map[string]net.IP
+	aaaa      map[string]net.IP
+}
+
+func newMdnsRecords(service string) *mdnsRecords {
+	return &mdnsRecords{
+		service: service,
+		srv:     make(map[string]*dns.SRV),
+		txt:     make(map[string][]string),
+		a:       make(map[string]net.IP),
+		aaaa:    make(map[string]net.IP),
+	}
+}
+
+// add sorts the records by type. Only instances of the queried service type are collected. Records with a TTL of 0 are goodbyes and ignored
+func (r *mdnsRecords) add(rrs []dns.RR) {
+	for _, rr := range rrs {
+		if rr.Header().Ttl == 0 {
+			continue
+		}
+		switch rr := rr.(type) {
+		case *dns.PTR:
+			if rr.Hdr.Name == r.service && !r.known(rr.Ptr) {
+				r.instances = append(r.instances, rr.Ptr)
+			}
+		case *dns.SRV:
+			if strings.HasSuffix(rr.Hdr.Name, "."+r.service) && !r.known(rr.Hdr.Name) {
+				r.instances = append(r.instances, rr.Hdr.Name)
+			}
+			r.srv[rr.Hdr.Name] = rr
+		case *dns.TXT:
+			r.txt[rr.Hdr.Name] = rr.Txt
+		case *dns.A:
+			r.a[rr.Hdr.Name] = rr.A
+		case *dns.AAAA:
+			r.aaaa[rr.Hdr.Name] = rr.AAAA
+		}
+	}
+}
+
+// known checks whether an instance has already been announced
+func (r *mdnsRecords) known(name string) bool {
+	for _, instance := range r.instances {
+		if instance == name {
+			return true
+		}
+	}
+	return false
+}
+
+// entry assembles the entry of an instance. Returns nil while IPv4 address, port or TXT record are missing. The IPv6 address is optional
+func (r *mdnsRecords) entry(name string) *mdns.ServiceEntry {
+	srv, ok := r.srv[name]
+	if !ok {
+		return nil
+	}
+	txt, ok := r.txt[name]
+	if !ok {
+		return nil
+	}
+	addr, ok := r.a[srv.Target]
+	if !ok {
+		return nil
+	}
+	entry := &mdns.ServiceEntry{
+		Name:       name,
+		Host:       srv.Target,
+		AddrV4:     addr,
+		Port:       int(srv.Port),
+		Info:       strings.Join(txt, "|"),
+		InfoFields: txt,
+	}
+	if addr6, ok := r.aaaa[srv.Target]; ok {
+		entry.AddrV6IPAddr = &net.IPAddr{IP: addr6}
+	}
+	return entry
+}
+
+// incomplete returns the instances still missing records
+func (r *mdnsRecords) incomplete() (names []string) {
+	for _, name := range r.instances {
+		if r.entry(name) == nil {
+			names = append(names, name)
+		}
+	}
+	return
+}
+
+// entries returns the complete entries in the order the instances were announced
+func (r *mdnsRecords) entries() (entries []*mdns.ServiceEntry) {
+	for _, name := range r.instances {
+		if e := r.entry(name); e != nil {
+			entries = append(entries, e)
+		}
+	}
+	return
+}
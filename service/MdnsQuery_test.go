@@ -0,0 +1,80 @@
+package service
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/assert"
+)
+
+// startMdnsResponder answers every query with the records of one Dante device, like a device answering a legacy unicast query
+func startMdnsResponder(t *testing.T) *net.UDPAddr {
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	go func() {
+		buf := make([]byte, 9000)
+		for {
+			n, src, err := conn.ReadFromUDP(buf)
+			if err != nil {
+				return
+			}
+			var query dns.Msg
+			if err := query.Unpack(buf[:n]); err != nil {
+				continue
+			}
+			var resp dns.Msg
+			resp.SetReply(&query)
+			resp.Answer = []dns.RR{
+				&dns.PTR{Hdr: dns.RR_Header{Name: "_netaudio-arc._udp.local.", Rrtype: dns.TypePTR, Class: dns.ClassINET, Ttl: 120}, Ptr: "stagebox._netaudio-arc._udp.local."},
+			}
+			resp.Extra = []dns.RR{
+				&dns.SRV{Hdr: dns.RR_Header{Name: "stagebox._netaudio-arc._udp.local.", Rrtype: dns.TypeSRV, Class: dns.ClassINET, Ttl: 120}, Port: 4440, Target: "stagebox.local."},
+				&dns.TXT{Hdr: dns.RR_Header{Name: "stagebox._netaudio-arc._udp.local.", Rrtype: dns.TypeTXT, Class: dns.ClassINET, Ttl: 120}, Txt: []string{"mf=Audinate", "model=DAI2"}},
+				&dns.A{Hdr: dns.RR_Header{Name: "stagebox.local.", Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 120}, A: net.ParseIP("192.168.2.10")},
+				&dns.AAAA{Hdr: dns.RR_Header{Name: "stagebox.local.", Rrtype: dns.TypeAAAA, Class: dns.ClassINET, Ttl: 120}, AAAA: net.ParseIP("fe80::10")},
+				&dns.SRV{Hdr: dns.RR_Header{Name: "stagebox._netaudio-cmc._udp.local.", Rrtype: dns.TypeSRV, Class: dns.ClassINET, Ttl: 120}, Port: 8800, Target: "stagebox.local."},
+			}
+			data, _ := resp.Pack()
+			conn.WriteToUDP(data, src)
+		}
+	}()
+	return conn.LocalAddr().(*net.UDPAddr)
+}
+
+func TestQueryInterfaceReturnsAnsweredEntries(t *testing.T) {
+	lo, err := net.InterfaceByName("lo")
+	if err != nil {
+		t.Skip("no loopback interface named lo")
+	}
+	responder := startMdnsResponder(t)
+
+	entries, err := queryInterface(lo, responder, "_netaudio-arc._udp", 200*time.Millisecond)
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, 1, len(entries))
+	assert.EqualValues(t, "stagebox._netaudio-arc._udp.local.", entries[0].Name)
+	assert.EqualValues(t, "stagebox.local.", entries[0].Host)
+	assert.EqualValues(t, 4440, entries[0].Port)
+	assert.EqualValues(t, "192.168.2.10", entries[0].AddrV4.String())
+	assert.EqualValues(t, "fe80::10", entries[0].AddrV6IPAddr.String())
+	assert.EqualValues(t, []string{"mf=Audinate", "model=DAI2"}, entries[0].InfoFields)
+}
+
+func TestMdnsRecordsIgnoresGoodbyesAndOtherServices(t *testing.T) {
+	records := newMdnsRecords("_netaudio-arc._udp.local.")
+
+	records.add([]dns.RR{
+		&dns.PTR{Hdr: dns.RR_Header{Name: "_netaudio-arc._udp.local.", Rrtype: dns.TypePTR, Ttl: 0}, Ptr: "leaving._netaudio-arc._udp.local."},
+		&dns.PTR{Hdr: dns.RR_Header{Name: "_netaudio-cmc._udp.local.", Rrtype: dns.TypePTR, Ttl: 120}, Ptr: "other._netaudio-cmc._udp.local."},
+		&dns.PTR{Hdr: dns.RR_Header{Name: "_netaudio-arc._udp.local.", Rrtype: dns.TypePTR, Ttl: 120}, Ptr: "amp._netaudio-arc._udp.local."},
+	})
+
+	assert.EqualValues(t, []string{"amp._netaudio-arc._udp.local."}, records.instances)
+	assert.EqualValues(t, []string{"amp._netaudio-arc._udp.local."}, records.incomplete())
+	assert.Nil(t, records.entries())
+}
@@ -0,0 +1,297 @@
+// package service implements the services and their business logic that provide the main part of the program
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/johannes-kuhfuss/alighieri/config"
+	"github.com/johannes-kuhfuss/alighieri/domain"
+	"github.com/johannes-kuhfuss/alighieri/dto"
+	"github.com/johannes-kuhfuss/alighieri/repositories"
+	"github.com/johannes-kuhfuss/services_utils/logger"
+)
+
+// Payloads of the availability topic. "offline" is also the Last Will sent by the broker when the connection is lost
+const (
+	MqttOnline  = "online"
+	MqttOffline = "offline"
+)
+
+type MqttService interface {
+	Start() error
+	Stop()
+	Notify(domain.Event)
+}
+
+// The MqttService publishes the device inventory as retained per-device topics and the device events to an MQTT broker.
+// Topics below the prefix: status (own availability), devices/<name>/state, devices/<name>/info (JSON) and events (JSON)
+type DefaultMqttService struct {
+	Cfg       *config.AppConfig
+	Repo      *repositories.DefaultDeviceRepository
+	Broadcast BroadcastService
+	conn      *mqttConnection
+}
+
+type mqttConnection struct {
+	sync.Mutex
+	client      mqtt.Client
+	published   map[string][]byte // last payload per retained topic, to skip unchanged updates
+	stop        chan bool
+	done        chan bool
+	unsubscribe func()
+	pending     sync.WaitGroup // events being published
+}
+
+var (
+	topicReplacer = strings.NewReplacer("/", "_", "+", "_", "#", "_")
+)
+
+// NewMqttService creates a new MQTT service and injects its dependencies. Call Start to connect to the broker
+func NewMqttService(cfg *config.AppConfig, repo *repositories.DefaultDeviceRepository, broadcast BroadcastService) DefaultMqttService {
+	return DefaultMqttService{
+		Cfg:       cfg,
+		Repo:      repo,
+		Broadcast: broadcast,
+		conn: &mqttConnection{
+			published: make(map[string][]byte),
+		},
+	}
+}
+
+// Start connects to the broker and keeps the device topics up to date until Stop is called. Does nothing if no broker is configured.
+// If the broker cannot be reached, the client keeps retrying in the background
+func (s DefaultMqttService) Start() error {
+	if s.Cfg.Mqtt.Broker == "" {
+		logger.Info("No MQTT broker configured. Publishing to MQTT is disabled.")
+		return nil
+	}
+	s.conn.Lock()
+	defer s.conn.Unlock()
+	if s.conn.client != nil {
+		return errors.New("MQTT service already started")
+	}
+	opts := mqtt.NewClientOptions().
+		AddBroker(s.Cfg.Mqtt.Broker).
+		SetClientID(s.Cfg.Mqtt.ClientId).
+		SetUsername(s.Cfg.Mqtt.UserName).
+		SetPassword(s.Cfg.Mqtt.Password).
+		SetWill(s.topic("status"), MqttOffline, s.Cfg.Mqtt.Qos, true).
+		SetConnectTimeout(s.timeOut()).
+		SetAutoReconnect(true).
+		SetConnectRetry(true).
+		SetOnConnectHandler(func(mqtt.Client) { go s.onConnect() }).
+		SetConnectionLostHandler(func(_ mqtt.Client, err error) {
+			logger.Error("Lost connection to MQTT broker", err)
+		})
+	s.conn.client = mqtt.NewClient(opts)
+	s.conn.stop = make(chan bool)
+	s.conn.done = make(chan bool)
+	token := s.conn.client.Connect()
+	if token.WaitTimeout(s.timeOut()) && token.Error() != nil {
+		s.conn.client = nil
+		return token.Error()
+	}
+	updates, unsubscribe := s.Broadcast.Subscribe()
+	s.conn.unsubscribe = unsubscribe
+	go s.run(updates)
+	return nil
+}
+
+// Stop announces alighieri as offline and disconnects from the broker. The device topics stay retained
+func (s DefaultMqttService) Stop() {
+	s.conn.Lock()
+	client := s.conn.client
+	if client == nil {
+		s.conn.Unlock()
+		return
+	}
+	s.conn.unsubscribe()
+	close(s.conn.stop)
+	s.conn.Unlock()
+	<-s.conn.done
+	s.conn.pending.Wait()
+	if client.IsConnected() {
+		s.publish(s.topic("status"), []byte(MqttOffline), true)
+	}
+	client.Disconnect(uint(s.timeOut().Milliseconds()))
+	s.conn.Lock()
+	s.conn.client = nil
+	s.conn.Unlock()
+	logger.Info("Disconnected from MQTT broker")
+}
+
+// Notify publishes an event on the events topic. Publishing runs in the background, so a slow broker does not hold up the scan
+func (s DefaultMqttService) Notify(e domain.Event) {
+	if !s.connected() {
+		return
+	}
+	payload, err := json.Marshal(newEventPayload(e))
+	if err != nil {
+		logger.Error("Could not convert event for MQTT", err)
+		return
+	}
+	s.conn.pending.Add(1)
+	go func() {
+		defer s.conn.pending.Done()
+		s.publish(s.topic("events"), payload, false)
+	}()
+}
+
+// run updates the device topics on device changes and at every scan cycle, as devices become stale or offline without being changed
+func (s DefaultMqttService) run(updates <-chan domain.Notification) {
+	defer close(s.conn.done)
+	interval := time.Duration(s.Cfg.DeviceScan.ScanCycleSec) * time.Second
+	if interval < time.Second {
+		interval = time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.conn.stop:
+			return
+		case n, ok := <-updates:
+			if !ok {
+				return
+			}
+			switch n.Type {
+			case domain.NotificationDeviceAdded, domain.NotificationDeviceChanged:
+				s.publishDevice(n.Device)
+			case domain.NotificationDeviceRemoved:
+				s.clearDevice(n.Device)
+			}
+		case <-ticker.C:
+			s.publishAll()
+		}
+	}
+}
+
+// onConnect announces alighieri as online and publishes the whole inventory, as the broker may have lost the retained topics
+func (s DefaultMqttService) onConnect() {
+	logger.Infof("Connected to MQTT broker %v", s.Cfg.Mqtt.Broker)
+	s.conn.Lock()
+	s.conn.published = make(map[string][]byte)
+	s.conn.Unlock()
+	s.publish(s.topic("status"), []byte(MqttOnline), true)
+	s.publishAll()
+}
+
+// publishAll publishes all devices in the repository and clears the topics of devices no longer in the repository
+func (s DefaultMqttService) publishAll() {
+	if !s.connected() {
+		return
+	}
+	names := make(map[string]bool)
+	if devices := s.Repo.GetAll(); devices != nil {
+		for _, device := range *devices {
+			names[s.deviceTopic(device.Name, "state")] = true
+			s.publishDevice(device.Name)
+		}
+	}
+	var gone []string
+	s.conn.Lock()
+	for topic := range s.conn.published {
+		if device, ok := strings.CutSuffix(topic, "/state"); ok && !names[topic] {
+			gone = append(gone, device)
+		}
+	}
+	s.conn.Unlock()
+	for _, device := range gone {
+		s.clearTopics(device)
+	}
+}
+
+// publishDevice publishes the state and info topics of a device
+func (s DefaultMqttService) publishDevice(name string) {
+	device := dto.GetDeviceByName(s.Repo, name)
+	if device == nil || !s.connected() {
+		return
+	}
+	info, err := json.Marshal(device)
+	if err != nil {
+		logger.Error("Could not convert device for MQTT", err)
+		return
+	}
+	s.publish(s.deviceTopic(name, "state"), []byte(device.State), true)
+	s.publish(s.deviceTopic(name, "info"), info, true)
+}
+
+// clearDevice removes the retained topics of a removed device
+func (s DefaultMqttService) clearDevice(name string) {
+	if s.connected() {
+		s.clearTopics(s.deviceTopic(name, ""))
+	}
+}
+
+// clearTopics removes the retained state and info topics below a device topic by publishing empty payloads
+func (s DefaultMqttService) clearTopics(device string) {
+	for _, topic := range []string{device + "/state", device + "/info"} {
+		s.publish(topic, nil, true)
+		s.conn.Lock()
+		delete(s.conn.published, topic)
+		s.conn.Unlock()
+	}
+}
+
+// publish sends a payload to the broker. Retained payloads equal to the last one published successfully are skipped
+func (s DefaultMqttService) publish(topic string, payload []byte, retained bool) {
+	s.conn.Lock()
+	client := s.conn.client
+	if client == nil {
+		s.conn.Unlock()
+		return
+	}
+	if retained && payload != nil {
+		if last, ok := s.conn.published[topic]; ok && bytes.Equal(last, payload) {
+			s.conn.Unlock()
+			return
+		}
+	}
+	s.conn.Unlock()
+	token := client.Publish(topic, s.Cfg.Mqtt.Qos, retained, payload)
+	if !token.WaitTimeout(s.timeOut()) {
+		logger.Warnf("Timeout while publishing MQTT topic %v", topic)
+		return
+	}
+	if err := token.Error(); err != nil {
+		logger.Errorf("Could not publish MQTT topic %v: %v", topic, err)
+		return
+	}
+	if retained && payload != nil {
+		s.conn.Lock()
+		s.conn.published[topic] = payload
+		s.conn.Unlock()
+	}
+}
+
+// connected checks whether the client is connected to the broker
+func (s DefaultMqttService) connected() bool {
+	s.conn.Lock()
+	defer s.conn.Unlock()
+	return s.conn.client != nil && s.conn.client.IsConnected()
+}
+
+// topic returns the full topic name below the configured prefix
+func (s DefaultMqttService) topic(name string) string {
+	return strings.TrimSuffix(s.Cfg.Mqtt.TopicPrefix, "/") + "/" + name
+}
+
+// deviceTopic returns the topic of a device property. Characters with a special meaning in topics are replaced in the device name
+func (s DefaultMqttService) deviceTopic(device string, property string) string {
+	topic := s.topic("devices/" + topicReplacer.Replace(device))
+	if property == "" {
+		return topic
+	}
+	return topic + "/" + property
+}
+
+// timeOut returns the configured time to wait for the broker
+func (s DefaultMqttService) timeOut() time.Duration {
+	return time.Duration(s.Cfg.Mqtt.TimeOutSec) * time.Second
+}
@@ -0,0 +1,180 @@
+package service
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/johannes-kuhfuss/alighieri/config"
+	"github.com/johannes-kuhfuss/alighieri/domain"
+	"github.com/johannes-kuhfuss/alighieri/repositories"
+	mqttserver "github.com/mochi-mqtt/server/v2"
+	"github.com/mochi-mqtt/server/v2/hooks/auth"
+	"github.com/mochi-mqtt/server/v2/listeners"
+	"github.com/mochi-mqtt/server/v2/packets"
+	"github.com/stretchr/testify/assert"
+)
+
+// mqttRecorder keeps all messages received by the embedded test broker per topic
+type mqttRecorder struct {
+	sync.Mutex
+	messages map[string][]string
+}
+
+func (r *mqttRecorder) last(topic string) (string, bool) {
+	r.Lock()
+	defer r.Unlock()
+	if len(r.messages[topic]) == 0 {
+		return "", false
+	}
+	return r.messages[topic][len(r.messages[topic])-1], true
+}
+
+func (r *mqttRecorder) all(topic string) []string {
+	r.Lock()
+	defer r.Unlock()
+	return append([]string(nil), r.messages[topic]...)
+}
+
+// startTestBroker starts an embedded MQTT broker on a free local port and records all messages published to it
+func startTestBroker(t *testing.T) (*mqttserver.Server, string, *mqttRecorder) {
+	server := mqttserver.New(&mqttserver.Options{
+		InlineClient: true,
+		Logger:       slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+	server.AddHook(new(auth.AllowHook), nil)
+	tcp := listeners.NewTCP(listeners.Config{ID: "test", Address: "127.0.0.1:0"})
+	if err := server.AddListener(tcp); err != nil {
+		t.Fatal(err)
+	}
+	recorder := &mqttRecorder{messages: make(map[string][]string)}
+	server.Subscribe("#", 1, func(cl *mqttserver.Client, sub packets.Subscription, pk packets.Packet) {
+		recorder.Lock()
+		defer recorder.Unlock()
+		recorder.messages[pk.TopicName] = append(recorder.messages[pk.TopicName], string(pk.Payload))
+	})
+	go server.Serve()
+	t.Cleanup(func() { server.Close() })
+	return server, "tcp://" + tcp.Address(), recorder
+}
+
+func setupMqttTest(t *testing.T, broker string) (DefaultMqttService, *repositories.DefaultDeviceRepository) {
+	var testCfg config.AppConfig
+	config.InitConfig("", &testCfg)
+	testCfg.Mqtt.Broker = broker
+	testCfg.Mqtt.TimeOutSec = 2
+	repo := repositories.NewDeviceRepository(&testCfg)
+	broadcast := NewBroadcastService(&testCfg)
+	repo.Watch(broadcast.DeviceChanged)
+	s := NewMqttService(&testCfg, &repo, broadcast)
+	t.Cleanup(s.Stop)
+	return s, &repo
+}
+
+func TestMqttStartWithoutBrokerDoesNothing(t *testing.T) {
+	s, _ := setupMqttTest(t, "")
+
+	err := s.Start()
+	s.Notify(domain.Event{Type: domain.EventNewDevice, Device: "stagebox"})
+
+	assert.Nil(t, err)
+	assert.False(t, s.connected())
+}
+
+func TestMqttStartPublishesStatusAndInventory(t *testing.T) {
+	_, broker, recorder := startTestBroker(t)
+	s, repo := setupMqttTest(t, broker)
+	repo.Store(domain.DeviceInfo{Name: "stagebox", Model: "DAI2", LastSeen: time.Now()})
+
+	err := s.Start()
+
+	assert.Nil(t, err)
+	assert.Eventually(t, func() bool {
+		status, _ := recorder.last("alighieri/status")
+		state, _ := recorder.last("alighieri/devices/stagebox/state")
+		info, _ := recorder.last("alighieri/devices/stagebox/info")
+		return status == MqttOnline && state == string(domain.DeviceOnline) && strings.Contains(info, `"model":"DAI2"`)
+	}, 5*time.Second, 20*time.Millisecond)
+}
+
+func TestMqttDeviceChangesUpdateTopics(t *testing.T) {
+	_, broker, recorder := startTestBroker(t)
+	s, repo := setupMqttTest(t, broker)
+	s.Start()
+	assert.Eventually(t, s.connected, 5*time.Second, 20*time.Millisecond)
+
+	repo.Store(domain.DeviceInfo{Name: "amp/1", LastSeen: time.Now()})
+	assert.Eventually(t, func() bool {
+		state, _ := recorder.last("alighieri/devices/amp_1/state")
+		return state == string(domain.DeviceOnline)
+	}, 5*time.Second, 20*time.Millisecond)
+	repo.Delete("amp/1")
+
+	assert.Eventually(t, func() bool {
+		state, ok := recorder.last("alighieri/devices/amp_1/state")
+		return ok && state == ""
+	}, 5*time.Second, 20*time.Millisecond)
+}
+
+func TestMqttNotifyPublishesEvent(t *testing.T) {
+	_, broker, recorder := startTestBroker(t)
+	s, _ := setupMqttTest(t, broker)
+	s.Start()
+	assert.Eventually(t, s.connected, 5*time.Second, 20*time.Millisecond)
+
+	s.Notify(domain.Event{Type: domain.EventIpChanged, Device: "stagebox", OldValue: "192.168.1.10", NewValue: "192.168.1.20"})
+
+	assert.Eventually(t, func() bool {
+		event, _ := recorder.last("alighieri/events")
+		return strings.Contains(event, `"newValue":"192.168.1.20"`)
+	}, 5*time.Second, 20*time.Millisecond)
+}
+
+func TestMqttFailedPublishIsRetried(t *testing.T) {
+	s, _ := setupMqttTest(t, "tcp://127.0.0.1:1")
+	s.conn.client = mqtt.NewClient(mqtt.NewClientOptions().AddBroker(s.Cfg.Mqtt.Broker))
+	defer func() { s.conn.client = nil }()
+
+	s.publish(s.topic("status"), []byte(MqttOnline), true)
+
+	assert.NotContains(t, s.conn.published, s.topic("status"))
+}
+
+func TestMqttStopPublishesOffline(t *testing.T) {
+	_, broker, recorder := startTestBroker(t)
+	s, _ := setupMqttTest(t, broker)
+	s.Start()
+	assert.Eventually(t, func() bool {
+		status, _ := recorder.last("alighieri/status")
+		return status == MqttOnline
+	}, 5*time.Second, 20*time.Millisecond)
+
+	s.Stop()
+	status, _ := recorder.last("alighieri/status")
+
+	assert.EqualValues(t, MqttOffline, status)
+	assert.False(t, s.connected())
+}
+
+func TestMqttLostConnectionSendsLastWill(t *testing.T) {
+	server, broker, recorder := startTestBroker(t)
+	s, _ := setupMqttTest(t, broker)
+	s.Start()
+	assert.Eventually(t, func() bool {
+		status, _ := recorder.last("alighieri/status")
+		return status == MqttOnline
+	}, 5*time.Second, 20*time.Millisecond)
+
+	cl, _ := server.Clients.Get(s.Cfg.Mqtt.ClientId)
+	cl.Stop(errors.New("connection dropped"))
+
+	assert.Eventually(t, func() bool {
+		return slices.Contains(recorder.all("alighieri/status"), MqttOffline)
+	}, 5*time.Second, 20*time.Millisecond)
+}
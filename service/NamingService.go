@@ -0,0 +1,162 @@
+// package service implements the services and their business logic that provide the main part of the program
+package service
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/johannes-kuhfuss/alighieri/config"
+	"github.com/johannes-kuhfuss/alighieri/domain"
+	"github.com/johannes-kuhfuss/alighieri/repositories"
+	"github.com/johannes-kuhfuss/services_utils/logger"
+)
+
+type NamingService interface {
+	Report() domain.NamingReport
+	ApplyRenames(bool) []domain.NamingRename
+}
+
+// The NamingService checks the device names against the naming policy and renames devices to the names it suggests
+type DefaultNamingService struct {
+	Cfg       *config.AppConfig
+	Repo      *repositories.DefaultDeviceRepository
+	RenameSvc RenameService
+}
+
+var (
+	// nameSeparators matches everything between the parts of a name, e.g. spaces and underscores
+	nameSeparators = regexp.MustCompile(`[^A-Z0-9]+`)
+)
+
+// NewNamingService creates a new naming service and injects its dependencies
+func NewNamingService(cfg *config.AppConfig, repo *repositories.DefaultDeviceRepository, renameSvc RenameService) DefaultNamingService {
+	return DefaultNamingService{
+		Cfg:       cfg,
+		Repo:      repo,
+		RenameSvc: renameSvc,
+	}
+}
+
+// Report checks the names of all devices in the repository
+func (s DefaultNamingService) Report() domain.NamingReport {
+	var devices []domain.DeviceInfo
+	if all := s.Repo.GetAll(); all != nil {
+		devices = *all
+	}
+	return analyzeNames(devices, s.Cfg)
+}
+
+// ApplyRenames renames all devices a new name is suggested for. With dryRun set, the renames are only listed. The new names show up with the next scan
+func (s DefaultNamingService) ApplyRenames(dryRun bool) []domain.NamingRename {
+	renames := s.Report().Renames
+	if dryRun {
+		return renames
+	}
+	for i, rename := range renames {
+		if apiErr := s.RenameSvc.RenameDevice(rename.Key, rename.NewName); apiErr != nil {
+			renames[i].Error = apiErr.Message()
+			continue
+		}
+		renames[i].Applied = true
+	}
+	logger.Infof("Applied %v of %v suggested renames", countApplied(renames), len(renames))
+	return renames
+}
+
+// analyzeNames checks each device name against the pattern applying to the device and, if configured, for names only differing in case.
+// Of devices sharing a name, the first one in alphabetical order keeps it
+func analyzeNames(devices []domain.DeviceInfo, cfg *config.AppConfig) (report domain.NamingReport) {
+	sort.SliceStable(devices, func(i, j int) bool {
+		if devices[i].Name != devices[j].Name {
+			return devices[i].Name < devices[j].Name
+		}
+		return devices[i].Key() < devices[j].Key()
+	})
+	taken := make(map[string]string)
+	for _, device := range devices {
+		for _, name := range []string{device.Name, device.DanteName} {
+			if _, ok := taken[strings.ToLower(name)]; name != "" && !ok {
+				taken[strings.ToLower(name)] = device.Key()
+			}
+		}
+	}
+	for _, device := range devices {
+		report.Checked++
+		pattern := namingPattern(cfg, device)
+		compliant := true
+		if pattern != nil && !pattern.MatchString(device.Name) {
+			compliant = false
+			report.Findings = append(report.Findings, domain.NamingFinding{
+				Device: device.Name,
+				Issue:  domain.NamingPattern,
+				Detail: fmt.Sprintf("%v does not match %v", device.Name, pattern),
+			})
+		}
+		if owner := taken[strings.ToLower(device.Name)]; cfg.NamingPolicy.UniqueNames && owner != device.Key() {
+			compliant = false
+			report.Findings = append(report.Findings, domain.NamingFinding{
+				Device: device.Name,
+				Issue:  domain.NamingDuplicate,
+				Detail: fmt.Sprintf("%v is also used by another device", device.Name),
+			})
+		}
+		if compliant {
+			report.Compliant++
+			continue
+		}
+		if newName := suggestName(device.Name, device.Key(), pattern, taken); newName != "" {
+			taken[strings.ToLower(newName)] = device.Key()
+			report.Renames = append(report.Renames, domain.NamingRename{Device: device.Name, Key: device.Key(), NewName: newName})
+		}
+	}
+	return
+}
+
+// namingPattern returns the pattern of the first rule matching the device's manufacturer and model or the house pattern if no rule matches
+func namingPattern(cfg *config.AppConfig, device domain.DeviceInfo) *regexp.Regexp {
+	for _, rule := range cfg.NamingPolicy.Rules {
+		if rule.Matches(device.Manufacturer, device.Model) {
+			return rule.Pattern.Regexp
+		}
+	}
+	return cfg.NamingPolicy.Pattern.Regexp
+}
+
+// suggestName derives a compliant name from the current one by converting it to upper case, joining its parts with hyphens
+// and using two digits for a trailing number, e.g. "studio a_mic 3" becomes "STUDIO-A-MIC-03". A name taken by another device gets the next free number.
+// Returns an empty string if no such name matches the pattern
+func suggestName(name string, key string, pattern *regexp.Regexp, taken map[string]string) string {
+	parts := strings.Split(strings.Trim(nameSeparators.ReplaceAllString(strings.ToUpper(name), "-"), "-"), "-")
+	number, err := strconv.Atoi(parts[len(parts)-1])
+	hasNumber := err == nil
+	for tries := 0; tries < 100; tries++ {
+		if hasNumber {
+			parts[len(parts)-1] = fmt.Sprintf("%02d", number)
+		}
+		candidate := strings.Join(parts, "-")
+		if domain.ValidateDeviceName(candidate) != nil || (pattern != nil && !pattern.MatchString(candidate)) {
+			return ""
+		}
+		if owner, ok := taken[strings.ToLower(candidate)]; !ok || owner == key {
+			return candidate
+		}
+		if !hasNumber {
+			return ""
+		}
+		number++
+	}
+	return ""
+}
+
+// countApplied returns the number of renames sent to the devices successfully
+func countApplied(renames []domain.NamingRename) (count int) {
+	for _, rename := range renames {
+		if rename.Applied {
+			count++
+		}
+	}
+	return
+}
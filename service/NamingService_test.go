@@ -0,0 +1,130 @@
+package service
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/johannes-kuhfuss/alighieri/config"
+	"github.com/johannes-kuhfuss/alighieri/domain"
+	"github.com/johannes-kuhfuss/alighieri/repositories"
+	"github.com/johannes-kuhfuss/services_utils/api_error"
+	"github.com/stretchr/testify/assert"
+)
+
+type renameServiceMock struct {
+	renamed []string
+	fail    string
+}
+
+func (m *renameServiceMock) RenameDevice(deviceName string, newName string) api_error.ApiErr {
+	if deviceName == m.fail {
+		return api_error.NewError("device does not advertise an ARC port", http.StatusBadRequest, nil)
+	}
+	m.renamed = append(m.renamed, deviceName+" -> "+newName)
+	return nil
+}
+
+func (m *renameServiceMock) RenameChannel(deviceName string, direction domain.ChannelDirection, channel int, newName string) api_error.ApiErr {
+	return nil
+}
+
+func namingTestConfig(rules string) *config.AppConfig {
+	var cfg config.AppConfig
+	config.InitConfig("", &cfg)
+	cfg.NamingPolicy.Rules.Decode(rules)
+	return &cfg
+}
+
+func TestAnalyzeNamesFlagsNamesNotMatchingPattern(t *testing.T) {
+	devices := []domain.DeviceInfo{
+		{Name: "STUDIOA-MIC-01"},
+		{Name: "studioA mic_3"},
+		{Name: "stagebox"},
+	}
+
+	report := analyzeNames(devices, namingTestConfig(""))
+
+	assert.EqualValues(t, 3, report.Checked)
+	assert.EqualValues(t, 1, report.Compliant)
+	assert.EqualValues(t, 2, report.Count(domain.NamingPattern))
+	assert.EqualValues(t, "stagebox does not match ^[A-Z0-9]+-[A-Z0-9]+-[0-9]{2}$", report.Findings[0].Detail)
+	assert.EqualValues(t, []domain.NamingRename{{Device: "studioA mic_3", Key: "studioA mic_3", NewName: "STUDIOA-MIC-03"}}, report.Renames)
+}
+
+func TestAnalyzeNamesUsesFirstMatchingRule(t *testing.T) {
+	devices := []domain.DeviceInfo{
+		{Name: "avio-7", Manufacturer: "Audinate", Model: "AVIO USB"},
+		{Name: "Lobby-AVIO-02", Manufacturer: "Audinate", Model: "AVIO USB"},
+	}
+
+	report := analyzeNames(devices, namingTestConfig("Audinate/AVIO*=^AVIO-[0-9]{2}$;*/*=^.*$"))
+
+	assert.EqualValues(t, 0, report.Compliant)
+	assert.EqualValues(t, []domain.NamingRename{{Device: "avio-7", Key: "avio-7", NewName: "AVIO-07"}}, report.Renames)
+}
+
+func TestAnalyzeNamesFlagsNamesDifferingInCaseOnly(t *testing.T) {
+	devices := []domain.DeviceInfo{
+		{Name: "FOH-MIX-01", Id: "b"},
+		{Name: "foh-mix-01", Id: "a"},
+		{Name: "FOH-MIX-02", Id: "c"},
+	}
+
+	report := analyzeNames(devices, namingTestConfig(""))
+
+	assert.EqualValues(t, 2, report.Compliant)
+	assert.EqualValues(t, 1, report.Count(domain.NamingDuplicate))
+	assert.EqualValues(t, "foh-mix-01", report.Findings[len(report.Findings)-1].Device)
+	assert.EqualValues(t, "FOH-MIX-03", report.Renames[0].NewName)
+}
+
+func TestAnalyzeNamesWithoutUniqueNamesIgnoresDuplicates(t *testing.T) {
+	cfg := namingTestConfig("")
+	cfg.NamingPolicy.UniqueNames = false
+	devices := []domain.DeviceInfo{
+		{Name: "FOH-MIX-01", Id: "a"},
+		{Name: "FOH-MIX-01", Id: "b"},
+	}
+
+	report := analyzeNames(devices, cfg)
+
+	assert.EqualValues(t, 2, report.Compliant)
+	assert.Empty(t, report.Findings)
+}
+
+func TestSuggestNameWithoutMatchingNameReturnsEmpty(t *testing.T) {
+	cfg := namingTestConfig("")
+
+	assert.EqualValues(t, "", suggestName("stagebox", "stagebox", cfg.NamingPolicy.Pattern.Regexp, map[string]string{}))
+	assert.EqualValues(t, "", suggestName("---", "---", nil, map[string]string{}))
+}
+
+func TestApplyRenamesDryRunDoesNotRename(t *testing.T) {
+	cfg := namingTestConfig("")
+	repo := repositories.NewDeviceRepository(cfg)
+	repo.Store(domain.DeviceInfo{Name: "studio-mic-1"})
+	renameMock := renameServiceMock{}
+	svc := NewNamingService(cfg, &repo, &renameMock)
+
+	renames := svc.ApplyRenames(true)
+
+	assert.EqualValues(t, 1, len(renames))
+	assert.False(t, renames[0].Applied)
+	assert.Empty(t, renameMock.renamed)
+}
+
+func TestApplyRenamesRecordsResultPerDevice(t *testing.T) {
+	cfg := namingTestConfig("")
+	repo := repositories.NewDeviceRepository(cfg)
+	repo.Store(domain.DeviceInfo{Name: "studio-mic-1"})
+	repo.Store(domain.DeviceInfo{Name: "studio-mic-2"})
+	renameMock := renameServiceMock{fail: "studio-mic-2"}
+	svc := NewNamingService(cfg, &repo, &renameMock)
+
+	renames := svc.ApplyRenames(false)
+
+	assert.EqualValues(t, []string{"studio-mic-1 -> STUDIO-MIC-01"}, renameMock.renamed)
+	assert.True(t, renames[0].Applied)
+	assert.False(t, renames[1].Applied)
+	assert.EqualValues(t, "device does not advertise an ARC port", renames[1].Error)
+}
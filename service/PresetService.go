@@ -0,0 +1,165 @@
+// package service implements the services and their business logic that provide the main part of the program
+package service
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/johannes-kuhfuss/alighieri/config"
+	"github.com/johannes-kuhfuss/alighieri/domain"
+	"github.com/johannes-kuhfuss/alighieri/repositories"
+	"github.com/johannes-kuhfuss/services_utils/api_error"
+	"github.com/johannes-kuhfuss/services_utils/logger"
+)
+
+type PresetService interface {
+	Save(string) (*domain.Preset, api_error.ApiErr)
+	Diff(string) ([]domain.PresetChange, api_error.ApiErr)
+	Recall(string) (*domain.RecallResult, api_error.ApiErr)
+	Delete(string) api_error.ApiErr
+}
+
+// The PresetService saves, compares and recalls snapshots of the network's routing
+type DefaultPresetService struct {
+	Cfg        *config.AppConfig
+	Repo       *repositories.DefaultDeviceRepository
+	PresetRepo *repositories.DefaultPresetRepository
+	Routing    RoutingService
+}
+
+// NewPresetService creates a new preset service and injects its dependencies
+func NewPresetService(cfg *config.AppConfig, repo *repositories.DefaultDeviceRepository, presetRepo *repositories.DefaultPresetRepository, routing RoutingService) DefaultPresetService {
+	return DefaultPresetService{
+		Cfg:        cfg,
+		Repo:       repo,
+		PresetRepo: presetRepo,
+		Routing:    routing,
+	}
+}
+
+// Save captures the current subscriptions of all receive channels as a preset with the given name, replacing an existing preset
+func (s DefaultPresetService) Save(name string) (*domain.Preset, api_error.ApiErr) {
+	if name == "" {
+		return nil, api_error.NewBadRequestError("preset name must not be empty")
+	}
+	preset := domain.Preset{
+		Name:          name,
+		Created:       time.Now(),
+		Subscriptions: s.liveSubscriptions(),
+	}
+	if len(preset.Subscriptions) == 0 {
+		return nil, api_error.NewBadRequestError("no receive channels known, nothing to save")
+	}
+	if err := s.PresetRepo.Store(preset); err != nil {
+		return nil, api_error.NewInternalServerError("could not store preset", err)
+	}
+	logger.Infof("Saved routing preset %v with %v receive channels", name, len(preset.Subscriptions))
+	return &preset, nil
+}
+
+// Diff returns the changes needed to bring the live routing to the state of the preset
+func (s DefaultPresetService) Diff(name string) ([]domain.PresetChange, api_error.ApiErr) {
+	preset := s.PresetRepo.GetByName(name)
+	if preset == nil {
+		return nil, api_error.NewNotFoundError(fmt.Sprintf("preset %v does not exist", name))
+	}
+	return diffPreset(*preset, s.liveSubscriptions()), nil
+}
+
+// Recall issues only the subscription changes needed to restore the preset and records which changes failed per device
+func (s DefaultPresetService) Recall(name string) (*domain.RecallResult, api_error.ApiErr) {
+	preset := s.PresetRepo.GetByName(name)
+	if preset == nil {
+		return nil, api_error.NewNotFoundError(fmt.Sprintf("preset %v does not exist", name))
+	}
+	result := domain.RecallResult{
+		Date:     time.Now(),
+		Failures: make(map[string][]domain.RecallFailure),
+	}
+	for _, change := range diffPreset(*preset, s.liveSubscriptions()) {
+		var apiErr api_error.ApiErr
+		if change.IsUnsubscribe() {
+			apiErr = s.Routing.Unsubscribe(change.RxDevice, change.RxChannel)
+		} else {
+			apiErr = s.Routing.Subscribe(change.RxDevice, change.RxChannel, change.PresetTxDevice, change.PresetTxChannel)
+		}
+		if apiErr != nil {
+			result.Failures[change.RxDevice] = append(result.Failures[change.RxDevice], domain.RecallFailure{
+				Change: change,
+				Error:  apiErr.Message(),
+			})
+		} else {
+			result.Applied = append(result.Applied, change)
+		}
+	}
+	preset.LastRecall = &result
+	if err := s.PresetRepo.Store(*preset); err != nil {
+		logger.Error("Could not store recall result", err)
+	}
+	logger.Infof("Recalled routing preset %v. %v change(s) applied, %v failed", name, len(result.Applied), result.FailureCount())
+	return &result, nil
+}
+
+// Delete removes the preset with the given name
+func (s DefaultPresetService) Delete(name string) api_error.ApiErr {
+	if err := s.PresetRepo.Delete(name); err != nil {
+		return api_error.NewNotFoundError(err.Error())
+	}
+	logger.Infof("Deleted routing preset %v", name)
+	return nil
+}
+
+// liveSubscriptions collects the current subscriptions of all receive channels known in the repository
+func (s DefaultPresetService) liveSubscriptions() (subscriptions []domain.PresetSubscription) {
+	if devices := s.Repo.GetAll(); devices != nil {
+		for _, device := range *devices {
+			for _, channel := range device.RxChannels {
+				subscriptions = append(subscriptions, domain.PresetSubscription{
+					RxDevice:  device.Name,
+					RxChannel: channel.Number,
+					TxDevice:  channel.TxDeviceName,
+					TxChannel: channel.TxChannelName,
+				})
+			}
+		}
+	}
+	sort.SliceStable(subscriptions, func(i, j int) bool {
+		if subscriptions[i].RxDevice != subscriptions[j].RxDevice {
+			return subscriptions[i].RxDevice < subscriptions[j].RxDevice
+		}
+		return subscriptions[i].RxChannel < subscriptions[j].RxChannel
+	})
+	return
+}
+
+// diffPreset compares a preset against the live subscriptions. Receive channels not present in the live state are reported as well, so recalling shows them as failed
+func diffPreset(preset domain.Preset, live []domain.PresetSubscription) (changes []domain.PresetChange) {
+	current := make(map[string]domain.PresetSubscription)
+	for _, sub := range live {
+		current[subscriptionKey(sub.RxDevice, sub.RxChannel)] = sub
+	}
+	for _, wanted := range preset.Subscriptions {
+		now, ok := current[subscriptionKey(wanted.RxDevice, wanted.RxChannel)]
+		if ok && now.TxDevice == wanted.TxDevice && now.TxChannel == wanted.TxChannel {
+			continue
+		}
+		if !ok && wanted.TxChannel == "" {
+			continue
+		}
+		changes = append(changes, domain.PresetChange{
+			RxDevice:         wanted.RxDevice,
+			RxChannel:        wanted.RxChannel,
+			CurrentTxDevice:  now.TxDevice,
+			CurrentTxChannel: now.TxChannel,
+			PresetTxDevice:   wanted.TxDevice,
+			PresetTxChannel:  wanted.TxChannel,
+		})
+	}
+	return
+}
+
+// subscriptionKey builds a map key for a receive channel
+func subscriptionKey(device string, channel int) string {
+	return fmt.Sprintf("%v/%v", device, channel)
+}
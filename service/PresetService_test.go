@@ -0,0 +1,138 @@
+package service
+
+import (
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"github.com/johannes-kuhfuss/alighieri/config"
+	"github.com/johannes-kuhfuss/alighieri/domain"
+	"github.com/johannes-kuhfuss/alighieri/repositories"
+	"github.com/johannes-kuhfuss/services_utils/api_error"
+	"github.com/stretchr/testify/assert"
+)
+
+type routingServiceMock struct {
+	failDevice string
+	calls      []string
+}
+
+func (m *routingServiceMock) Subscribe(rxDevice string, rxChannel int, txDevice string, txChannel string) api_error.ApiErr {
+	if rxDevice == m.failDevice {
+		return api_error.NewNotFoundError("receiving device " + rxDevice + " does not exist")
+	}
+	m.calls = append(m.calls, "subscribe "+rxDevice+" "+txChannel+"@"+txDevice)
+	return nil
+}
+
+func (m *routingServiceMock) Unsubscribe(rxDevice string, rxChannel int) api_error.ApiErr {
+	if rxDevice == m.failDevice {
+		return api_error.NewNotFoundError("receiving device " + rxDevice + " does not exist")
+	}
+	m.calls = append(m.calls, "unsubscribe "+rxDevice)
+	return nil
+}
+
+var (
+	presetCfg     config.AppConfig
+	presetDevRepo repositories.DefaultDeviceRepository
+	presetRepo    repositories.DefaultPresetRepository
+	presetRouting routingServiceMock
+	presetSvc     DefaultPresetService
+)
+
+func setupPresetTest(t *testing.T) {
+	config.InitConfig("", &presetCfg)
+	presetCfg.Routing.PresetFile = filepath.Join(t.TempDir(), "presets.json")
+	presetDevRepo = repositories.NewDeviceRepository(&presetCfg)
+	presetRepo = repositories.NewPresetRepository(&presetCfg)
+	presetRouting = routingServiceMock{}
+	presetSvc = NewPresetService(&presetCfg, &presetDevRepo, &presetRepo, &presetRouting)
+	presetDevRepo.Store(domain.DeviceInfo{
+		Name: "stagebox",
+		RxChannels: domain.ChannelList{
+			{Number: 1, Name: "In 1", TxChannelName: "Out 1", TxDeviceName: "mixer"},
+			{Number: 2, Name: "In 2"},
+		},
+	})
+}
+
+func TestSaveEmptyNameReturnsBadRequest(t *testing.T) {
+	setupPresetTest(t)
+	_, err := presetSvc.Save("")
+
+	assert.NotNil(t, err)
+	assert.EqualValues(t, http.StatusBadRequest, err.StatusCode())
+}
+
+func TestSaveCapturesAllReceiveChannels(t *testing.T) {
+	setupPresetTest(t)
+	preset, err := presetSvc.Save("evening")
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, 2, len(preset.Subscriptions))
+	assert.EqualValues(t, "Out 1", preset.Subscriptions[0].TxChannel)
+	assert.EqualValues(t, "", preset.Subscriptions[1].TxChannel)
+	assert.True(t, presetRepo.Exists("evening"))
+}
+
+func TestDiffUnknownPresetReturnsNotFound(t *testing.T) {
+	setupPresetTest(t)
+	_, err := presetSvc.Diff("unknown")
+
+	assert.NotNil(t, err)
+	assert.EqualValues(t, http.StatusNotFound, err.StatusCode())
+}
+
+func TestDiffUnchangedRoutingReturnsNoChanges(t *testing.T) {
+	setupPresetTest(t)
+	presetSvc.Save("evening")
+	changes, err := presetSvc.Diff("evening")
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, 0, len(changes))
+}
+
+func TestRecallIssuesOnlyNeededChanges(t *testing.T) {
+	setupPresetTest(t)
+	presetRepo.Store(domain.Preset{
+		Name: "evening",
+		Subscriptions: []domain.PresetSubscription{
+			{RxDevice: "stagebox", RxChannel: 1, TxDevice: "mixer", TxChannel: "Out 1"},
+			{RxDevice: "stagebox", RxChannel: 2, TxDevice: "mixer", TxChannel: "Out 2"},
+		},
+	})
+	result, err := presetSvc.Recall("evening")
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, []string{"subscribe stagebox Out 2@mixer"}, presetRouting.calls)
+	assert.EqualValues(t, 1, len(result.Applied))
+	assert.EqualValues(t, 0, result.FailureCount())
+	assert.NotNil(t, presetRepo.GetByName("evening").LastRecall)
+}
+
+func TestRecallRecordsFailuresPerDevice(t *testing.T) {
+	setupPresetTest(t)
+	presetRouting.failDevice = "stagebox"
+	presetRepo.Store(domain.Preset{
+		Name: "evening",
+		Subscriptions: []domain.PresetSubscription{
+			{RxDevice: "stagebox", RxChannel: 1},
+			{RxDevice: "gone", RxChannel: 1, TxDevice: "mixer", TxChannel: "Out 1"},
+		},
+	})
+	result, err := presetSvc.Recall("evening")
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, 1, len(result.Failures["stagebox"]))
+	assert.EqualValues(t, 1, len(result.Applied))
+	assert.EqualValues(t, "gone", result.Applied[0].RxDevice)
+}
+
+func TestDeleteUnknownPresetReturnsNotFound(t *testing.T) {
+	setupPresetTest(t)
+	err := presetSvc.Delete("unknown")
+
+	assert.NotNil(t, err)
+	assert.EqualValues(t, "preset with name unknown does not exist", err.Message())
+}
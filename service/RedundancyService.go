@@ -0,0 +1,129 @@
+// package service implements the services and their business logic that provide the main part of the program
+package service
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"time"
+
+	"github.com/johannes-kuhfuss/alighieri/config"
+	"github.com/johannes-kuhfuss/alighieri/domain"
+	"github.com/johannes-kuhfuss/alighieri/repositories"
+)
+
+type RedundancyService interface {
+	Report() domain.RedundancyReport
+}
+
+// The RedundancyService checks how the devices are connected to the primary and secondary network
+type DefaultRedundancyService struct {
+	Cfg  *config.AppConfig
+	Repo *repositories.DefaultDeviceRepository
+}
+
+var (
+	// interfaceSubnet returns the IPv4 subnet of a network interface, replaced in tests
+	interfaceSubnet = func(iface *net.Interface) *net.IPNet {
+		addrs, err := iface.Addrs()
+		if err != nil {
+			return nil
+		}
+		for _, addr := range addrs {
+			if ipNet, ok := addr.(*net.IPNet); ok && ipNet.IP.To4() != nil {
+				return &net.IPNet{IP: ipNet.IP.To4().Mask(ipNet.Mask), Mask: ipNet.Mask}
+			}
+		}
+		return nil
+	}
+)
+
+// NewRedundancyService creates a new redundancy service and injects its dependencies
+func NewRedundancyService(cfg *config.AppConfig, repo *repositories.DefaultDeviceRepository) DefaultRedundancyService {
+	return DefaultRedundancyService{
+		Cfg:  cfg,
+		Repo: repo,
+	}
+}
+
+// Report checks all devices not offline. The first scanned interface is the primary, the second the secondary network.
+// With only one interface scanned, the report is empty and not enabled
+func (s DefaultRedundancyService) Report() (report domain.RedundancyReport) {
+	s.Cfg.RunTime.Mu.Lock()
+	ifaces := s.Cfg.RunTime.DeviceScanInterfaces
+	s.Cfg.RunTime.Mu.Unlock()
+	if len(ifaces) < 2 {
+		return
+	}
+	report.Enabled = true
+	report.Primary = domain.Network{Interface: ifaces[0].Name, Subnet: interfaceSubnet(ifaces[0])}
+	report.Secondary = domain.Network{Interface: ifaces[1].Name, Subnet: interfaceSubnet(ifaces[1])}
+	devices := s.Repo.GetAll()
+	if devices == nil {
+		return
+	}
+	sort.Slice(*devices, func(i, j int) bool {
+		return (*devices)[i].Name < (*devices)[j].Name
+	})
+	staleAfter, offlineAfter := config.DeviceStateThresholds(s.Cfg)
+	now := time.Now().UTC()
+	for _, device := range *devices {
+		if device.State(now, staleAfter, offlineAfter) == domain.DeviceOffline {
+			continue
+		}
+		report.Checked++
+		report.Findings = append(report.Findings, checkRedundancy(device, report.Primary, report.Secondary, staleAfter)...)
+	}
+	return
+}
+
+// checkRedundancy checks a single device. A device is flagged if it answers on one network only,
+// if one of its addresses is not in the subnet of the network it answered on, or if both addresses are in the same subnet.
+// Both addresses in one subnet usually means both ports are cabled to the same network, so the address in the other network's subnet is not reported again
+func checkRedundancy(device domain.DeviceInfo, primary domain.Network, secondary domain.Network, staleAfter time.Duration) (findings []domain.RedundancyFinding) {
+	add := func(issue domain.RedundancyIssue, format string, a ...any) {
+		findings = append(findings, domain.RedundancyFinding{Device: device.Name, Issue: issue, Detail: fmt.Sprintf(format, a...)})
+	}
+	p, pFound := findSighting(device.Sightings, primary.Interface)
+	s, sFound := findSighting(device.Sightings, secondary.Interface)
+	pMissing, sMissing := isMissing(device, primary.Interface, staleAfter), isMissing(device, secondary.Interface, staleAfter)
+	switch {
+	case !pFound && !sFound:
+		return
+	case !pFound || pMissing:
+		add(domain.RedundancySingleNetwork, "answers on the secondary network (%v) only", secondary.Interface)
+	case !sFound || sMissing:
+		add(domain.RedundancySingleNetwork, "answers on the primary network (%v) only", primary.Interface)
+	}
+	if pFound && sFound && p.IPv4 != nil && s.IPv4 != nil {
+		for _, subnet := range []*net.IPNet{primary.Subnet, secondary.Subnet} {
+			if subnet != nil && subnet.Contains(p.IPv4) && subnet.Contains(s.IPv4) {
+				add(domain.RedundancySameSubnet, "primary address %v and secondary address %v are both in %v", p.IPv4, s.IPv4, subnet)
+				return
+			}
+		}
+	}
+	if pFound && p.IPv4 != nil && primary.Subnet != nil && !primary.Subnet.Contains(p.IPv4) {
+		add(domain.RedundancyWrongSubnet, "primary address %v is not in %v (%v)", p.IPv4, primary.Subnet, primary.Interface)
+	}
+	if sFound && s.IPv4 != nil && secondary.Subnet != nil && !secondary.Subnet.Contains(s.IPv4) {
+		add(domain.RedundancyWrongSubnet, "secondary address %v is not in %v (%v)", s.IPv4, secondary.Subnet, secondary.Interface)
+	}
+	return
+}
+
+// findSighting returns the sighting of a device on an interface
+func findSighting(sightings []domain.Sighting, iface string) (domain.Sighting, bool) {
+	for _, sighting := range sightings {
+		if sighting.Interface == iface {
+			return sighting, true
+		}
+	}
+	return domain.Sighting{}, false
+}
+
+// isMissing checks whether a device stopped answering on an interface
+func isMissing(device domain.DeviceInfo, iface string, staleAfter time.Duration) bool {
+	_, missing := findSighting(device.MissingOn(staleAfter), iface)
+	return missing
+}
@@ -0,0 +1,128 @@
+package service
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/johannes-kuhfuss/alighieri/config"
+	"github.com/johannes-kuhfuss/alighieri/domain"
+	"github.com/johannes-kuhfuss/alighieri/repositories"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	primaryNet   = domain.Network{Interface: "eth0", Subnet: mustParseSubnet("192.168.1.0/24")}
+	secondaryNet = domain.Network{Interface: "eth1", Subnet: mustParseSubnet("192.168.2.0/24")}
+)
+
+func mustParseSubnet(cidr string) *net.IPNet {
+	_, subnet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		panic(err)
+	}
+	return subnet
+}
+
+func redundantDevice(primaryIp string, secondaryIp string) domain.DeviceInfo {
+	now := time.Now().UTC()
+	device := domain.DeviceInfo{Name: "stagebox", LastSeen: now}
+	if primaryIp != "" {
+		device.Sightings = append(device.Sightings, domain.Sighting{Interface: "eth0", IPv4: net.ParseIP(primaryIp), LastSeen: now})
+	}
+	if secondaryIp != "" {
+		device.Sightings = append(device.Sightings, domain.Sighting{Interface: "eth1", IPv4: net.ParseIP(secondaryIp), LastSeen: now})
+	}
+	return device
+}
+
+func TestCheckRedundancyCorrectDeviceReturnsNoFindings(t *testing.T) {
+	findings := checkRedundancy(redundantDevice("192.168.1.10", "192.168.2.10"), primaryNet, secondaryNet, time.Minute)
+
+	assert.Empty(t, findings)
+}
+
+func TestCheckRedundancyPrimaryOnlyReturnsSingleNetwork(t *testing.T) {
+	findings := checkRedundancy(redundantDevice("192.168.1.10", ""), primaryNet, secondaryNet, time.Minute)
+
+	assert.EqualValues(t, 1, len(findings))
+	assert.EqualValues(t, domain.RedundancySingleNetwork, findings[0].Issue)
+	assert.EqualValues(t, "answers on the primary network (eth0) only", findings[0].Detail)
+}
+
+func TestCheckRedundancyMissingSecondaryReturnsSingleNetwork(t *testing.T) {
+	device := redundantDevice("192.168.1.10", "192.168.2.10")
+	device.Sightings[1].LastSeen = device.LastSeen.Add(-time.Hour)
+
+	findings := checkRedundancy(device, primaryNet, secondaryNet, time.Minute)
+
+	assert.EqualValues(t, 1, len(findings))
+	assert.EqualValues(t, domain.RedundancySingleNetwork, findings[0].Issue)
+}
+
+func TestCheckRedundancySecondaryInWrongSubnetReturnsWrongSubnet(t *testing.T) {
+	findings := checkRedundancy(redundantDevice("192.168.1.10", "10.0.0.10"), primaryNet, secondaryNet, time.Minute)
+
+	assert.EqualValues(t, 1, len(findings))
+	assert.EqualValues(t, domain.RedundancyWrongSubnet, findings[0].Issue)
+	assert.EqualValues(t, "secondary address 10.0.0.10 is not in 192.168.2.0/24 (eth1)", findings[0].Detail)
+}
+
+func TestCheckRedundancyBothInSameSubnetReturnsSameSubnet(t *testing.T) {
+	findings := checkRedundancy(redundantDevice("192.168.1.10", "192.168.1.11"), primaryNet, secondaryNet, time.Minute)
+
+	assert.EqualValues(t, 1, len(findings))
+	assert.EqualValues(t, domain.RedundancySameSubnet, findings[0].Issue)
+	assert.EqualValues(t, "primary address 192.168.1.10 and secondary address 192.168.1.11 are both in 192.168.1.0/24", findings[0].Detail)
+}
+
+func TestCheckRedundancyWithoutSightingsReturnsNoFindings(t *testing.T) {
+	findings := checkRedundancy(domain.DeviceInfo{Name: "stagebox", LastSeen: time.Now()}, primaryNet, secondaryNet, time.Minute)
+
+	assert.Empty(t, findings)
+}
+
+func TestReportWithOneInterfaceIsNotEnabled(t *testing.T) {
+	var testCfg config.AppConfig
+	config.InitConfig("", &testCfg)
+	testCfg.RunTime.DeviceScanInterfaces = []*net.Interface{{Name: "eth0"}}
+	repo := repositories.NewDeviceRepository(&testCfg)
+	svc := NewRedundancyService(&testCfg, &repo)
+
+	report := svc.Report()
+
+	assert.False(t, report.Enabled)
+	assert.Empty(t, report.Findings)
+}
+
+func TestReportSkipsOfflineDevices(t *testing.T) {
+	var testCfg config.AppConfig
+	config.InitConfig("", &testCfg)
+	testCfg.RunTime.DeviceScanInterfaces = []*net.Interface{{Name: "eth0"}, {Name: "eth1"}}
+	repo := repositories.NewDeviceRepository(&testCfg)
+	svc := NewRedundancyService(&testCfg, &repo)
+	origSubnet := interfaceSubnet
+	interfaceSubnet = func(iface *net.Interface) *net.IPNet {
+		if iface.Name == "eth0" {
+			return primaryNet.Subnet
+		}
+		return secondaryNet.Subnet
+	}
+	defer func() { interfaceSubnet = origSubnet }()
+	online := redundantDevice("192.168.1.10", "")
+	offline := redundantDevice("192.168.1.11", "")
+	offline.Name = "amp"
+	offline.LastSeen = offline.LastSeen.Add(-24 * time.Hour)
+	repo.Store(online)
+	repo.Store(offline)
+	defer repo.Delete("stagebox")
+	defer repo.Delete("amp")
+
+	report := svc.Report()
+
+	assert.True(t, report.Enabled)
+	assert.EqualValues(t, "192.168.1.0/24", report.Primary.Subnet.String())
+	assert.EqualValues(t, 1, report.Checked)
+	assert.EqualValues(t, 1, report.Count(domain.RedundancySingleNetwork))
+	assert.EqualValues(t, "stagebox", report.Findings[0].Device)
+}
@@ -0,0 +1,108 @@
+// package service implements the services and their business logic that provide the main part of the program
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/johannes-kuhfuss/alighieri/config"
+	"github.com/johannes-kuhfuss/alighieri/domain"
+	"github.com/johannes-kuhfuss/alighieri/repositories"
+	"github.com/johannes-kuhfuss/services_utils/api_error"
+	"github.com/johannes-kuhfuss/services_utils/logger"
+)
+
+type RenameService interface {
+	RenameDevice(string, string) api_error.ApiErr
+	RenameChannel(string, domain.ChannelDirection, int, string) api_error.ApiErr
+}
+
+// The RenameService changes device names and channel labels via the ARC protocol. The repository is not changed, the new names show up with the next scan
+type DefaultRenameService struct {
+	Cfg  *config.AppConfig
+	Repo *repositories.DefaultDeviceRepository
+}
+
+// NewRenameService creates a new rename service and injects its dependencies
+func NewRenameService(cfg *config.AppConfig, repo *repositories.DefaultDeviceRepository) DefaultRenameService {
+	return DefaultRenameService{
+		Cfg:  cfg,
+		Repo: repo,
+	}
+}
+
+// RenameDevice changes the name of a device after checking it against the Dante naming rules and the names of all other devices
+func (s DefaultRenameService) RenameDevice(deviceName string, newName string) api_error.ApiErr {
+	if err := domain.ValidateDeviceName(newName); err != nil {
+		return api_error.NewBadRequestError(err.Error())
+	}
+	device, apiErr := s.getDevice(deviceName)
+	if apiErr != nil {
+		return apiErr
+	}
+	if other := s.findDeviceNamed(newName); other != nil && other.Key() != device.Key() {
+		return api_error.NewProcessingConflictError(fmt.Sprintf("name %v is already used by device %v", newName, other.Name))
+	}
+	if err := arcClient(s.Cfg, device).SetDeviceName(newName); err != nil {
+		logger.Errorf("Could not rename device %v to %v: %v", deviceName, newName, err)
+		return api_error.NewInternalServerError("could not rename device", err)
+	}
+	logger.Infof("Renamed device %v to %v", deviceName, newName)
+	return nil
+}
+
+// RenameChannel changes the label of a transmit or receive channel after checking it against the Dante naming rules and the other channels of the device
+func (s DefaultRenameService) RenameChannel(deviceName string, direction domain.ChannelDirection, channel int, newName string) api_error.ApiErr {
+	if direction != domain.ChannelTx && direction != domain.ChannelRx {
+		return api_error.NewBadRequestError(fmt.Sprintf("invalid channel direction %v", direction))
+	}
+	if err := domain.ValidateChannelName(newName); err != nil {
+		return api_error.NewBadRequestError(err.Error())
+	}
+	device, apiErr := s.getDevice(deviceName)
+	if apiErr != nil {
+		return apiErr
+	}
+	channels := device.RxChannels
+	if direction == domain.ChannelTx {
+		channels = device.TxChannels
+	}
+	if channel < 1 || (len(channels) > 0 && channels.GetByNumber(channel) == nil) {
+		return api_error.NewNotFoundError(fmt.Sprintf("device %v has no %v channel %v", deviceName, direction, channel))
+	}
+	for _, other := range channels {
+		if other.Number != channel && strings.EqualFold(other.Name, newName) {
+			return api_error.NewProcessingConflictError(fmt.Sprintf("name %v is already used by %v channel %v of device %v", newName, direction, other.Number, deviceName))
+		}
+	}
+	if err := arcClient(s.Cfg, device).SetChannelName(direction, channel, newName); err != nil {
+		logger.Errorf("Could not rename %v channel %v of device %v to %v: %v", direction, channel, deviceName, newName, err)
+		return api_error.NewInternalServerError("could not rename channel", err)
+	}
+	logger.Infof("Renamed %v channel %v of device %v to %v", direction, channel, deviceName, newName)
+	return nil
+}
+
+// getDevice looks up a device by its name or its id and checks that it can be controlled via ARC
+func (s DefaultRenameService) getDevice(deviceName string) (*domain.DeviceInfo, api_error.ApiErr) {
+	device := s.Repo.Find(deviceName)
+	if device == nil {
+		return nil, api_error.NewNotFoundError(fmt.Sprintf("device %v does not exist or its name is not unique", deviceName))
+	}
+	if device.ArcPort == 0 || device.IPv4 == nil {
+		return nil, api_error.NewBadRequestError(fmt.Sprintf("device %v does not advertise an ARC port", deviceName))
+	}
+	return device, nil
+}
+
+// findDeviceNamed returns the device using a name either as its name or as its Dante name. Dante names are not case-sensitive
+func (s DefaultRenameService) findDeviceNamed(name string) *domain.DeviceInfo {
+	if devices := s.Repo.GetAll(); devices != nil {
+		for _, device := range *devices {
+			if strings.EqualFold(device.Name, name) || strings.EqualFold(device.DanteName, name) {
+				return &device
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+package service
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/johannes-kuhfuss/alighieri/arc"
+	"github.com/johannes-kuhfuss/alighieri/config"
+	"github.com/johannes-kuhfuss/alighieri/domain"
+	"github.com/johannes-kuhfuss/alighieri/repositories"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	renameCfg  config.AppConfig
+	renameRepo repositories.DefaultDeviceRepository
+	renameSvc  DefaultRenameService
+)
+
+func setupRenameTest(clientErr error) func() {
+	config.InitConfig("", &renameCfg)
+	renameRepo = repositories.NewDeviceRepository(&renameCfg)
+	renameSvc = NewRenameService(&renameCfg, &renameRepo)
+	renameRepo.Store(domain.DeviceInfo{
+		Name:    "stagebox",
+		Id:      "001dc1fffe000001",
+		IPv4:    net.ParseIP("127.0.0.1"),
+		ArcPort: 4440,
+		TxChannels: domain.ChannelList{
+			{DeviceName: "stagebox", Direction: domain.ChannelTx, Number: 1, Name: "Vocal L"},
+			{DeviceName: "stagebox", Direction: domain.ChannelTx, Number: 2, Name: "Vocal R"},
+		},
+		RxChannels: domain.ChannelList{{DeviceName: "stagebox", Direction: domain.ChannelRx, Number: 1, Name: "Vocal L"}},
+	})
+	renameRepo.Store(domain.DeviceInfo{Name: "mixer", DanteName: "FOH-Mixer"})
+	lastArcCall = ""
+	origClient := newArcClient
+	newArcClient = func(ip net.IP, port int, timeout time.Duration) arc.ArcClient {
+		return arcClientMock{err: clientErr}
+	}
+	return func() {
+		newArcClient = origClient
+	}
+}
+
+func TestRenameDeviceInvalidNameReturnsBadRequest(t *testing.T) {
+	teardown := setupRenameTest(nil)
+	defer teardown()
+	err := renameSvc.RenameDevice("stagebox", "stage box")
+
+	assert.NotNil(t, err)
+	assert.EqualValues(t, http.StatusBadRequest, err.StatusCode())
+	assert.EqualValues(t, "device name stage box may only contain letters, digits and hyphens and must not start or end with a hyphen", err.Message())
+	assert.EqualValues(t, "", lastArcCall)
+}
+
+func TestRenameDeviceTooLongReturnsBadRequest(t *testing.T) {
+	teardown := setupRenameTest(nil)
+	defer teardown()
+	err := renameSvc.RenameDevice("stagebox", "stagebox-with-a-much-too-long-name")
+
+	assert.NotNil(t, err)
+	assert.EqualValues(t, http.StatusBadRequest, err.StatusCode())
+	assert.Contains(t, err.Message(), "longer than 31 characters")
+}
+
+func TestRenameDeviceNameInUseReturnsConflict(t *testing.T) {
+	teardown := setupRenameTest(nil)
+	defer teardown()
+	err := renameSvc.RenameDevice("stagebox", "foh-mixer")
+
+	assert.NotNil(t, err)
+	assert.EqualValues(t, http.StatusConflict, err.StatusCode())
+	assert.EqualValues(t, "name foh-mixer is already used by device mixer", err.Message())
+	assert.EqualValues(t, "", lastArcCall)
+}
+
+func TestRenameDeviceWithoutArcReturnsBadRequest(t *testing.T) {
+	teardown := setupRenameTest(nil)
+	defer teardown()
+	err := renameSvc.RenameDevice("mixer", "FOH-Desk")
+
+	assert.NotNil(t, err)
+	assert.EqualValues(t, http.StatusBadRequest, err.StatusCode())
+}
+
+func TestRenameDeviceSendsNameAndKeepsRepo(t *testing.T) {
+	teardown := setupRenameTest(nil)
+	defer teardown()
+	err := renameSvc.RenameDevice("001dc1fffe000001", "Stage-Left")
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, "rename device Stage-Left", lastArcCall)
+	assert.NotNil(t, renameRepo.GetByName("stagebox"))
+}
+
+func TestRenameChannelInvalidNameReturnsBadRequest(t *testing.T) {
+	teardown := setupRenameTest(nil)
+	defer teardown()
+	err := renameSvc.RenameChannel("stagebox", domain.ChannelTx, 1, "Vocal@L")
+
+	assert.NotNil(t, err)
+	assert.EqualValues(t, http.StatusBadRequest, err.StatusCode())
+	assert.EqualValues(t, "channel name Vocal@L must not contain any of =.@", err.Message())
+}
+
+func TestRenameChannelUnknownChannelReturnsNotFound(t *testing.T) {
+	teardown := setupRenameTest(nil)
+	defer teardown()
+	err := renameSvc.RenameChannel("stagebox", domain.ChannelRx, 2, "Ambience")
+
+	assert.NotNil(t, err)
+	assert.EqualValues(t, http.StatusNotFound, err.StatusCode())
+	assert.EqualValues(t, "device stagebox has no rx channel 2", err.Message())
+}
+
+func TestRenameChannelNameInUseReturnsConflict(t *testing.T) {
+	teardown := setupRenameTest(nil)
+	defer teardown()
+	err := renameSvc.RenameChannel("stagebox", domain.ChannelTx, 2, "vocal l")
+
+	assert.NotNil(t, err)
+	assert.EqualValues(t, http.StatusConflict, err.StatusCode())
+	assert.EqualValues(t, "name vocal l is already used by tx channel 1 of device stagebox", err.Message())
+}
+
+func TestRenameChannelSameNameOtherDirectionSendsName(t *testing.T) {
+	teardown := setupRenameTest(nil)
+	defer teardown()
+	err := renameSvc.RenameChannel("stagebox", domain.ChannelRx, 1, "Vocal R")
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, "rename rx 1 Vocal R", lastArcCall)
+}
+
+func TestRenameChannelClientErrorReturnsInternalServerError(t *testing.T) {
+	teardown := setupRenameTest(errors.New("no response"))
+	defer teardown()
+	err := renameSvc.RenameChannel("stagebox", domain.ChannelTx, 1, "Lead Vocal")
+
+	assert.NotNil(t, err)
+	assert.EqualValues(t, http.StatusInternalServerError, err.StatusCode())
+	assert.EqualValues(t, "could not rename channel", err.Message())
+}
@@ -0,0 +1,141 @@
+// package service implements the services and their business logic that provide the main part of the program
+package service
+
+import (
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/johannes-kuhfuss/alighieri/arc"
+	"github.com/johannes-kuhfuss/alighieri/config"
+	"github.com/johannes-kuhfuss/alighieri/domain"
+	"github.com/johannes-kuhfuss/alighieri/repositories"
+	"github.com/johannes-kuhfuss/services_utils/api_error"
+	"github.com/johannes-kuhfuss/services_utils/logger"
+)
+
+type RoutingService interface {
+	Subscribe(string, int, string, string) api_error.ApiErr
+	Unsubscribe(string, int) api_error.ApiErr
+}
+
+// The RoutingService creates and removes audio subscriptions via the ARC protocol
+type DefaultRoutingService struct {
+	Cfg  *config.AppConfig
+	Repo *repositories.DefaultDeviceRepository
+}
+
+var (
+	// newArcClient creates the ARC client used to talk to a device, replaced in tests
+	newArcClient = func(ip net.IP, port int, timeout time.Duration) arc.ArcClient {
+		return arc.NewArcClient(ip, port, timeout)
+	}
+)
+
+// NewRoutingService creates a new routing service and injects its dependencies
+func NewRoutingService(cfg *config.AppConfig, repo *repositories.DefaultDeviceRepository) DefaultRoutingService {
+	return DefaultRoutingService{
+		Cfg:  cfg,
+		Repo: repo,
+	}
+}
+
+// Subscribe subscribes a receive channel of one device to a transmit channel of another device
+func (s DefaultRoutingService) Subscribe(rxDeviceName string, rxChannel int, txDeviceName string, txChannelName string) api_error.ApiErr {
+	rxDevice, apiErr := s.getRxChannel(rxDeviceName, rxChannel)
+	if apiErr != nil {
+		return apiErr
+	}
+	txDevice := s.findTxDevice(txDeviceName)
+	if txDevice == nil {
+		return api_error.NewNotFoundError(fmt.Sprintf("transmitting device %v does not exist", txDeviceName))
+	}
+	if len(txDevice.TxChannels) > 0 && txDevice.TxChannels.GetByName(txChannelName) == nil {
+		return api_error.NewNotFoundError(fmt.Sprintf("transmitting device %v has no channel %v", txDeviceName, txChannelName))
+	}
+	danteName := txDevice.DanteName
+	if danteName == "" {
+		danteName = txDevice.Name
+	}
+	if err := s.client(rxDevice).Subscribe(rxChannel, txChannelName, danteName); err != nil {
+		logger.Errorf("Could not subscribe %v channel %v to %v@%v: %v", rxDeviceName, rxChannel, txChannelName, danteName, err)
+		return api_error.NewInternalServerError("could not create subscription", err)
+	}
+	logger.Infof("Subscribed %v channel %v to %v@%v", rxDeviceName, rxChannel, txChannelName, danteName)
+	s.updateRxChannel(rxDeviceName, rxChannel, txChannelName, danteName, domain.SubscriptionUnresolved)
+	return nil
+}
+
+// Unsubscribe clears the subscription of a receive channel
+func (s DefaultRoutingService) Unsubscribe(rxDeviceName string, rxChannel int) api_error.ApiErr {
+	rxDevice, apiErr := s.getRxChannel(rxDeviceName, rxChannel)
+	if apiErr != nil {
+		return apiErr
+	}
+	if err := s.client(rxDevice).Unsubscribe(rxChannel); err != nil {
+		logger.Errorf("Could not unsubscribe %v channel %v: %v", rxDeviceName, rxChannel, err)
+		return api_error.NewInternalServerError("could not remove subscription", err)
+	}
+	logger.Infof("Unsubscribed %v channel %v", rxDeviceName, rxChannel)
+	s.updateRxChannel(rxDeviceName, rxChannel, "", "", domain.SubscriptionNone)
+	return nil
+}
+
+// getRxChannel checks that the receiving device can be controlled and has the given receive channel
+func (s DefaultRoutingService) getRxChannel(deviceName string, channel int) (*domain.DeviceInfo, api_error.ApiErr) {
+	device := s.Repo.GetByName(deviceName)
+	if device == nil {
+		return nil, api_error.NewNotFoundError(fmt.Sprintf("receiving device %v does not exist", deviceName))
+	}
+	if device.ArcPort == 0 || device.IPv4 == nil {
+		return nil, api_error.NewBadRequestError(fmt.Sprintf("receiving device %v does not advertise an ARC port", deviceName))
+	}
+	if channel < 1 {
+		return nil, api_error.NewBadRequestError(fmt.Sprintf("invalid channel number %v", channel))
+	}
+	if len(device.RxChannels) > 0 && device.RxChannels.GetByNumber(channel) == nil {
+		return nil, api_error.NewNotFoundError(fmt.Sprintf("receiving device %v has no channel %v", deviceName, channel))
+	}
+	return device, nil
+}
+
+// findTxDevice looks up a transmitting device by its name or, as subscriptions refer to devices by their Dante name, by its Dante name
+func (s DefaultRoutingService) findTxDevice(name string) *domain.DeviceInfo {
+	if device := s.Repo.GetByName(name); device != nil {
+		return device
+	}
+	if devices := s.Repo.GetAll(); devices != nil {
+		for _, device := range *devices {
+			if device.DanteName == name {
+				return &device
+			}
+		}
+	}
+	return nil
+}
+
+// client returns an ARC client for the given device
+func (s DefaultRoutingService) client(device *domain.DeviceInfo) arc.ArcClient {
+	return arcClient(s.Cfg, device)
+}
+
+// updateRxChannel reflects a subscription change in the repository until the next scan run retrieves the actual state
+func (s DefaultRoutingService) updateRxChannel(deviceName string, channel int, txChannelName string, txDeviceName string, status domain.SubscriptionStatus) {
+	device := s.Repo.GetByName(deviceName)
+	if device == nil {
+		return
+	}
+	rxChannels := append(domain.ChannelList(nil), device.RxChannels...)
+	if rx := rxChannels.GetByNumber(channel); rx != nil {
+		rx.TxChannelName = txChannelName
+		rx.TxDeviceName = txDeviceName
+		rx.SubscriptionStatus = status
+		device.RxChannels = rxChannels
+		s.Repo.Store(*device)
+	}
+}
+
+// arcClient returns a client for the ARC port of the given device
+func arcClient(cfg *config.AppConfig, device *domain.DeviceInfo) arc.ArcClient {
+	return newArcClient(device.IPv4, device.ArcPort, time.Duration(cfg.DeviceScan.ArcTimeOutMs)*time.Millisecond)
+}
@@ -0,0 +1,117 @@
+package service
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/johannes-kuhfuss/alighieri/arc"
+	"github.com/johannes-kuhfuss/alighieri/config"
+	"github.com/johannes-kuhfuss/alighieri/domain"
+	"github.com/johannes-kuhfuss/alighieri/repositories"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	routingCfg  config.AppConfig
+	routingRepo repositories.DefaultDeviceRepository
+	routingSvc  DefaultRoutingService
+)
+
+func setupRoutingTest(clientErr error) func() {
+	config.InitConfig("", &routingCfg)
+	routingRepo = repositories.NewDeviceRepository(&routingCfg)
+	routingSvc = NewRoutingService(&routingCfg, &routingRepo)
+	routingRepo.Store(domain.DeviceInfo{
+		Name:       "stagebox",
+		IPv4:       net.ParseIP("127.0.0.1"),
+		ArcPort:    4440,
+		RxChannels: domain.ChannelList{{DeviceName: "stagebox", Direction: domain.ChannelRx, Number: 1, Name: "In 1"}},
+	})
+	routingRepo.Store(domain.DeviceInfo{
+		Name:       "mixer",
+		DanteName:  "mixer-dante",
+		TxChannels: domain.ChannelList{{DeviceName: "mixer", Direction: domain.ChannelTx, Number: 1, Name: "Out 1"}},
+	})
+	lastArcCall = ""
+	origClient := newArcClient
+	newArcClient = func(ip net.IP, port int, timeout time.Duration) arc.ArcClient {
+		return arcClientMock{err: clientErr}
+	}
+	return func() {
+		newArcClient = origClient
+	}
+}
+
+func TestSubscribeUnknownRxDeviceReturnsNotFound(t *testing.T) {
+	teardown := setupRoutingTest(nil)
+	defer teardown()
+	err := routingSvc.Subscribe("unknown", 1, "mixer", "Out 1")
+
+	assert.NotNil(t, err)
+	assert.EqualValues(t, http.StatusNotFound, err.StatusCode())
+	assert.EqualValues(t, "receiving device unknown does not exist", err.Message())
+}
+
+func TestSubscribeRxDeviceWithoutArcReturnsBadRequest(t *testing.T) {
+	teardown := setupRoutingTest(nil)
+	defer teardown()
+	err := routingSvc.Subscribe("mixer", 1, "mixer", "Out 1")
+
+	assert.NotNil(t, err)
+	assert.EqualValues(t, http.StatusBadRequest, err.StatusCode())
+}
+
+func TestSubscribeUnknownTxChannelReturnsNotFound(t *testing.T) {
+	teardown := setupRoutingTest(nil)
+	defer teardown()
+	err := routingSvc.Subscribe("stagebox", 1, "mixer", "Out 9")
+
+	assert.NotNil(t, err)
+	assert.EqualValues(t, "transmitting device mixer has no channel Out 9", err.Message())
+}
+
+func TestSubscribeSendsDanteNameAndUpdatesRepo(t *testing.T) {
+	teardown := setupRoutingTest(nil)
+	defer teardown()
+	err := routingSvc.Subscribe("stagebox", 1, "mixer", "Out 1")
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, "subscribe 1 Out 1@mixer-dante", lastArcCall)
+	rx := routingRepo.GetByName("stagebox").RxChannels[0]
+	assert.EqualValues(t, "Out 1", rx.TxChannelName)
+	assert.EqualValues(t, "mixer-dante", rx.TxDeviceName)
+	assert.EqualValues(t, domain.SubscriptionUnresolved, rx.SubscriptionStatus)
+}
+
+func TestSubscribeClientErrorReturnsInternalServerError(t *testing.T) {
+	teardown := setupRoutingTest(errors.New("timeout"))
+	defer teardown()
+	err := routingSvc.Subscribe("stagebox", 1, "mixer", "Out 1")
+
+	assert.NotNil(t, err)
+	assert.EqualValues(t, http.StatusInternalServerError, err.StatusCode())
+	assert.False(t, routingRepo.GetByName("stagebox").RxChannels[0].IsSubscribed())
+}
+
+func TestUnsubscribeUnknownChannelReturnsNotFound(t *testing.T) {
+	teardown := setupRoutingTest(nil)
+	defer teardown()
+	err := routingSvc.Unsubscribe("stagebox", 5)
+
+	assert.NotNil(t, err)
+	assert.EqualValues(t, http.StatusNotFound, err.StatusCode())
+}
+
+func TestUnsubscribeClearsSubscription(t *testing.T) {
+	teardown := setupRoutingTest(nil)
+	defer teardown()
+	routingSvc.Subscribe("stagebox", 1, "mixer", "Out 1")
+	err := routingSvc.Unsubscribe("stagebox", 1)
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, "unsubscribe 1", lastArcCall)
+	assert.False(t, routingRepo.GetByName("stagebox").RxChannels[0].IsSubscribed())
+}
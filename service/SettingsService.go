@@ -0,0 +1,163 @@
+// package service implements the services and their business logic that provide the main part of the program
+package service
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/johannes-kuhfuss/alighieri/config"
+	"github.com/johannes-kuhfuss/alighieri/domain"
+	"github.com/johannes-kuhfuss/alighieri/repositories"
+	"github.com/johannes-kuhfuss/alighieri/settings"
+	"github.com/johannes-kuhfuss/services_utils/api_error"
+	"github.com/johannes-kuhfuss/services_utils/logger"
+)
+
+type SettingsService interface {
+	Read(string) (domain.DeviceSettings, api_error.ApiErr)
+	Update(string, domain.DeviceSettings) (domain.DeviceSettings, api_error.ApiErr)
+	Identify(string) api_error.ApiErr
+}
+
+// The SettingsService reads and changes device-wide settings such as the sample rate via the device settings port and makes devices identify themselves
+type DefaultSettingsService struct {
+	Cfg        *config.AppConfig
+	Repo       *repositories.DefaultDeviceRepository
+	identified *identifyLog
+}
+
+// identifyLog remembers when each device was last asked to identify itself
+type identifyLog struct {
+	sync.Mutex
+	last map[string]time.Time
+}
+
+var (
+	// newSettingsClient creates the client used to talk to a device's settings port, replaced in tests
+	newSettingsClient = func(ip net.IP, port int, timeout time.Duration) settings.SettingsClient {
+		return settings.NewSettingsClient(ip, port, timeout)
+	}
+)
+
+// NewSettingsService creates a new settings service and injects its dependencies
+func NewSettingsService(cfg *config.AppConfig, repo *repositories.DefaultDeviceRepository) DefaultSettingsService {
+	return DefaultSettingsService{
+		Cfg:        cfg,
+		Repo:       repo,
+		identified: &identifyLog{last: make(map[string]time.Time)},
+	}
+}
+
+// Read reads the current settings from a device and keeps them on the device
+func (s DefaultSettingsService) Read(deviceName string) (domain.DeviceSettings, api_error.ApiErr) {
+	device, apiErr := s.getDevice(deviceName)
+	if apiErr != nil {
+		return domain.DeviceSettings{}, apiErr
+	}
+	current, err := settingsClient(s.Cfg, device.IPv4).GetSettings()
+	if err != nil {
+		logger.Errorf("Could not read settings of device %v: %v", deviceName, err)
+		return domain.DeviceSettings{}, api_error.NewInternalServerError("could not read settings", err)
+	}
+	s.updateSettings(device.Key(), current)
+	return current, nil
+}
+
+// Update changes the settings of a device to the given values. Only settings differing from the device's current settings are written
+func (s DefaultSettingsService) Update(deviceName string, target domain.DeviceSettings) (domain.DeviceSettings, api_error.ApiErr) {
+	if err := target.Validate(); err != nil {
+		return domain.DeviceSettings{}, api_error.NewBadRequestError(err.Error())
+	}
+	device, apiErr := s.getDevice(deviceName)
+	if apiErr != nil {
+		return domain.DeviceSettings{}, apiErr
+	}
+	client := settingsClient(s.Cfg, device.IPv4)
+	current, err := client.GetSettings()
+	if err != nil {
+		logger.Errorf("Could not read settings of device %v: %v", deviceName, err)
+		return domain.DeviceSettings{}, api_error.NewInternalServerError("could not read settings", err)
+	}
+	changes := []struct {
+		name    string
+		changed bool
+		apply   func() error
+	}{
+		{"sample rate", target.SampleRate != current.SampleRate, func() error { return client.SetSampleRate(target.SampleRate) }},
+		{"encoding", target.Encoding != current.Encoding, func() error { return client.SetEncoding(target.Encoding) }},
+		{"latency", target.LatencyNs != current.LatencyNs, func() error { return client.SetLatency(target.LatencyNs) }},
+		{"pull-up/down", target.PullUp != current.PullUp, func() error { return client.SetPullUp(target.PullUp) }},
+	}
+	for _, change := range changes {
+		if !change.changed {
+			continue
+		}
+		if err := change.apply(); err != nil {
+			logger.Errorf("Could not change %v of device %v: %v", change.name, deviceName, err)
+			return domain.DeviceSettings{}, api_error.NewInternalServerError(fmt.Sprintf("could not change %v", change.name), err)
+		}
+		logger.Infof("Changed %v of device %v", change.name, deviceName)
+	}
+	if current, err = client.GetSettings(); err != nil {
+		logger.Errorf("Could not read settings of device %v: %v", deviceName, err)
+		return domain.DeviceSettings{}, api_error.NewInternalServerError("could not read settings", err)
+	}
+	s.updateSettings(device.Key(), current)
+	return current, nil
+}
+
+// Identify makes a device flash its LEDs. Requests for the same device within the throttle time are rejected, so repeated clicks cannot flood the device
+func (s DefaultSettingsService) Identify(deviceName string) api_error.ApiErr {
+	device, apiErr := s.getDevice(deviceName)
+	if apiErr != nil {
+		return apiErr
+	}
+	throttle := time.Duration(s.Cfg.DeviceScan.IdentifyThrottleSec) * time.Second
+	s.identified.Lock()
+	if last, ok := s.identified.last[device.Key()]; ok && time.Since(last) < throttle {
+		s.identified.Unlock()
+		return api_error.NewError(fmt.Sprintf("device %v was asked to identify itself less than %v ago", deviceName, throttle), http.StatusTooManyRequests, nil)
+	}
+	s.identified.last[device.Key()] = time.Now()
+	s.identified.Unlock()
+	if err := settingsClient(s.Cfg, device.IPv4).Identify(); err != nil {
+		// a failed request must not block retries
+		s.identified.Lock()
+		delete(s.identified.last, device.Key())
+		s.identified.Unlock()
+		logger.Errorf("Could not identify device %v: %v", deviceName, err)
+		return api_error.NewInternalServerError("could not identify device", err)
+	}
+	logger.Infof("Asked device %v to identify itself", deviceName)
+	return nil
+}
+
+// getDevice looks up a device by its name or its id and checks that it has an address its settings port can be reached at
+func (s DefaultSettingsService) getDevice(deviceName string) (*domain.DeviceInfo, api_error.ApiErr) {
+	device := s.Repo.Find(deviceName)
+	if device == nil {
+		return nil, api_error.NewNotFoundError(fmt.Sprintf("device %v does not exist or its name is not unique", deviceName))
+	}
+	if device.IPv4 == nil {
+		return nil, api_error.NewBadRequestError(fmt.Sprintf("device %v has no IPv4 address", deviceName))
+	}
+	return device, nil
+}
+
+// updateSettings reflects the settings read from a device in the repository
+func (s DefaultSettingsService) updateSettings(key string, current domain.DeviceSettings) {
+	device := s.Repo.GetByKey(key)
+	if device == nil {
+		return
+	}
+	device.Settings = current
+	s.Repo.Store(*device)
+}
+
+// settingsClient returns a client for the settings port of the device reachable at the given address
+func settingsClient(cfg *config.AppConfig, ip net.IP) settings.SettingsClient {
+	return newSettingsClient(ip, cfg.DeviceScan.SettingsPort, time.Duration(cfg.DeviceScan.SettingsTimeOutMs)*time.Millisecond)
+}
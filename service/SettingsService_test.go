@@ -0,0 +1,193 @@
+package service
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/johannes-kuhfuss/alighieri/config"
+	"github.com/johannes-kuhfuss/alighieri/domain"
+	"github.com/johannes-kuhfuss/alighieri/repositories"
+	"github.com/johannes-kuhfuss/alighieri/settings"
+	"github.com/stretchr/testify/assert"
+)
+
+// settingsClientMock keeps the settings of all devices in memory, so changes show up when reading them again
+type settingsClientMock struct {
+	current *domain.DeviceSettings
+	err     error
+}
+
+var (
+	settingsCfg   config.AppConfig
+	settingsRepo  repositories.DefaultDeviceRepository
+	settingsSvc   DefaultSettingsService
+	settingsCalls []string
+)
+
+func (m settingsClientMock) GetSettings() (domain.DeviceSettings, error) {
+	settingsCalls = append(settingsCalls, "get")
+	current := *m.current
+	current.ReadAt = time.Now()
+	return current, m.err
+}
+
+func (m settingsClientMock) SetSampleRate(sampleRate int) error {
+	settingsCalls = append(settingsCalls, "sample rate")
+	m.current.SampleRate = sampleRate
+	return m.err
+}
+
+func (m settingsClientMock) SetEncoding(encoding int) error {
+	settingsCalls = append(settingsCalls, "encoding")
+	m.current.Encoding = encoding
+	return m.err
+}
+
+func (m settingsClientMock) SetLatency(latencyNs int) error {
+	settingsCalls = append(settingsCalls, "latency")
+	m.current.LatencyNs = latencyNs
+	return m.err
+}
+
+func (m settingsClientMock) SetPullUp(pullUp domain.PullUp) error {
+	settingsCalls = append(settingsCalls, "pull-up")
+	m.current.PullUp = pullUp
+	return m.err
+}
+
+func (m settingsClientMock) Identify() error {
+	settingsCalls = append(settingsCalls, "identify")
+	return m.err
+}
+
+func setupSettingsTest(clientErr error) func() {
+	config.InitConfig("", &settingsCfg)
+	settingsRepo = repositories.NewDeviceRepository(&settingsCfg)
+	settingsSvc = NewSettingsService(&settingsCfg, &settingsRepo)
+	settingsRepo.Store(domain.DeviceInfo{Name: "stagebox", Id: "001dc1fffe000001", IPv4: net.ParseIP("127.0.0.1")})
+	settingsRepo.Store(domain.DeviceInfo{Name: "mixer"})
+	settingsCalls = nil
+	current := domain.DeviceSettings{SampleRate: 48000, Encoding: 24, LatencyNs: 1000000, PullUp: domain.PullUpNone}
+	origClient := newSettingsClient
+	newSettingsClient = func(ip net.IP, port int, timeout time.Duration) settings.SettingsClient {
+		return settingsClientMock{current: &current, err: clientErr}
+	}
+	return func() {
+		newSettingsClient = origClient
+	}
+}
+
+func TestReadSettingsUnknownDeviceReturnsNotFound(t *testing.T) {
+	teardown := setupSettingsTest(nil)
+	defer teardown()
+	_, err := settingsSvc.Read("unknown")
+
+	assert.NotNil(t, err)
+	assert.EqualValues(t, http.StatusNotFound, err.StatusCode())
+	assert.EqualValues(t, "device unknown does not exist or its name is not unique", err.Message())
+}
+
+func TestReadSettingsWithoutAddressReturnsBadRequest(t *testing.T) {
+	teardown := setupSettingsTest(nil)
+	defer teardown()
+	_, err := settingsSvc.Read("mixer")
+
+	assert.NotNil(t, err)
+	assert.EqualValues(t, http.StatusBadRequest, err.StatusCode())
+}
+
+func TestReadSettingsStoresSettingsOnDevice(t *testing.T) {
+	teardown := setupSettingsTest(nil)
+	defer teardown()
+	current, err := settingsSvc.Read("stagebox")
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, 48000, current.SampleRate)
+	assert.EqualValues(t, 48000, settingsRepo.GetByName("stagebox").Settings.SampleRate)
+	assert.True(t, settingsRepo.GetByName("stagebox").Settings.Known())
+}
+
+func TestUpdateSettingsInvalidValueReturnsBadRequest(t *testing.T) {
+	teardown := setupSettingsTest(nil)
+	defer teardown()
+	_, err := settingsSvc.Update("stagebox", domain.DeviceSettings{SampleRate: 47000, Encoding: 24, LatencyNs: 1000000})
+
+	assert.NotNil(t, err)
+	assert.EqualValues(t, http.StatusBadRequest, err.StatusCode())
+	assert.EqualValues(t, "unsupported sample rate 47000", err.Message())
+	assert.Empty(t, settingsCalls)
+}
+
+func TestUpdateSettingsWritesChangedSettingsOnly(t *testing.T) {
+	teardown := setupSettingsTest(nil)
+	defer teardown()
+	current, err := settingsSvc.Update("stagebox", domain.DeviceSettings{SampleRate: 96000, Encoding: 24, LatencyNs: 2000000, PullUp: domain.PullUpNone})
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, []string{"get", "sample rate", "latency", "get"}, settingsCalls)
+	assert.EqualValues(t, 96000, current.SampleRate)
+	assert.EqualValues(t, 2000000, settingsRepo.GetByName("stagebox").Settings.LatencyNs)
+}
+
+func TestUpdateSettingsClientErrorReturnsInternalServerError(t *testing.T) {
+	teardown := setupSettingsTest(errors.New("no response"))
+	defer teardown()
+	_, err := settingsSvc.Update("stagebox", domain.DeviceSettings{SampleRate: 96000, Encoding: 24, LatencyNs: 1000000})
+
+	assert.NotNil(t, err)
+	assert.EqualValues(t, http.StatusInternalServerError, err.StatusCode())
+	assert.EqualValues(t, "could not read settings", err.Message())
+}
+
+func TestReadSettingsDuringScanKeepsLastKnownSettings(t *testing.T) {
+	teardown := setupSettingsTest(nil)
+	defer teardown()
+	svc, repo, _ := setupDeviceScanTest()
+	found := map[string]domain.DeviceInfo{"stagebox": {Name: "stagebox", IPv4: net.ParseIP("127.0.0.1"), LastSeen: time.Now()}}
+	svc.readSettings(found)
+	svc.storeDevice(found["stagebox"])
+
+	assert.EqualValues(t, 48000, repo.GetByName("stagebox").Settings.SampleRate)
+
+	svc.storeDevice(domain.DeviceInfo{Name: "stagebox", IPv4: net.ParseIP("127.0.0.1"), LastSeen: time.Now()})
+
+	assert.EqualValues(t, 48000, repo.GetByName("stagebox").Settings.SampleRate)
+}
+
+func TestIdentifyThrottlesRepeatedRequests(t *testing.T) {
+	teardown := setupSettingsTest(nil)
+	defer teardown()
+	first := settingsSvc.Identify("stagebox")
+	second := settingsSvc.Identify("001dc1fffe000001")
+
+	assert.Nil(t, first)
+	assert.NotNil(t, second)
+	assert.EqualValues(t, http.StatusTooManyRequests, second.StatusCode())
+	assert.EqualValues(t, "device 001dc1fffe000001 was asked to identify itself less than 5s ago", second.Message())
+	assert.EqualValues(t, []string{"identify"}, settingsCalls)
+}
+
+func TestIdentifyAfterThrottleTimeSendsAgain(t *testing.T) {
+	teardown := setupSettingsTest(nil)
+	defer teardown()
+	settingsCfg.DeviceScan.IdentifyThrottleSec = 0
+	settingsSvc.Identify("stagebox")
+	err := settingsSvc.Identify("stagebox")
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, []string{"identify", "identify"}, settingsCalls)
+}
+
+func TestIdentifyFailedSendDoesNotThrottle(t *testing.T) {
+	teardown := setupSettingsTest(errors.New("network unreachable"))
+	defer teardown()
+	first := settingsSvc.Identify("stagebox")
+	second := settingsSvc.Identify("stagebox")
+
+	assert.EqualValues(t, http.StatusInternalServerError, first.StatusCode())
+	assert.EqualValues(t, http.StatusInternalServerError, second.StatusCode())
+	assert.EqualValues(t, []string{"identify", "identify"}, settingsCalls)
+}
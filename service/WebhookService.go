@@ -0,0 +1,201 @@
+// package service implements the services and their business logic that provide the main part of the program
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/johannes-kuhfuss/alighieri/config"
+	"github.com/johannes-kuhfuss/alighieri/domain"
+	"github.com/johannes-kuhfuss/services_utils/logger"
+)
+
+// EventNotifier is informed about every event published by the event service
+type EventNotifier interface {
+	Notify(domain.Event)
+}
+
+type WebhookService interface {
+	Notify(domain.Event)
+	Deliveries() domain.WebhookDeliveryList
+}
+
+// The WebhookService posts device events to the webhook targets defined in the configuration and keeps a log of the deliveries
+type DefaultWebhookService struct {
+	Cfg       *config.AppConfig
+	Client    *http.Client
+	templates map[string]*template.Template
+	log       *domain.SafeWebhookDeliveryList
+	pending   *sync.WaitGroup
+}
+
+// eventPayload is the JSON representation of an event sent to webhooks and MQTT and passed to payload templates
+type eventPayload struct {
+	Date     time.Time `json:"date"`
+	Type     string    `json:"type"`
+	Device   string    `json:"device"`
+	Property string    `json:"property,omitempty"`
+	OldValue string    `json:"oldValue,omitempty"`
+	NewValue string    `json:"newValue,omitempty"`
+}
+
+var (
+	payloadFuncs = template.FuncMap{
+		"json": func(v any) (string, error) {
+			data, err := json.Marshal(v)
+			return string(data), err
+		},
+	}
+)
+
+// NewWebhookService creates a new webhook service and loads the payload templates of the webhook targets. Targets with an invalid template get the default payload
+func NewWebhookService(cfg *config.AppConfig) DefaultWebhookService {
+	s := DefaultWebhookService{
+		Cfg: cfg,
+		Client: &http.Client{
+			Timeout: time.Duration(cfg.Webhooks.TimeOutSec) * time.Second,
+		},
+		templates: make(map[string]*template.Template),
+		log:       &domain.SafeWebhookDeliveryList{},
+		pending:   &sync.WaitGroup{},
+	}
+	for _, target := range cfg.Webhooks.Targets {
+		if target.TemplateFile == "" {
+			continue
+		}
+		tmpl, err := template.New(filepath.Base(target.TemplateFile)).Funcs(payloadFuncs).ParseFiles(target.TemplateFile)
+		if err != nil {
+			logger.Errorf("Could not load payload template for webhook %v. Sending default payload: %v", target.Name, err)
+			continue
+		}
+		s.templates[target.Name] = tmpl
+	}
+	return s
+}
+
+// Notify posts an event to all webhook targets interested in its type. Deliveries run in the background
+func (s DefaultWebhookService) Notify(e domain.Event) {
+	for _, target := range s.Cfg.Webhooks.Targets {
+		if !wantsEvent(target, e.Type) {
+			continue
+		}
+		s.pending.Add(1)
+		go func() {
+			defer s.pending.Done()
+			s.deliver(target, e)
+		}()
+	}
+}
+
+// Deliveries returns the logged deliveries, newest first
+func (s DefaultWebhookService) Deliveries() domain.WebhookDeliveryList {
+	s.log.RLock()
+	defer s.log.RUnlock()
+	list := slices.Clone(s.log.Deliveries)
+	slices.Reverse(list)
+	return list
+}
+
+// wait blocks until all running deliveries are finished
+func (s DefaultWebhookService) wait() {
+	s.pending.Wait()
+}
+
+// wantsEvent checks whether a target is interested in an event type. Targets without event types get all events
+func wantsEvent(target config.WebhookTarget, eventType domain.EventType) bool {
+	return len(target.Events) == 0 || slices.Contains(target.Events, string(eventType))
+}
+
+// deliver posts an event to a target. Failed attempts are retried with a doubled delay each time
+func (s DefaultWebhookService) deliver(target config.WebhookTarget, e domain.Event) {
+	delivery := domain.WebhookDelivery{
+		Date:   time.Now(),
+		Target: target.Name,
+		Url:    target.Url,
+		Event:  e,
+	}
+	body, err := s.payload(target, e)
+	if err != nil {
+		delivery.Error = fmt.Sprintf("could not render payload: %v", err)
+	} else {
+		delay := time.Duration(s.Cfg.Webhooks.RetryDelaySec) * time.Second
+		for attempt := 0; attempt <= s.Cfg.Webhooks.Retries; attempt++ {
+			if attempt > 0 {
+				time.Sleep(delay)
+				delay *= 2
+			}
+			delivery.Attempts++
+			delivery.StatusCode, err = s.post(target.Url, body)
+			if err == nil {
+				delivery.Success = true
+				delivery.Error = ""
+				break
+			}
+			delivery.Error = err.Error()
+		}
+	}
+	if delivery.Success {
+		logger.Infof("Sent %v event for device %v to webhook %v", e.Type, e.Device, target.Name)
+	} else {
+		logger.Errorf("Could not send %v event for device %v to webhook %v after %v attempt(s): %v", e.Type, e.Device, target.Name, delivery.Attempts, delivery.Error)
+	}
+	s.record(delivery)
+}
+
+// payload renders the body posted to a target, either with the target's template or as the default JSON payload
+func (s DefaultWebhookService) payload(target config.WebhookTarget, e domain.Event) ([]byte, error) {
+	data := newEventPayload(e)
+	tmpl, ok := s.templates[target.Name]
+	if !ok {
+		return json.Marshal(data)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// newEventPayload converts an event to its JSON representation
+func newEventPayload(e domain.Event) eventPayload {
+	return eventPayload{
+		Date:     e.Date,
+		Type:     string(e.Type),
+		Device:   e.Device,
+		Property: e.Property,
+		OldValue: e.OldValue,
+		NewValue: e.NewValue,
+	}
+}
+
+// post sends a payload to a URL. Any status code outside 2xx is an error
+func (s DefaultWebhookService) post(url string, body []byte) (int, error) {
+	resp, err := s.Client.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("webhook answered with status %v", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+// record adds a delivery to the log and drops the oldest deliveries beyond the configured maximum
+func (s DefaultWebhookService) record(delivery domain.WebhookDelivery) {
+	s.log.Lock()
+	defer s.log.Unlock()
+	s.log.Deliveries = append(s.log.Deliveries, delivery)
+	if limit := s.Cfg.Webhooks.MaxLog; limit > 0 && len(s.log.Deliveries) > limit {
+		s.log.Deliveries = append(domain.WebhookDeliveryList(nil), s.log.Deliveries[len(s.log.Deliveries)-limit:]...)
+	}
+}
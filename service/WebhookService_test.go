@@ -0,0 +1,146 @@
+package service
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/johannes-kuhfuss/alighieri/config"
+	"github.com/johannes-kuhfuss/alighieri/domain"
+	"github.com/johannes-kuhfuss/alighieri/repositories"
+	"github.com/stretchr/testify/assert"
+)
+
+// webhookReceiver records the bodies posted to it and fails the first requests with the given status codes
+func webhookReceiver(failures ...int) (*httptest.Server, *[]string) {
+	var bodies []string
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		call := int(calls.Add(1)) - 1
+		if call < len(failures) {
+			w.WriteHeader(failures[call])
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	return srv, &bodies
+}
+
+func setupWebhookTest(targets ...config.WebhookTarget) DefaultWebhookService {
+	var testCfg config.AppConfig
+	config.InitConfig("", &testCfg)
+	testCfg.Webhooks.Targets = targets
+	testCfg.Webhooks.RetryDelaySec = 0
+	return NewWebhookService(&testCfg)
+}
+
+func TestNotifyPostsDefaultPayload(t *testing.T) {
+	srv, bodies := webhookReceiver()
+	defer srv.Close()
+	s := setupWebhookTest(config.WebhookTarget{Name: "oncall", Url: srv.URL})
+
+	s.Notify(domain.Event{Type: domain.EventOffline, Device: "stagebox", NewValue: "offline"})
+	s.wait()
+	var payload map[string]any
+	json.Unmarshal([]byte((*bodies)[0]), &payload)
+	deliveries := s.Deliveries()
+
+	assert.EqualValues(t, 1, len(*bodies))
+	assert.EqualValues(t, "stagebox", payload["device"])
+	assert.EqualValues(t, "offline", payload["type"])
+	assert.EqualValues(t, 1, len(deliveries))
+	assert.True(t, deliveries[0].Success)
+	assert.EqualValues(t, http.StatusNoContent, deliveries[0].StatusCode)
+}
+
+func TestNotifySkipsTargetsNotInterestedInEvent(t *testing.T) {
+	srv, bodies := webhookReceiver()
+	defer srv.Close()
+	s := setupWebhookTest(config.WebhookTarget{Name: "oncall", Url: srv.URL, Events: []string{string(domain.EventOffline)}})
+
+	s.Notify(domain.Event{Type: domain.EventPortChanged, Device: "stagebox"})
+	s.wait()
+
+	assert.EqualValues(t, 0, len(*bodies))
+	assert.Nil(t, s.Deliveries())
+}
+
+func TestNotifyRetriesFailedDelivery(t *testing.T) {
+	srv, bodies := webhookReceiver(http.StatusInternalServerError, http.StatusBadGateway)
+	defer srv.Close()
+	s := setupWebhookTest(config.WebhookTarget{Name: "oncall", Url: srv.URL})
+
+	s.Notify(domain.Event{Type: domain.EventNewDevice, Device: "stagebox"})
+	s.wait()
+	deliveries := s.Deliveries()
+
+	assert.EqualValues(t, 1, len(*bodies))
+	assert.EqualValues(t, 3, deliveries[0].Attempts)
+	assert.True(t, deliveries[0].Success)
+	assert.EqualValues(t, "", deliveries[0].Error)
+}
+
+func TestNotifyGivesUpAfterRetries(t *testing.T) {
+	srv, _ := webhookReceiver(http.StatusInternalServerError, http.StatusInternalServerError, http.StatusInternalServerError, http.StatusInternalServerError, http.StatusInternalServerError)
+	defer srv.Close()
+	s := setupWebhookTest(config.WebhookTarget{Name: "oncall", Url: srv.URL})
+	s.Cfg.Webhooks.Retries = 1
+
+	s.Notify(domain.Event{Type: domain.EventNewDevice, Device: "stagebox"})
+	s.wait()
+	deliveries := s.Deliveries()
+
+	assert.EqualValues(t, 2, deliveries[0].Attempts)
+	assert.False(t, deliveries[0].Success)
+	assert.EqualValues(t, http.StatusInternalServerError, deliveries[0].StatusCode)
+	assert.Contains(t, deliveries[0].Error, "500")
+}
+
+func TestNotifyRendersPayloadTemplate(t *testing.T) {
+	srv, bodies := webhookReceiver()
+	defer srv.Close()
+	file := filepath.Join(t.TempDir(), "oncall.tmpl")
+	os.WriteFile(file, []byte(`{"text": {{ json (printf "%s is %s" .Device .Type) }}}`), 0600)
+	s := setupWebhookTest(config.WebhookTarget{Name: "oncall", Url: srv.URL, TemplateFile: file})
+
+	s.Notify(domain.Event{Type: domain.EventOffline, Device: "stage \"box\""})
+	s.wait()
+
+	assert.EqualValues(t, `{"text": "stage \"box\" is offline"}`, (*bodies)[0])
+}
+
+func TestDeliveriesKeepsConfiguredMaximumNewestFirst(t *testing.T) {
+	s := setupWebhookTest()
+	s.Cfg.Webhooks.MaxLog = 2
+
+	s.record(domain.WebhookDelivery{Target: "a"})
+	s.record(domain.WebhookDelivery{Target: "b"})
+	s.record(domain.WebhookDelivery{Target: "c"})
+	deliveries := s.Deliveries()
+
+	assert.EqualValues(t, 2, len(deliveries))
+	assert.EqualValues(t, "c", deliveries[0].Target)
+	assert.EqualValues(t, "b", deliveries[1].Target)
+}
+
+func TestEventServicePublishNotifiesWebhooks(t *testing.T) {
+	srv, bodies := webhookReceiver()
+	defer srv.Close()
+	s := setupWebhookTest(config.WebhookTarget{Name: "oncall", Url: srv.URL})
+	s.Cfg.Events.EventFile = ""
+	eventRepo := repositories.NewEventRepository(s.Cfg)
+	events := NewEventService(s.Cfg, &eventRepo, s)
+
+	events.Publish(domain.Event{Type: domain.EventIpChanged, Device: "stagebox"})
+	s.wait()
+
+	assert.EqualValues(t, 1, eventRepo.Size())
+	assert.EqualValues(t, 1, len(*bodies))
+}
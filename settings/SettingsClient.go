@@ -0,0 +1,221 @@
+// package settings implements a client for the Dante device settings protocol spoken on UDP port 8700. The port is not advertised via mDNS
+//
+// Each request and response starts with a 32 byte header, all values big endian:
+//
+//	0-1    protocol id (0xffff)
+//	2-3    length of the whole packet
+//	4-5    sequence number, echoed by the device
+//	6-7    zero
+//	8-13   MAC address of the sender, zero in requests
+//	14-15  zero
+//	16-23  vendor string "Audinate"
+//	24-25  message version (0x0727, 0x0731 for identify)
+//	26-27  opcode, one per setting
+//	28-29  zero in requests, result code in responses (0x0001 = success)
+//	30-31  message class (0x0064)
+//
+// The header is followed by the action (4 bytes, 0 = read, 1 = write) and the value of the setting (4 bytes). Read requests carry a zero value,
+// responses always carry the value now in effect. Identify requests consist of the header only and are not answered.
+package settings
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"sync/atomic"
+	"time"
+
+	"github.com/johannes-kuhfuss/alighieri/domain"
+)
+
+const (
+	protocolSettings uint16 = 0xffff
+	headerLength            = 32
+	packetLength            = headerLength + 8
+	messageVersion   uint16 = 0x0727
+	identifyVersion  uint16 = 0x0731
+	messageClass     uint16 = 0x0064
+	resultOk         uint16 = 0x0001
+	maxPacket               = 512
+
+	opSampleRate uint16 = 0x0081
+	opLatency    uint16 = 0x0082
+	opEncoding   uint16 = 0x0083
+	opPullUp     uint16 = 0x0084
+	opIdentify   uint16 = 0x0063
+
+	actionRead  uint32 = 0
+	actionWrite uint32 = 1
+)
+
+var (
+	vendor   = []byte("Audinate")
+	sequence atomic.Uint32
+)
+
+type SettingsClient interface {
+	GetSettings() (domain.DeviceSettings, error)
+	SetSampleRate(int) error
+	SetEncoding(int) error
+	SetLatency(int) error
+	SetPullUp(domain.PullUp) error
+	Identify() error
+}
+
+// The DefaultSettingsClient talks to the settings port of a single device
+type DefaultSettingsClient struct {
+	Addr    *net.UDPAddr
+	Timeout time.Duration
+}
+
+// NewSettingsClient creates a new settings client for the device reachable at the given address and port
+func NewSettingsClient(ip net.IP, port int, timeout time.Duration) DefaultSettingsClient {
+	return DefaultSettingsClient{
+		Addr:    &net.UDPAddr{IP: ip, Port: port},
+		Timeout: timeout,
+	}
+}
+
+// GetSettings reads sample rate, encoding, latency and pull-up/down of the device
+func (c DefaultSettingsClient) GetSettings() (settings domain.DeviceSettings, err error) {
+	if settings.SampleRate, err = c.read(opSampleRate); err != nil {
+		return domain.DeviceSettings{}, err
+	}
+	if settings.Encoding, err = c.read(opEncoding); err != nil {
+		return domain.DeviceSettings{}, err
+	}
+	latencyUs, err := c.read(opLatency)
+	if err != nil {
+		return domain.DeviceSettings{}, err
+	}
+	pullUp, err := c.read(opPullUp)
+	if err != nil {
+		return domain.DeviceSettings{}, err
+	}
+	settings.LatencyNs = latencyUs * int(time.Microsecond)
+	settings.PullUp = domain.PullUp(pullUp)
+	settings.ReadAt = time.Now()
+	return settings, nil
+}
+
+// SetSampleRate changes the sample rate of the device, e.g. to 48000
+func (c DefaultSettingsClient) SetSampleRate(sampleRate int) error {
+	return c.write(opSampleRate, sampleRate)
+}
+
+// SetEncoding changes the bit depth of the PCM encoding used by the device, e.g. to 24
+func (c DefaultSettingsClient) SetEncoding(encoding int) error {
+	return c.write(opEncoding, encoding)
+}
+
+// SetLatency changes the latency configured on the device. The device accepts microseconds, the value is given in nanoseconds like all latencies
+func (c DefaultSettingsClient) SetLatency(latencyNs int) error {
+	return c.write(opLatency, latencyNs/int(time.Microsecond))
+}
+
+// SetPullUp changes the pull-up or pull-down of the device's sample rate
+func (c DefaultSettingsClient) SetPullUp(pullUp domain.PullUp) error {
+	return c.write(opPullUp, int(pullUp))
+}
+
+// Identify makes the device flash its LEDs for a few seconds. The device does not answer, so only errors sending the request are returned
+func (c DefaultSettingsClient) Identify() error {
+	conn, err := net.DialUDP("udp", nil, c.Addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	packet := make([]byte, headerLength)
+	buildHeader(packet, identifyVersion, opIdentify, uint16(sequence.Add(1)))
+	_, err = conn.Write(packet)
+	return err
+}
+
+// read queries the value of a setting
+func (c DefaultSettingsClient) read(opcode uint16) (int, error) {
+	return c.request(opcode, actionRead, 0)
+}
+
+// write changes the value of a setting and checks that the device applied it
+func (c DefaultSettingsClient) write(opcode uint16, value int) error {
+	applied, err := c.request(opcode, actionWrite, value)
+	if err != nil {
+		return err
+	}
+	if applied != value {
+		return fmt.Errorf("device kept value %v instead of %v for opcode 0x%04x", applied, value, opcode)
+	}
+	return nil
+}
+
+// request sends a request to the device, waits for the matching response and returns the value it carries
+func (c DefaultSettingsClient) request(opcode uint16, action uint32, value int) (int, error) {
+	conn, err := net.DialUDP("udp", nil, c.Addr)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+	seq := uint16(sequence.Add(1))
+	if _, err := conn.Write(buildPacket(opcode, seq, action, uint32(value))); err != nil {
+		return 0, err
+	}
+	if err := conn.SetReadDeadline(time.Now().Add(c.Timeout)); err != nil {
+		return 0, err
+	}
+	buf := make([]byte, maxPacket)
+	for {
+		n, err := conn.Read(buf)
+		if err != nil {
+			return 0, fmt.Errorf("no response from %v for opcode 0x%04x: %w", c.Addr, opcode, err)
+		}
+		resp := buf[:n]
+		if len(resp) < headerLength || binary.BigEndian.Uint16(resp[4:]) != seq {
+			// stale or foreign packet, keep waiting for ours
+			continue
+		}
+		if err := checkResponse(resp, opcode); err != nil {
+			return 0, err
+		}
+		return int(binary.BigEndian.Uint32(resp[headerLength+4:])), nil
+	}
+}
+
+// buildPacket assembles a request packet
+func buildPacket(opcode uint16, seq uint16, action uint32, value uint32) []byte {
+	packet := make([]byte, packetLength)
+	buildHeader(packet, messageVersion, opcode, seq)
+	binary.BigEndian.PutUint32(packet[headerLength:], action)
+	binary.BigEndian.PutUint32(packet[headerLength+4:], value)
+	return packet
+}
+
+// buildHeader fills in the header of a request packet
+func buildHeader(packet []byte, version uint16, opcode uint16, seq uint16) {
+	binary.BigEndian.PutUint16(packet[0:], protocolSettings)
+	binary.BigEndian.PutUint16(packet[2:], uint16(len(packet)))
+	binary.BigEndian.PutUint16(packet[4:], seq)
+	copy(packet[16:24], vendor)
+	binary.BigEndian.PutUint16(packet[24:], version)
+	binary.BigEndian.PutUint16(packet[26:], opcode)
+	binary.BigEndian.PutUint16(packet[30:], messageClass)
+}
+
+// checkResponse validates the header of a response
+func checkResponse(resp []byte, opcode uint16) error {
+	if binary.BigEndian.Uint16(resp[0:]) != protocolSettings {
+		return fmt.Errorf("unexpected protocol id 0x%04x", binary.BigEndian.Uint16(resp[0:]))
+	}
+	if int(binary.BigEndian.Uint16(resp[2:])) != len(resp) {
+		return fmt.Errorf("length field %v does not match packet length %v", binary.BigEndian.Uint16(resp[2:]), len(resp))
+	}
+	if len(resp) < packetLength {
+		return fmt.Errorf("response to opcode 0x%04x too short", opcode)
+	}
+	if binary.BigEndian.Uint16(resp[26:]) != opcode {
+		return fmt.Errorf("unexpected opcode 0x%04x in response to 0x%04x", binary.BigEndian.Uint16(resp[26:]), opcode)
+	}
+	if result := binary.BigEndian.Uint16(resp[28:]); result != resultOk {
+		return fmt.Errorf("device returned result code 0x%04x for opcode 0x%04x", result, opcode)
+	}
+	return nil
+}
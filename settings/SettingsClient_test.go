@@ -0,0 +1,191 @@
+package settings
+
+import (
+	"encoding/binary"
+	"net"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/johannes-kuhfuss/alighieri/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+// emulator keeps the settings of a device in memory and answers read and write requests like the device's settings port
+type emulator struct {
+	mu       sync.Mutex
+	conn     *net.UDPConn
+	values   map[uint16]uint32
+	rejected []uint32 // values the emulated device does not support
+	silent   bool
+	requests [][]byte
+}
+
+func startEmulator(t *testing.T) *emulator {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := emulator{
+		conn: conn,
+		values: map[uint16]uint32{
+			opSampleRate: 48000,
+			opEncoding:   24,
+			opLatency:    1000,
+			opPullUp:     uint32(domain.PullUpNone),
+		},
+	}
+	go e.serve()
+	t.Cleanup(func() {
+		conn.Close()
+	})
+	return &e
+}
+
+func (e *emulator) serve() {
+	buf := make([]byte, maxPacket)
+	for {
+		n, addr, err := e.conn.ReadFromUDP(buf)
+		if err != nil {
+			return
+		}
+		req := append([]byte(nil), buf[:n]...)
+		e.mu.Lock()
+		e.requests = append(e.requests, req)
+		if e.silent || len(req) < packetLength {
+			e.mu.Unlock()
+			continue
+		}
+		opcode := binary.BigEndian.Uint16(req[26:])
+		resp := append([]byte(nil), req...)
+		binary.BigEndian.PutUint16(resp[28:], resultOk)
+		value, known := e.values[opcode]
+		switch {
+		case !known:
+			binary.BigEndian.PutUint16(resp[28:], 0x0022)
+		case binary.BigEndian.Uint32(req[headerLength:]) == actionWrite:
+			if requested := binary.BigEndian.Uint32(req[headerLength+4:]); !slices.Contains(e.rejected, requested) {
+				value = requested
+				e.values[opcode] = value
+			}
+		}
+		binary.BigEndian.PutUint32(resp[headerLength+4:], value)
+		e.mu.Unlock()
+		e.conn.WriteToUDP(resp, addr)
+	}
+}
+
+func (e *emulator) value(opcode uint16) uint32 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.values[opcode]
+}
+
+func (e *emulator) received() [][]byte {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.requests
+}
+
+func (e *emulator) client() DefaultSettingsClient {
+	addr := e.conn.LocalAddr().(*net.UDPAddr)
+	return NewSettingsClient(addr.IP, addr.Port, 500*time.Millisecond)
+}
+
+func TestGetSettingsReturnsSettings(t *testing.T) {
+	e := startEmulator(t)
+	settings, err := e.client().GetSettings()
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, 48000, settings.SampleRate)
+	assert.EqualValues(t, 24, settings.Encoding)
+	assert.EqualValues(t, 1000000, settings.LatencyNs)
+	assert.EqualValues(t, domain.PullUpNone, settings.PullUp)
+	assert.True(t, settings.Known())
+}
+
+func TestRequestSendsValidHeader(t *testing.T) {
+	e := startEmulator(t)
+	e.client().read(opEncoding)
+
+	requests := e.received()
+	assert.EqualValues(t, 1, len(requests))
+	req := requests[0]
+	assert.EqualValues(t, protocolSettings, binary.BigEndian.Uint16(req[0:]))
+	assert.EqualValues(t, len(req), binary.BigEndian.Uint16(req[2:]))
+	assert.EqualValues(t, "Audinate", string(req[16:24]))
+	assert.EqualValues(t, messageVersion, binary.BigEndian.Uint16(req[24:]))
+	assert.EqualValues(t, opEncoding, binary.BigEndian.Uint16(req[26:]))
+	assert.EqualValues(t, actionRead, binary.BigEndian.Uint32(req[headerLength:]))
+}
+
+func TestIdentifySendsHeaderOnly(t *testing.T) {
+	e := startEmulator(t)
+	err := e.client().Identify()
+
+	assert.Nil(t, err)
+	assert.Eventually(t, func() bool { return len(e.received()) == 1 }, time.Second, 10*time.Millisecond)
+	req := e.received()[0]
+	assert.EqualValues(t, headerLength, len(req))
+	assert.EqualValues(t, headerLength, binary.BigEndian.Uint16(req[2:]))
+	assert.EqualValues(t, identifyVersion, binary.BigEndian.Uint16(req[24:]))
+	assert.EqualValues(t, opIdentify, binary.BigEndian.Uint16(req[26:]))
+}
+
+func TestSetSampleRateChangesSampleRate(t *testing.T) {
+	e := startEmulator(t)
+	err := e.client().SetSampleRate(96000)
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, 96000, e.value(opSampleRate))
+}
+
+func TestSetLatencySendsMicroseconds(t *testing.T) {
+	e := startEmulator(t)
+	err := e.client().SetLatency(2000000)
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, 2000, e.value(opLatency))
+}
+
+func TestSetPullUpChangesPullUp(t *testing.T) {
+	e := startEmulator(t)
+	err := e.client().SetPullUp(domain.PullUpMinus01)
+
+	assert.Nil(t, err)
+	assert.EqualValues(t, domain.PullUpMinus01, e.value(opPullUp))
+}
+
+func TestSetEncodingNotAppliedReturnsError(t *testing.T) {
+	e := startEmulator(t)
+	e.mu.Lock()
+	e.rejected = []uint32{32}
+	e.mu.Unlock()
+	err := e.client().SetEncoding(32)
+
+	assert.NotNil(t, err)
+	assert.EqualValues(t, "device kept value 24 instead of 32 for opcode 0x0083", err.Error())
+}
+
+func TestGetSettingsUnsupportedSettingReturnsError(t *testing.T) {
+	e := startEmulator(t)
+	e.mu.Lock()
+	delete(e.values, opPullUp)
+	e.mu.Unlock()
+	_, err := e.client().GetSettings()
+
+	assert.NotNil(t, err)
+	assert.EqualValues(t, "device returned result code 0x0022 for opcode 0x0084", err.Error())
+}
+
+func TestRequestNoResponseReturnsError(t *testing.T) {
+	e := startEmulator(t)
+	e.mu.Lock()
+	e.silent = true
+	e.mu.Unlock()
+	_, err := e.client().GetSettings()
+
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "no response from")
+}